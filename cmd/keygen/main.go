@@ -0,0 +1,99 @@
+/*
+Keygen - is a command that generates cipher keys of uid cookie and rotates them in key file.
+
+How to use:
+	go run cmd/keygen/main.go [-flag]
+The flags are:
+	-k
+		Sets COOKIE_KEYS_FILE. New key is added to that file and becomes active, previous keys are kept
+		to decrypt already issued cookies. If no file is provided, key is printed in format of COOKIE_KEYS.
+	-id
+		ID of new key. Defaults to current UTC time.
+	-keep
+		Amount of the newest keys kept in file, older ones are removed. 0 keeps all keys.
+	-inactive
+		If flag provided, new key is added to file without becoming active, so it can be distributed
+		to all replicas before it's used.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var (
+	keysFile   = flag.String("k", config.CookieKeysFile(), "COOKIE_KEYS_FILE")
+	keyID      = flag.String("id", "k"+time.Now().UTC().Format("20060102150405"), "ID of new key")
+	keep       = flag.Int("keep", 0, "amount of the newest keys kept in file")
+	isInactive = flag.Bool("inactive", false, "add key without making it active")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := generate(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate - generates new key and prints it or adds it to key file.
+func generate() error {
+	key, err := utils.GenerateKey(*keyID)
+	if err != nil {
+		return err
+	}
+
+	if *keysFile == "" {
+		fmt.Printf("%s:%s\n", key.ID, key.Secret)
+		return nil
+	}
+
+	f, err := utils.ReadKeyFile(*keysFile)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range f.Keys {
+		if k.ID == key.ID {
+			return fmt.Errorf("key %q already exists in %s", key.ID, *keysFile)
+		}
+	}
+
+	f.Keys = append([]utils.KeyFileKey{key}, f.Keys...)
+	if !*isInactive || f.Active == "" {
+		f.Active = key.ID
+	}
+
+	if *keep > 0 && len(f.Keys) > *keep {
+		f.Keys = f.Keys[:*keep]
+	}
+
+	if !hasKey(f, f.Active) {
+		return fmt.Errorf("active key %q would be removed, increase -keep", f.Active)
+	}
+
+	if err = utils.WriteKeyFile(*keysFile, f); err != nil {
+		return err
+	}
+
+	fmt.Printf("Key %s added to %s, active key: %s, keys: %d\n", key.ID, *keysFile, f.Active, len(f.Keys))
+
+	return nil
+}
+
+// hasKey - reports whether key file has key with provided ID.
+func hasKey(f utils.KeyFile, id string) bool {
+	for _, k := range f.Keys {
+		if k.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
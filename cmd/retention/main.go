@@ -0,0 +1,95 @@
+/*
+Retention - is a one-shot command that physically removes soft deleted links from File/Database storage.
+
+How to use:
+	go run cmd/retention/main.go [-flag]
+The flags are:
+	-f
+		Sets FILE_STORAGE_PATH.
+	-d
+		Sets DATABASE_DSN.
+	-r
+		Sets RETENTION_DAYS. Links soft deleted more than this amount of days ago are removed.
+	-q
+		Sets QUARANTINE_DAYS. Keys of removed links can't be reused during this amount of days.
+	-n
+		Sets RETENTION_BATCH_SIZE.
+	-c
+		Path to config file. Must be in .json format.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+func init() {
+	fileStoragePath := flag.String("f", config.FileStoragePath(), "FILE_STORAGE_PATH")
+	databaseDSN := flag.String("d", config.DatabaseDSN(), "DATABASE_DSN")
+	retentionDays := flag.Int("r", config.RetentionDays(), "RETENTION_DAYS")
+	quarantineDays := flag.Int("q", config.QuarantineDays(), "QUARANTINE_DAYS")
+	batchSize := flag.Int("n", config.RetentionBatchSize(), "RETENTION_BATCH_SIZE")
+	usingJSON := flag.String("c", config.JSONConfigPath(), "CONFIG PATH")
+
+	flag.Parse()
+
+	c := config.NewConfig(
+		config.WithFileStoragePath(*fileStoragePath),
+		config.WithDatabaseConnection(*databaseDSN),
+		config.WithRetentionDays(*retentionDays),
+		config.WithQuarantineDays(*quarantineDays),
+		config.WithRetentionBatchSize(*batchSize),
+		config.WithJSONConfig(*usingJSON),
+	)
+
+	if c.JSONConfigPath != "" {
+		c.SetJSONValues()
+	}
+}
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer stop()
+
+	purged, err := purge(ctx, logger)
+	fmt.Printf("Purged links: %d\n", purged)
+	if err != nil {
+		logger.Fatal(err.Error(), zap.Error(err))
+	}
+}
+
+// purge - creates storage based on config options and purges soft deleted links from it once.
+func purge(ctx context.Context, logger *zap.Logger) (int, error) {
+	if config.RetentionDays() <= 0 {
+		return 0, errors.New("RETENTION_DAYS must be positive to purge soft deleted links")
+	}
+
+	s, err := storage.NewStorage(logger)
+	if err != nil {
+		return 0, err
+	}
+
+	retentionStorage, ok := s.(storage.Retention)
+	if !ok {
+		return 0, errors.New("storage does not support purging of soft deleted links")
+	}
+
+	return service.NewRetentionService(retentionStorage, nil, logger).Purge(ctx)
+}
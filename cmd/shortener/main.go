@@ -17,16 +17,19 @@ The flags are:
 	-c
 		Path to config file. Must be in .json format.
 	-t
-		Sets Trusted Subnet
+		Sets Trusted Subnet, comma separated IPv4/IPv6 CIDRs.
+	-k
+		Sets COOKIE_KEYS_FILE. Keys can be generated by cmd/keygen.
 */
 package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"expvar"
 	"flag"
 	"fmt"
-	"log"
 	"math/rand"
 	"net"
 	"net/http"
@@ -34,6 +37,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -41,17 +45,26 @@ import (
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/acme/autocert"
+	googleGRPC "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/grpc"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/handlers"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/jobs"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/scheduler"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 	"github.com/sergalkin/go-url-shortener.git/pkg/certificate"
 	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
 )
 
+// shutdownTimeout - time given to the service to finish requests and drain queues on shutdown.
+const shutdownTimeout = 30 * time.Second
+
 var (
 	buildVersion string
 	buildDate    string
@@ -66,6 +79,7 @@ func init() {
 	enableHTTPS := flag.Bool("s", config.EnableHTTPS(), "ENABLE_HTTPS")
 	trustedSubnet := flag.String("t", config.TrustedSubnet(), "TRUSTED_SUBNET")
 	grpcPort := flag.String("g", config.GRPCPort(), "GRPC_PORT")
+	cookieKeysFile := flag.String("k", config.CookieKeysFile(), "COOKIE_KEYS_FILE")
 	usingJSON := flag.String("c", config.JSONConfigPath(), "CONFIG PATH")
 
 	flag.Parse()
@@ -78,6 +92,7 @@ func init() {
 		config.WithEnableHTTPS(*enableHTTPS),
 		config.WithTrustedSubnet(*trustedSubnet),
 		config.WithGRPCPort(*grpcPort),
+		config.WithCookieKeysFile(*cookieKeysFile),
 		config.WithJSONConfig(*usingJSON),
 	)
 
@@ -99,20 +114,114 @@ func main() {
 
 	rand.Seed(time.Now().UnixNano())
 
+	keyring, err := utils.LoadKeyring(config.CookieKeys(), config.CookieKeysFile(), config.CookieActiveKey())
+	if err != nil {
+		logger.Fatal(err.Error(), zap.Error(err))
+	}
+	if keyring == nil {
+		logger.Warn("cookie keys are not configured, random key is used and users are logged out on restart")
+	} else {
+		utils.SetKeyring(keyring)
+	}
+
+	signer, isEphemeral, err := auth.LoadSigner(config.TokenAlgorithm(), config.TokenSecret(), config.TokenKeyFile())
+	if err != nil {
+		logger.Fatal(err.Error(), zap.Error(err))
+	}
+	if isEphemeral {
+		logger.Warn("token secret is not configured, random secret is used and tokens are invalidated on restart")
+	}
+	tokens := auth.NewTokens(signer, config.TokenTTL(), config.TokenMaxTTL())
+	authHandler := handlers.NewAuthHandler(tokens)
+
+	s, err := storage.NewStorage(logger)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	apiKeys := auth.NewAPIKeys(storage.NewKeyStore(s, logger), config.APIKeyRateLimit(), logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeys)
+
+	rateStore := auth.RateStore(auth.NewMemoryRates())
+	if config.RateLimitShared() {
+		rateStore = storage.NewRateStore(s, logger)
+	}
+	limiter := auth.NewRateLimits(rateStore, map[string]int{
+		auth.RateShorten:  config.RateLimitShorten(),
+		auth.RateBatch:    config.RateLimitBatch(),
+		auth.RateRedirect: config.RateLimitRedirect(),
+		auth.RateDelete:   config.RateLimitDelete(),
+		auth.RateLogin:    config.RateLimitLogin(),
+	}, logger)
+
+	var merger auth.LinkMerger
+	if m, ok := s.(storage.Merger); ok {
+		merger = m
+	}
+	accounts := auth.NewAccounts(storage.NewAccountStore(s, logger), merger, config.SessionTTL(), logger)
+	accountHandler := handlers.NewAccountHandler(accounts)
+
+	transfers := auth.NewTransfers(storage.NewTransferStore(s, logger), merger, config.TransferCodeTTL(), logger)
+	transferHandler := handlers.NewTransferHandler(transfers)
+
+	workspaces := auth.NewWorkspaces(storage.NewWorkspaceStore(s, logger), logger)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaces)
+	inWorkspace := middleware.Workspace(workspaces)
+
+	var oidcHandler *handlers.OIDCHandler
+	if config.OIDCIssuer() != "" {
+		roles, errRoles := auth.ParseRoleMapping(config.OIDCRoles())
+		if errRoles != nil {
+			logger.Fatal(errRoles.Error(), zap.Error(errRoles))
+		}
+
+		oidc := auth.NewOIDC(auth.OIDCConfig{
+			Issuer:       config.OIDCIssuer(),
+			ClientID:     config.OIDCClientID(),
+			ClientSecret: config.OIDCClientSecret(),
+			RedirectURL:  config.OIDCRedirectURL(),
+			Scopes:       config.OIDCScopes(),
+			UserClaim:    config.OIDCUserClaim(),
+			GroupsClaim:  config.OIDCGroupsClaim(),
+			RoleMapping:  roles,
+		}, &http.Client{Timeout: 10 * time.Second})
+		oidcHandler = handlers.NewOIDCHandler(oidc, accounts)
+	}
+
+	headers := middleware.HeadersPolicyFromConfig()
 	r := chi.NewRouter()
 	r.Use(
+		middleware.Headers(headers, map[string]middleware.HeadersPolicy{
+			"/api/internal": headers.WithoutCORS(),
+			"/api/admin":    headers.WithoutCORS(),
+		}),
 		chiMiddleware.Compress(5),
 		middleware.Gzip,
-		middleware.Cookie,
+		middleware.Bearer(tokens),
+		middleware.APIKey(apiKeys),
+		middleware.Session(accounts),
+		middleware.Cookie(logger),
 	)
+	seq := sequence.NewSequence()
 
-	s, err := storage.NewStorage(logger)
-	if err != nil {
-		fmt.Println(err)
+	usage, ok := s.(storage.Usage)
+	if !ok {
+		logger.Fatal("storage does not support counting of links for quotas")
 	}
-	seq := sequence.NewSequence()
+	quotaService := service.NewQuotaService(
+		usage,
+		storage.NewQuotaStore(s, logger),
+		storage.NewQuotaLocker(s, logger),
+		storage.Quota{
+			MaxLinks: config.QuotaMaxLinks(),
+			MaxBatch: config.QuotaMaxBatch(),
+			MaxDaily: config.QuotaMaxDaily(),
+		},
+		logger,
+	)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
 
-	shortenService := service.NewURLShortenerService(s, seq, logger)
+	shortenService := service.NewQuotaShortener(service.NewURLShortenerService(s, seq, logger), quotaService)
 	shortenHandler := handlers.NewURLShortenerHandler(shortenService)
 
 	expandService := service.NewURLExpandService(s, logger)
@@ -121,6 +230,14 @@ func main() {
 	internalService := service.NewInternalService(s, logger)
 	internalHandler := handlers.NewInternalHandler(internalService)
 
+	moderation, ok := s.(storage.Moderation)
+	if !ok {
+		logger.Fatal("storage does not support moderation of links")
+	}
+	adminService := service.NewAdminService(moderation, logger)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	notBanned := middleware.RejectBanned(adminService)
+
 	db, err := storage.NewDBConnection(logger, true)
 	if err != nil {
 		fmt.Println(err)
@@ -129,53 +246,225 @@ func main() {
 	ctxContext, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
 	defer stop()
 
+	jobStore := storage.NewJobStore(s, logger)
+	jobRunner := jobs.NewRunner(jobStore, logger)
+	jobRunner.Register(storage.KindDeleteURLs, storage.DeleteJobHandler(s))
+
+	taskScheduler := scheduler.New(storage.NewSchedulerBackend(s, logger), logger)
+	schedulerHandler := handlers.NewSchedulerHandler(taskScheduler)
+
+	retentionStorage, hasRetention := s.(storage.Retention)
+	if hasRetention {
+		retentionService := service.NewRetentionService(retentionStorage, jobStore, logger)
+		jobRunner.Register(service.KindPurgeDeleted, retentionService.HandleJob)
+		taskScheduler.Add(scheduler.Task{
+			Name:     "retention",
+			Interval: config.RetentionInterval(),
+			Run:      retentionService.Enqueue,
+		})
+	}
+
+	taskScheduler.Add(scheduler.Task{
+		Name:     "ttl",
+		Interval: config.TTLPurgeInterval(),
+		Run:      service.NewTTLService(retentionStorage, jobStore, logger).Purge,
+	})
+
+	taskScheduler.Add(scheduler.Task{
+		Name:         "stats",
+		Interval:     config.StatsRollupInterval(),
+		Run:          internalService.Rollup,
+		EveryReplica: true,
+	})
+
+	taskScheduler.Add(scheduler.Task{
+		Name:     "sessions",
+		Interval: time.Hour,
+		Run:      accounts.PurgeSessions,
+	})
+
+	taskScheduler.Add(scheduler.Task{
+		Name:     "transfers",
+		Interval: time.Hour,
+		Run:      transfers.PurgeCodes,
+	})
+
+	taskScheduler.Add(scheduler.Task{
+		Name:     "rate_buckets",
+		Interval: 10 * time.Minute,
+		Run:      limiter.PurgeBuckets,
+	})
+
+	jobRunner.Start()
+	go taskScheduler.Run(ctxContext)
+
 	ctx, cancel := context.WithTimeout(ctxContext, 30*time.Second)
 	defer cancel()
 	defer db.Close(ctx)
 	dbHandler := handlers.NewDBHandler(db, logger)
-	batchHandler := handlers.NewBatchHandler(db, logger)
-	deleteHandler := handlers.NewURLDeleteHandler(service.NewURLDeleteService(db, logger))
+	batchHandler := handlers.NewBatchHandler(db, quotaService, logger)
+	deleteQueue := storage.NewDeleteQueue(storage.NewJobDeleter(jobStore), logger)
+	deleteQueue.Start()
+	deleteHandler := handlers.NewURLDeleteHandler(service.NewURLDeleteService(deleteQueue, logger))
+	csrfHandler := handlers.NewCSRFHandler()
 
 	r.Route("/", func(r chi.Router) {
-		r.Post("/", shortenHandler.ShortenURL)
-		r.Get("/{id}", expandHandler.ExpandURL)
+		r.Use(middleware.CSRF(config.CSRFMode()))
+		r.With(middleware.RateLimit(limiter, auth.RateShorten), middleware.RequireScope(auth.ScopeShorten), notBanned, inWorkspace).
+			Post("/", shortenHandler.ShortenURL)
+		r.With(middleware.RateLimit(limiter, auth.RateRedirect)).Get("/{id}", expandHandler.ExpandURL)
 		r.Get("/ping", dbHandler.Ping)
 	})
 
 	r.Route("/api", func(r chi.Router) {
-		r.Post("/shorten", shortenHandler.APIShortenURL)
-		r.Post("/shorten/batch", batchHandler.BatchInsert)
-		r.Get("/user/urls", expandHandler.UserURLs)
-		r.Delete("/user/urls", deleteHandler.Delete)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.TrustedSubnet)
-			r.Get("/internal/stats", internalHandler.Stats)
+			r.Use(middleware.CSRF(config.CSRFMode()))
+			r.Get("/csrf", csrfHandler.Token)
+			r.With(middleware.RateLimit(limiter, auth.RateShorten), middleware.RequireScope(auth.ScopeShorten), notBanned, inWorkspace).
+				Post("/shorten", shortenHandler.APIShortenURL)
+			r.With(middleware.RateLimit(limiter, auth.RateBatch), middleware.RequireScope(auth.ScopeShorten), notBanned, inWorkspace).
+				Post("/shorten/batch", batchHandler.BatchInsert)
+			r.With(middleware.RequireScope(auth.ScopeRead), inWorkspace).Get("/user/urls", expandHandler.UserURLs)
+			r.With(inWorkspace).Get("/user/quota", quotaHandler.Usage)
+			r.With(middleware.RateLimit(limiter, auth.RateDelete), middleware.RequireScope(auth.ScopeDelete), inWorkspace).
+				Delete("/user/urls", deleteHandler.Delete)
+			r.Post("/workspaces", workspaceHandler.Create)
+			r.Get("/workspaces", workspaceHandler.List)
+			r.Get("/workspaces/{id}/members", workspaceHandler.Members)
+			r.Put("/workspaces/{id}/members/{uid}", workspaceHandler.SetMember)
+			r.Delete("/workspaces/{id}/members/{uid}", workspaceHandler.RemoveMember)
+			r.Post("/auth/token", authHandler.IssueToken)
+			r.Post("/user/register", accountHandler.Register)
+			r.With(middleware.RateLimit(limiter, auth.RateLogin)).Post("/user/login", accountHandler.Login)
+			r.Post("/user/logout", accountHandler.Logout)
+			r.Post("/user/transfer", transferHandler.Issue)
+			r.Post("/user/transfer/redeem", transferHandler.Redeem)
+			if oidcHandler != nil {
+				r.Get("/auth/oidc/login", oidcHandler.Login)
+				r.Get("/auth/oidc/callback", oidcHandler.Callback)
+			}
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.TrustedSubnet)
+				r.Get("/internal/stats", internalHandler.Stats)
+				r.Get("/internal/metrics", expvar.Handler().ServeHTTP)
+				r.Get("/internal/scheduler", schedulerHandler.Statuses)
+				r.Post("/internal/keys", apiKeyHandler.Create)
+				r.Get("/internal/keys", apiKeyHandler.List)
+				r.Delete("/internal/keys/{id}", apiKeyHandler.Revoke)
+			})
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireAdmin, middleware.CSRF(config.CSRFAdminMode()))
+			r.Get("/admin/links", adminHandler.SearchLinks)
+			r.Delete("/admin/links", adminHandler.DeleteLinks)
+			r.Post("/admin/links/disable", adminHandler.DisableLinks)
+			r.Post("/admin/links/enable", adminHandler.EnableLinks)
+			r.Get("/admin/users/{uid}/links", adminHandler.UserLinks)
+			r.Put("/admin/users/{uid}/ban", adminHandler.BanUser)
+			r.Delete("/admin/users/{uid}/ban", adminHandler.UnbanUser)
+			r.Get("/admin/bans", adminHandler.Bans)
+			r.Get("/admin/users/{uid}/quota", quotaHandler.UserUsage)
+			r.Put("/admin/users/{uid}/quota", quotaHandler.SetOverride)
+			r.Delete("/admin/users/{uid}/quota", quotaHandler.DeleteOverride)
 		})
 	})
 
-	go startGRPCServer(db, internalService, shortenService, expandService)
+	health := grpc.NewHealth(db, logger)
+	go health.Run(ctxContext, config.GRPCHealthInterval())
+	grpcServer := startGRPCServer(
+		db, internalService, shortenService, expandService, adminService, deleteQueue, apiKeys, workspaces, limiter,
+		quotaService, tokens, health, logger, stop,
+	)
 
 	if config.EnableHTTPS() {
 		srv := startHTTPSServer(r, stop)
-		releaseResources(ctxContext, logger, srv, db)
+		releaseResources(ctxContext, logger, srv, grpcServer, health, db, deleteQueue, jobRunner)
 	} else {
 		srv := startHTTPServer(r, stop)
-		releaseResources(ctxContext, logger, srv, db)
+		releaseResources(ctxContext, logger, srv, grpcServer, health, db, deleteQueue, jobRunner)
 	}
 }
 
-// startGRPCServer - passed to gRPC server needed services and starts it.
-func startGRPCServer(db storage.DB, internal service.Internal, shorten service.URLShorten, expand service.URLExpand) {
-	server := grpc.NewServer(db, internal, shorten, expand)
+// startGRPCServer - passed to gRPC server needed services and starts it. Server uses TLS with GRPC_CERT_FILE
+// or, if it's not set but HTTPS is on, with certificate of HTTPS server. Errors of TLS config and listening
+// are reported like errors of HTTP server, service is stopped then.
+func startGRPCServer(
+	db storage.DB,
+	internal service.Internal,
+	shorten service.URLShorten,
+	expand service.URLExpand,
+	admin service.Admin,
+	deletes storage.DeleteQueuer,
+	keys auth.KeyAuthenticator,
+	workspaces auth.WorkspaceAuthorizer,
+	limiter auth.RateLimiter,
+	quotas service.Quotas,
+	verifier auth.Verifier,
+	health *grpc.Health,
+	l *zap.Logger,
+	stop context.CancelFunc,
+) *googleGRPC.Server {
+	var opts []googleGRPC.ServerOption
+	if config.GRPCTLS() {
+		tlsConfig, err := grpcTLSConfig()
+		if err != nil {
+			fmt.Println(err.Error())
+			stop()
+			return googleGRPC.NewServer()
+		}
+		opts = append(opts, googleGRPC.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(
+		db, internal, shorten, expand, admin, deletes, keys, workspaces, limiter, quotas, verifier, health, l, opts...,
+	)
 
 	listen, err := net.Listen("tcp", ":"+config.GRPCPort())
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println(err.Error())
+		stop()
+		return server
 	}
 
 	fmt.Println("gRPC Server started.")
-	if errServe := server.Serve(listen); errServe != nil {
-		log.Fatal(errServe)
+	go func() {
+		if errServe := server.Serve(listen); errServe != nil {
+			fmt.Println(errServe.Error())
+			stop()
+		}
+	}()
+
+	return server
+}
+
+// grpcTLSConfig - returns TLS config of gRPC server with GRPC_CERT_FILE and GRPC_KEY_FILE or with certificate
+// of HTTPS server, if they are not set.
+func grpcTLSConfig() (*tls.Config, error) {
+	certFile, keyFile := config.GRPCCertFile(), config.GRPCKeyFile()
+	if certFile == "" {
+		path, err := certificatePath()
+		if err != nil {
+			return nil, err
+		}
+		certFile, keyFile = fmt.Sprintf("%s/cert.crt", path), fmt.Sprintf("%s/cert.key", path)
+	}
+
+	return grpc.TLSConfig(certFile, keyFile, config.GRPCClientCAFile(), config.GRPCRequireClientCert())
+}
+
+// stopGRPCServer - stops gRPC server gracefully, waiting for calls in progress until ctx is done. Calls which
+// are not finished by then are canceled.
+func stopGRPCServer(ctx context.Context, server *googleGRPC.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
 	}
 }
 
@@ -194,23 +483,12 @@ func setDefaultValuesForBuildInfo() {
 
 // startHTTPSServer - starts HTTPS server if -s flag was provided.
 func startHTTPSServer(r *chi.Mux, stop context.CancelFunc) *http.Server {
-	pwd, errPwd := exec.Command("pwd").Output()
-	if errPwd != nil {
-		fmt.Println(errPwd)
+	path, errPath := certificatePath()
+	if errPath != nil {
+		fmt.Println(errPath)
 		stop()
 	}
 
-	var path string
-	if !strings.Contains(string(pwd), "/cmd/shortener") {
-		path = strings.TrimSuffix(string(pwd), "\n") + "/cmd/shortener"
-	} else {
-		path = "."
-	}
-
-	if _, err := os.Stat(fmt.Sprintf("%s/cert.key", path)); errors.Is(err, os.ErrNotExist) {
-		certificate.Generate(path)
-	}
-
 	// конструируем менеджер TLS-сертификатов
 	manager := &autocert.Manager{
 		// директория для хранения сертификатов
@@ -240,6 +518,28 @@ func startHTTPSServer(r *chi.Mux, stop context.CancelFunc) *http.Server {
 	return server
 }
 
+// certificatePath - returns directory of self-signed certificate of HTTPS server, certificate is generated
+// if there is none yet.
+func certificatePath() (string, error) {
+	pwd, err := exec.Command("pwd").Output()
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+	if !strings.Contains(string(pwd), "/cmd/shortener") {
+		path = strings.TrimSuffix(string(pwd), "\n") + "/cmd/shortener"
+	} else {
+		path = "."
+	}
+
+	if _, err = os.Stat(fmt.Sprintf("%s/cert.key", path)); errors.Is(err, os.ErrNotExist) {
+		certificate.Generate(path)
+	}
+
+	return path, nil
+}
+
 // startHTTPServer - starts HTTP server if -s flag was not provided.
 func startHTTPServer(r *chi.Mux, stop context.CancelFunc) *http.Server {
 	server := &http.Server{
@@ -259,8 +559,18 @@ func startHTTPServer(r *chi.Mux, stop context.CancelFunc) *http.Server {
 	return server
 }
 
-// releaseResources - realising resources, stopping db connection.
-func releaseResources(ctx context.Context, l *zap.Logger, srv *http.Server, db storage.DB) {
+// releaseResources - realising resources, stopping http and gRPC servers, draining delete queue, stopping job
+// workers and stopping db connection.
+func releaseResources(
+	ctx context.Context,
+	l *zap.Logger,
+	srv *http.Server,
+	grpcServer *googleGRPC.Server,
+	health *grpc.Health,
+	db storage.DB,
+	q *storage.DeleteQueue,
+	runner *jobs.Runner,
+) {
 	<-ctx.Done()
 	if ctx.Err() != nil {
 		fmt.Printf("Error:%v\n", ctx.Err())
@@ -268,10 +578,38 @@ func releaseResources(ctx context.Context, l *zap.Logger, srv *http.Server, db s
 
 	l.Info("The service is shutting down...")
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	health.Shutdown()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stopGRPCServer(shutdownCtx, grpcServer)
+		l.Info("gRPC server stopped")
+	}()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		l.Info("app error exit", zap.Error(err))
+	}
+	wg.Wait()
+
+	l.Info("Draining delete queue", zap.Int("depth", q.Depth()))
+	if err := q.Shutdown(shutdownCtx); err != nil {
+		l.Error("Could not drain delete queue", zap.Error(err))
+	}
+
+	l.Info("Stopping job workers")
+	if err := runner.Shutdown(shutdownCtx); err != nil {
+		l.Error("Could not stop job workers", zap.Error(err))
+	}
+
 	if db.HasNotNilConn() {
 		l.Info("Closing connection with database")
 
-		err := db.Close(ctx)
+		err := db.Close(shutdownCtx)
 		if err != nil {
 			l.Error("Could not close connection with database")
 		}
@@ -279,9 +617,5 @@ func releaseResources(ctx context.Context, l *zap.Logger, srv *http.Server, db s
 		l.Info("Connection with database closed")
 	}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		l.Info("app error exit", zap.Error(err))
-	}
-
 	l.Info("Done")
 }
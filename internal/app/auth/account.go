@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Limits of account credentials.
+const (
+	minLoginLen    = 3
+	maxLoginLen    = 64
+	minPasswordLen = 8
+	maxPasswordLen = 72 // bcrypt ignores bytes after 72nd
+)
+
+// externalLoginPrefix - prefix of logins of accounts created by identity provider, such logins can't be registered.
+const externalLoginPrefix = "oidc:"
+
+// Account - registered user. ID of account is used as user ID of its links.
+type Account struct {
+	CreatedAt    time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"-"`
+}
+
+// Session - login session of account. Token of session is never stored, only its SHA-256 hash.
+type Session struct {
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Hash      string
+	UID       string
+	Roles     []string
+}
+
+// AccountStore - storage of accounts and their sessions.
+type AccountStore interface {
+	// CreateAccount - stores new account. Returns utils.ErrLoginTaken if login is already used.
+	CreateAccount(ctx context.Context, a Account) error
+	// AccountByLogin - returns account with provided login or utils.ErrBadCredentials.
+	AccountByLogin(ctx context.Context, login string) (Account, error)
+	// SaveSession - stores new session.
+	SaveSession(ctx context.Context, s Session) error
+	// SessionByHash - returns session with provided hash or utils.ErrInvalidSession.
+	SessionByHash(ctx context.Context, hash string) (Session, error)
+	// DeleteSession - removes session with provided hash.
+	DeleteSession(ctx context.Context, hash string) error
+	// DeleteExpiredSessions - removes sessions expired before provided time and returns their count.
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int, error)
+}
+
+// LinkMerger - moves links of one user to another.
+type LinkMerger interface {
+	// MergeUserLinks - moves all links of user from to user to and returns count of moved links.
+	MergeUserLinks(ctx context.Context, from string, to string) (int, error)
+}
+
+// AccountManager - registers accounts and logs them in and out.
+type AccountManager interface {
+	// Register - creates account. Links of anonymous user anonUID are merged into it, if anonUID is not empty.
+	Register(ctx context.Context, login string, password string, anonUID string) (Account, error)
+	// Login - checks credentials and starts session, which token is returned. Links of anonymous user anonUID
+	// are merged into account, if anonUID is not empty.
+	Login(ctx context.Context, login string, password string, anonUID string) (string, Session, error)
+	// Logout - ends session.
+	Logout(ctx context.Context, token string) error
+}
+
+// ExternalLogin - logs in users authenticated by identity provider.
+type ExternalLogin interface {
+	// LoginExternal - creates account of identity on first login and starts session with roles of identity.
+	// Links of anonymous user anonUID are merged into account, if anonUID is not empty.
+	LoginExternal(ctx context.Context, id Identity, anonUID string) (string, Session, error)
+}
+
+// SessionAuthenticator - identifies requests by session tokens.
+type SessionAuthenticator interface {
+	// Session - returns not expired session with provided token or utils.ErrInvalidSession.
+	Session(ctx context.Context, token string) (Session, error)
+}
+
+// BanChecker - reports whether user was banned by admin.
+type BanChecker interface {
+	// IsBanned - reports whether user is banned.
+	IsBanned(ctx context.Context, uid string) (bool, error)
+}
+
+var _ AccountManager = (*Accounts)(nil)
+var _ SessionAuthenticator = (*Accounts)(nil)
+var _ ExternalLogin = (*Accounts)(nil)
+
+// Accounts - manages accounts and sessions stored in AccountStore.
+type Accounts struct {
+	store      AccountStore
+	merger     LinkMerger
+	logger     *zap.Logger
+	now        func() time.Time
+	dummyHash  []byte
+	sessionTTL time.Duration
+	cost       int
+}
+
+// NewAccounts - creates Accounts. Sessions expire after sessionTTL.
+func NewAccounts(s AccountStore, m LinkMerger, sessionTTL time.Duration, l *zap.Logger) *Accounts {
+	a := &Accounts{
+		store:      s,
+		merger:     m,
+		logger:     l,
+		now:        time.Now,
+		sessionTTL: sessionTTL,
+		cost:       bcrypt.DefaultCost,
+	}
+
+	// hash compared on login of unknown account, so existing logins can't be found by response time
+	a.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), a.cost)
+
+	return a
+}
+
+// Register - validates credentials, stores account with bcrypt hash of password and merges anonymous links into it.
+func (a *Accounts) Register(ctx context.Context, login string, password string, anonUID string) (Account, error) {
+	if n := utf8.RuneCountInString(login); n < minLoginLen || n > maxLoginLen || strings.HasPrefix(login, externalLoginPrefix) {
+		return Account{}, utils.ErrWrongLogin
+	}
+	if len(password) < minPasswordLen || len(password) > maxPasswordLen {
+		return Account{}, utils.ErrWeakPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		return Account{}, err
+	}
+
+	acc := Account{
+		CreatedAt:    a.now().UTC(),
+		ID:           uuid.New().String(),
+		Login:        login,
+		PasswordHash: string(hash),
+	}
+	if err = a.store.CreateAccount(ctx, acc); err != nil {
+		return Account{}, err
+	}
+
+	a.merge(ctx, anonUID, acc.ID)
+
+	return acc, nil
+}
+
+// Login - compares password with bcrypt hash of account, merges anonymous links into account and starts session.
+func (a *Accounts) Login(ctx context.Context, login string, password string, anonUID string) (string, Session, error) {
+	acc, err := a.store.AccountByLogin(ctx, login)
+	if errors.Is(err, utils.ErrBadCredentials) {
+		_ = bcrypt.CompareHashAndPassword(a.dummyHash, []byte(password))
+		return "", Session{}, err
+	}
+	if err != nil {
+		return "", Session{}, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(acc.PasswordHash), []byte(password)) != nil {
+		return "", Session{}, utils.ErrBadCredentials
+	}
+
+	a.merge(ctx, anonUID, acc.ID)
+
+	return a.startSession(ctx, acc.ID, nil)
+}
+
+// LoginExternal - creates account with ID of identity and login prefixed by "oidc:", if it doesn't exist,
+// merges anonymous links into it and starts session. Password of such account is empty, so it can't log in
+// with password.
+func (a *Accounts) LoginExternal(ctx context.Context, id Identity, anonUID string) (string, Session, error) {
+	acc := Account{
+		CreatedAt: a.now().UTC(),
+		ID:        id.UID,
+		Login:     externalLoginPrefix + id.Login,
+	}
+
+	err := a.store.CreateAccount(ctx, acc)
+	if errors.Is(err, utils.ErrLoginTaken) {
+		existing, errGet := a.store.AccountByLogin(ctx, acc.Login)
+		if errGet != nil {
+			return "", Session{}, errGet
+		}
+		if existing.ID != acc.ID {
+			return "", Session{}, utils.ErrLoginTaken
+		}
+		err = nil
+	}
+	if err != nil {
+		return "", Session{}, err
+	}
+
+	a.merge(ctx, anonUID, acc.ID)
+
+	return a.startSession(ctx, acc.ID, id.Roles)
+}
+
+// Logout - removes session from store.
+func (a *Accounts) Logout(ctx context.Context, token string) error {
+	return a.store.DeleteSession(ctx, HashSecret(token))
+}
+
+// Session - looks session up by hash of token.
+func (a *Accounts) Session(ctx context.Context, token string) (Session, error) {
+	s, err := a.store.SessionByHash(ctx, HashSecret(token))
+	if err != nil {
+		return Session{}, err
+	}
+	if !a.now().Before(s.ExpiresAt) {
+		return Session{}, utils.ErrInvalidSession
+	}
+
+	return s, nil
+}
+
+// startSession - stores session of account with random token.
+func (a *Accounts) startSession(ctx context.Context, uid string, roles []string) (string, Session, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", Session{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	now := a.now().UTC()
+	s := Session{
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.sessionTTL),
+		Hash:      HashSecret(token),
+		UID:       uid,
+		Roles:     roles,
+	}
+	if err := a.store.SaveSession(ctx, s); err != nil {
+		return "", Session{}, err
+	}
+
+	return token, s, nil
+}
+
+// PurgeSessions - removes expired sessions from store.
+func (a *Accounts) PurgeSessions(ctx context.Context) error {
+	n, err := a.store.DeleteExpiredSessions(ctx, a.now().UTC())
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("expired sessions purged", zap.Int("count", n))
+
+	return nil
+}
+
+// merge - moves links of anonymous user to account. Failed merge doesn't fail login, links stay with
+// anonymous user and are merged on next login.
+func (a *Accounts) merge(ctx context.Context, anonUID string, uid string) {
+	if a.merger == nil || anonUID == "" || anonUID == uid {
+		return
+	}
+
+	n, err := a.merger.MergeUserLinks(ctx, anonUID, uid)
+	if err != nil {
+		a.logger.Error("could not merge anonymous links", zap.String("uid", uid), zap.Error(err))
+		return
+	}
+
+	if n > 0 {
+		a.logger.Info("anonymous links merged", zap.String("uid", uid), zap.Int("count", n))
+	}
+}
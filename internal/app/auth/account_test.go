@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type mergerMock struct {
+	merged map[string]string
+}
+
+func (m *mergerMock) MergeUserLinks(ctx context.Context, from string, to string) (int, error) {
+	m.merged[from] = to
+	return 1, nil
+}
+
+func newTestAccounts() (*Accounts, *mergerMock) {
+	merger := &mergerMock{merged: map[string]string{}}
+	a := NewAccounts(NewMemoryAccounts(), merger, time.Hour, zap.NewNop())
+	a.cost = bcrypt.MinCost
+
+	return a, merger
+}
+
+func TestAccounts_Register(t *testing.T) {
+	tests := []struct {
+		wantErr  error
+		name     string
+		login    string
+		password string
+	}{
+		{
+			name:     "Account can be registered",
+			login:    "user",
+			password: "password",
+		},
+		{
+			name:     "Account with taken login can't be registered",
+			login:    "taken",
+			password: "password",
+			wantErr:  utils.ErrLoginTaken,
+		},
+		{
+			name:     "Account with short login can't be registered",
+			login:    "u",
+			password: "password",
+			wantErr:  utils.ErrWrongLogin,
+		},
+		{
+			name:     "Account with short password can't be registered",
+			login:    "user",
+			password: "pass",
+			wantErr:  utils.ErrWeakPassword,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, merger := newTestAccounts()
+			_, err := a.Register(context.Background(), "taken", "password", "")
+			require.NoError(t, err)
+
+			acc, err := a.Register(context.Background(), tt.login, tt.password, "anon")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, merger.merged)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEqual(t, "anon", acc.ID)
+			assert.NotEqual(t, tt.password, acc.PasswordHash)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(acc.PasswordHash), []byte(tt.password)))
+			assert.Equal(t, map[string]string{"anon": acc.ID}, merger.merged)
+		})
+	}
+}
+
+func TestAccounts_Login(t *testing.T) {
+	a, merger := newTestAccounts()
+	ctx := context.Background()
+
+	acc, err := a.Register(ctx, "user", "password", "")
+	require.NoError(t, err)
+
+	_, _, err = a.Login(ctx, "user", "wrong password", "anon")
+	assert.ErrorIs(t, err, utils.ErrBadCredentials)
+	_, _, err = a.Login(ctx, "unknown", "password", "anon")
+	assert.ErrorIs(t, err, utils.ErrBadCredentials)
+	assert.Empty(t, merger.merged, "links must not be merged on failed login")
+
+	token, s, err := a.Login(ctx, "user", "password", "anon")
+	require.NoError(t, err)
+	assert.Equal(t, acc.ID, s.UID)
+	assert.Equal(t, map[string]string{"anon": acc.ID}, merger.merged)
+
+	got, err := a.Session(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, acc.ID, got.UID)
+
+	a.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = a.Session(ctx, token)
+	assert.ErrorIs(t, err, utils.ErrInvalidSession)
+	require.NoError(t, a.PurgeSessions(ctx))
+
+	a.now = time.Now
+	_, err = a.Session(ctx, token)
+	assert.ErrorIs(t, err, utils.ErrInvalidSession, "expired session must be purged")
+
+	token, _, err = a.Login(ctx, "user", "password", "")
+	require.NoError(t, err)
+	require.NoError(t, a.Logout(ctx, token))
+	_, err = a.Session(ctx, token)
+	assert.ErrorIs(t, err, utils.ErrInvalidSession)
+}
+
+func TestAccounts_LoginExternal(t *testing.T) {
+	a, merger := newTestAccounts()
+	ctx := context.Background()
+	id := Identity{UID: "3f1c9d2e-0000-5000-8000-000000000001", Login: "42", Roles: []string{"admin"}}
+
+	token, s, err := a.LoginExternal(ctx, id, "anon")
+	require.NoError(t, err)
+	assert.Equal(t, id.UID, s.UID)
+	assert.Equal(t, []string{"admin"}, s.Roles)
+	assert.Equal(t, map[string]string{"anon": id.UID}, merger.merged)
+
+	got, err := a.Session(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, got.Roles)
+
+	id.Roles = nil
+	_, s, err = a.LoginExternal(ctx, id, "")
+	require.NoError(t, err, "existing account must be reused")
+	assert.Equal(t, id.UID, s.UID)
+	assert.Empty(t, s.Roles)
+
+	_, _, err = a.LoginExternal(ctx, Identity{UID: "other", Login: "42"}, "")
+	assert.ErrorIs(t, err, utils.ErrLoginTaken)
+
+	_, _, err = a.Login(ctx, externalLoginPrefix+"42", "", "")
+	assert.ErrorIs(t, err, utils.ErrBadCredentials, "external account can't log in with password")
+}
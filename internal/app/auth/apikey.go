@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+const (
+	// apiKeyPrefix - prefix of API keys, so leaked keys can be recognized by secret scanners.
+	apiKeyPrefix = "usk_"
+	// apiKeyHintLen - length of beginning of key which is stored as is to tell keys apart in listings.
+	apiKeyHintLen = 12
+	// lastUsedPrecision - how often last usage of key is written to store.
+	lastUsedPrecision = time.Minute
+)
+
+// APIKey - long-lived key of service account. Secret of key is never stored, only its SHA-256 hash.
+type APIKey struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ID         string     `json:"id"`
+	UID        string     `json:"uid"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"` // requests per minute
+}
+
+// KeyStore - storage of API keys.
+type KeyStore interface {
+	// SaveKey - stores new key.
+	SaveKey(ctx context.Context, k APIKey) error
+	// Keys - returns keys of user ordered by creation time, keys of all users if uid is empty.
+	Keys(ctx context.Context, uid string) ([]APIKey, error)
+	// KeyByHash - returns key with provided hash or utils.ErrInvalidAPIKey.
+	KeyByHash(ctx context.Context, hash string) (APIKey, error)
+	// RevokeKey - marks key as revoked. Returns utils.ErrAPIKeyNotFound if there is no such key.
+	RevokeKey(ctx context.Context, id string, at time.Time) error
+	// TouchKey - sets last usage time of key.
+	TouchKey(ctx context.Context, id string, at time.Time) error
+}
+
+// KeyManager - creates, lists and revokes API keys.
+type KeyManager interface {
+	// Create - creates key of user and returns its secret, which is shown only once. New user is created
+	// if uid is empty. Zero rate limit means default one.
+	Create(ctx context.Context, uid string, name string, scopes []string, rateLimit int) (string, APIKey, error)
+	// List - returns keys of user, keys of all users if uid is empty.
+	List(ctx context.Context, uid string) ([]APIKey, error)
+	// Revoke - revokes key, so it can't be used anymore.
+	Revoke(ctx context.Context, id string) error
+}
+
+// KeyAuthenticator - identifies requests by API keys.
+type KeyAuthenticator interface {
+	// Authenticate - returns not revoked key matching provided secret or utils.ErrInvalidAPIKey.
+	Authenticate(ctx context.Context, key string) (APIKey, error)
+	// Allow - takes request from rate limit of key. If limit is exceeded returns false and time
+	// after which request can be retried.
+	Allow(k APIKey) (bool, time.Duration)
+}
+
+var _ KeyManager = (*APIKeys)(nil)
+var _ KeyAuthenticator = (*APIKeys)(nil)
+
+// APIKeys - manages API keys stored in KeyStore and limits rate of their requests.
+type APIKeys struct {
+	store     KeyStore
+	logger    *zap.Logger
+	now       func() time.Time
+	buckets   *MemoryRates
+	rateLimit int
+}
+
+// NewAPIKeys - creates APIKeys. rateLimit is used for keys created without their own limit.
+func NewAPIKeys(s KeyStore, rateLimit int, l *zap.Logger) *APIKeys {
+	return &APIKeys{
+		store:     s,
+		logger:    l,
+		now:       time.Now,
+		buckets:   NewMemoryRates(),
+		rateLimit: rateLimit,
+	}
+}
+
+// Create - generates random key of user and stores its hash.
+func (a *APIKeys) Create(ctx context.Context, uid string, name string, scopes []string, rateLimit int) (string, APIKey, error) {
+	if rateLimit < 0 {
+		return "", APIKey{}, utils.ErrWrongRateLimit
+	}
+	if rateLimit == 0 {
+		rateLimit = a.rateLimit
+	}
+
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	if uid == "" {
+		uid = uuid.New().String()
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	k := APIKey{
+		CreatedAt: a.now().UTC(),
+		ID:        uuid.New().String(),
+		UID:       uid,
+		Name:      name,
+		Hint:      key[:apiKeyHintLen],
+		Hash:      HashSecret(key),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+	}
+
+	if err = a.store.SaveKey(ctx, k); err != nil {
+		return "", APIKey{}, err
+	}
+
+	return key, k, nil
+}
+
+// List - returns keys of user from store.
+func (a *APIKeys) List(ctx context.Context, uid string) ([]APIKey, error) {
+	return a.store.Keys(ctx, uid)
+}
+
+// Revoke - marks key as revoked in store.
+func (a *APIKeys) Revoke(ctx context.Context, id string) error {
+	if err := a.store.RevokeKey(ctx, id, a.now().UTC()); err != nil {
+		return err
+	}
+
+	a.buckets.forget(id)
+
+	return nil
+}
+
+// Authenticate - looks key up by hash of provided secret. Last usage of key is written to store
+// not more often than once a minute.
+func (a *APIKeys) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return APIKey{}, utils.ErrInvalidAPIKey
+	}
+
+	k, err := a.store.KeyByHash(ctx, HashSecret(key))
+	if err != nil {
+		return APIKey{}, err
+	}
+	if k.RevokedAt != nil {
+		return APIKey{}, utils.ErrInvalidAPIKey
+	}
+
+	now := a.now().UTC()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedPrecision {
+		if errTouch := a.store.TouchKey(ctx, k.ID, now); errTouch != nil {
+			a.logger.Error("could not save last usage of api key", zap.String("id", k.ID), zap.Error(errTouch))
+		}
+		k.LastUsedAt = &now
+	}
+
+	return k, nil
+}
+
+// Allow - takes token from bucket of key. Bucket holds RateLimit tokens and is refilled
+// with RateLimit tokens per minute.
+func (a *APIKeys) Allow(k APIKey) (bool, time.Duration) {
+	if k.RateLimit <= 0 {
+		return true, 0
+	}
+
+	return a.buckets.take(k.ID, k.RateLimit, ratePeriod, a.now())
+}
+
+// HashSecret - returns hex encoded SHA-256 hash of API key or session token. They are random and long enough,
+// so slow password hashes are not needed.
+func HashSecret(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
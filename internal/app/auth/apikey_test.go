@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestAPIKeys_Create(t *testing.T) {
+	tests := []struct {
+		wantErr   error
+		name      string
+		uid       string
+		scopes    []string
+		rateLimit int
+		want      APIKey
+	}{
+		{
+			name: "Key of a new user with all scopes and default rate limit can be created",
+			want: APIKey{Scopes: []string{ScopeDelete, ScopeRead, ScopeShorten}, RateLimit: 60},
+		},
+		{
+			name:      "Key of user with own scopes and rate limit can be created",
+			uid:       "user",
+			scopes:    []string{ScopeRead},
+			rateLimit: 10,
+			want:      APIKey{UID: "user", Scopes: []string{ScopeRead}, RateLimit: 10},
+		},
+		{
+			name:    "Key with unknown scope can't be created",
+			scopes:  []string{"superuser"},
+			wantErr: utils.ErrUnknownScope,
+		},
+		{
+			name:      "Key with negative rate limit can't be created",
+			rateLimit: -1,
+			wantErr:   utils.ErrWrongRateLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryKeys()
+			a := NewAPIKeys(store, 60, zap.NewNop())
+
+			key, k, err := a.Create(context.Background(), tt.uid, "ci", tt.scopes, tt.rateLimit)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(key, apiKeyPrefix))
+			assert.Equal(t, key[:apiKeyHintLen], k.Hint)
+			assert.Equal(t, HashSecret(key), k.Hash)
+			assert.NotContains(t, k.Hash, key)
+			assert.NotEmpty(t, k.UID)
+			if tt.want.UID != "" {
+				assert.Equal(t, tt.want.UID, k.UID)
+			}
+			assert.Equal(t, tt.want.Scopes, k.Scopes)
+			assert.Equal(t, tt.want.RateLimit, k.RateLimit)
+
+			keys, err := a.List(context.Background(), k.UID)
+			require.NoError(t, err)
+			assert.Equal(t, []APIKey{k}, keys)
+		})
+	}
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	a := NewAPIKeys(NewMemoryKeys(), 60, zap.NewNop())
+	ctx := context.Background()
+
+	key, created, err := a.Create(ctx, "user", "ci", nil, 0)
+	require.NoError(t, err)
+
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	k, err := a.Authenticate(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, k.ID)
+	require.NotNil(t, k.LastUsedAt)
+
+	keys, err := a.List(ctx, "user")
+	require.NoError(t, err)
+	require.NotNil(t, keys[0].LastUsedAt)
+	assert.True(t, keys[0].LastUsedAt.Equal(now.UTC()))
+
+	_, err = a.Authenticate(ctx, key+"x")
+	assert.ErrorIs(t, err, utils.ErrInvalidAPIKey)
+
+	_, err = a.Authenticate(ctx, "key")
+	assert.ErrorIs(t, err, utils.ErrInvalidAPIKey)
+
+	require.NoError(t, a.Revoke(ctx, k.ID))
+	_, err = a.Authenticate(ctx, key)
+	assert.ErrorIs(t, err, utils.ErrInvalidAPIKey)
+
+	assert.ErrorIs(t, a.Revoke(ctx, "unknown"), utils.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeys_Allow(t *testing.T) {
+	a := NewAPIKeys(NewMemoryKeys(), 60, zap.NewNop())
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	k := APIKey{ID: "key", RateLimit: 2}
+	other := APIKey{ID: "other", RateLimit: 2}
+
+	ok, _ := a.Allow(k)
+	assert.True(t, ok)
+	ok, _ = a.Allow(k)
+	assert.True(t, ok)
+
+	ok, retryAfter := a.Allow(k)
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	ok, _ = a.Allow(other)
+	assert.True(t, ok, "rate limits of keys must be independent")
+
+	now = now.Add(30 * time.Second)
+	ok, _ = a.Allow(k)
+	assert.True(t, ok)
+}
@@ -0,0 +1,408 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var _ KeyStore = (*MemoryKeys)(nil)
+
+// MemoryKeys - in-memory KeyStore used when service runs in memory or file mode.
+type MemoryKeys struct {
+	keys map[string]APIKey
+	mu   sync.RWMutex
+}
+
+// NewMemoryKeys - creates MemoryKeys.
+func NewMemoryKeys() *MemoryKeys {
+	return &MemoryKeys{keys: map[string]APIKey{}}
+}
+
+// SaveKey - stores key by its ID.
+func (m *MemoryKeys) SaveKey(ctx context.Context, k APIKey) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.keys[k.ID] = k
+
+	return nil
+}
+
+// Keys - returns keys of user ordered by creation time, keys of all users if uid is empty.
+func (m *MemoryKeys) Keys(ctx context.Context, uid string) ([]APIKey, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	keys := make([]APIKey, 0, len(m.keys))
+	for _, k := range m.keys {
+		if uid == "" || k.UID == uid {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// KeyByHash - returns key with provided hash.
+func (m *MemoryKeys) KeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	for _, k := range m.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+
+	return APIKey{}, utils.ErrInvalidAPIKey
+}
+
+// RevokeKey - marks key as revoked. Revocation time of already revoked key is kept.
+func (m *MemoryKeys) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	k, ok := m.keys[id]
+	if !ok {
+		return utils.ErrAPIKeyNotFound
+	}
+	if k.RevokedAt == nil {
+		k.RevokedAt = &at
+		m.keys[id] = k
+	}
+
+	return nil
+}
+
+// TouchKey - sets last usage time of key.
+func (m *MemoryKeys) TouchKey(ctx context.Context, id string, at time.Time) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if k, ok := m.keys[id]; ok {
+		k.LastUsedAt = &at
+		m.keys[id] = k
+	}
+
+	return nil
+}
+
+var _ AccountStore = (*MemoryAccounts)(nil)
+
+// MemoryAccounts - in-memory AccountStore used when service runs in memory or file mode.
+type MemoryAccounts struct {
+	accounts map[string]Account // by login
+	sessions map[string]Session // by hash
+	mu       sync.RWMutex
+}
+
+// NewMemoryAccounts - creates MemoryAccounts.
+func NewMemoryAccounts() *MemoryAccounts {
+	return &MemoryAccounts{accounts: map[string]Account{}, sessions: map[string]Session{}}
+}
+
+// CreateAccount - stores account by its login.
+func (m *MemoryAccounts) CreateAccount(ctx context.Context, a Account) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if _, ok := m.accounts[a.Login]; ok {
+		return utils.ErrLoginTaken
+	}
+	m.accounts[a.Login] = a
+
+	return nil
+}
+
+// AccountByLogin - returns account with provided login.
+func (m *MemoryAccounts) AccountByLogin(ctx context.Context, login string) (Account, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	a, ok := m.accounts[login]
+	if !ok {
+		return Account{}, utils.ErrBadCredentials
+	}
+
+	return a, nil
+}
+
+// SaveSession - stores session by its hash.
+func (m *MemoryAccounts) SaveSession(ctx context.Context, s Session) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.sessions[s.Hash] = s
+
+	return nil
+}
+
+// SessionByHash - returns session with provided hash.
+func (m *MemoryAccounts) SessionByHash(ctx context.Context, hash string) (Session, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	s, ok := m.sessions[hash]
+	if !ok {
+		return Session{}, utils.ErrInvalidSession
+	}
+
+	return s, nil
+}
+
+// DeleteSession - removes session with provided hash.
+func (m *MemoryAccounts) DeleteSession(ctx context.Context, hash string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.sessions, hash)
+
+	return nil
+}
+
+// DeleteExpiredSessions - removes sessions expired before provided time.
+func (m *MemoryAccounts) DeleteExpiredSessions(ctx context.Context, before time.Time) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	n := 0
+	for hash, s := range m.sessions {
+		if s.ExpiresAt.Before(before) {
+			delete(m.sessions, hash)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+var _ WorkspaceStore = (*MemoryWorkspaces)(nil)
+
+// MemoryWorkspaces - in-memory WorkspaceStore used when service runs in memory or file mode.
+type MemoryWorkspaces struct {
+	workspaces map[string]Workspace                  // by ID
+	members    map[string]map[string]WorkspaceMember // by workspace ID and uid
+	mu         sync.RWMutex
+}
+
+// NewMemoryWorkspaces - creates MemoryWorkspaces.
+func NewMemoryWorkspaces() *MemoryWorkspaces {
+	return &MemoryWorkspaces{workspaces: map[string]Workspace{}, members: map[string]map[string]WorkspaceMember{}}
+}
+
+// CreateWorkspace - stores workspace by its ID with its first member.
+func (m *MemoryWorkspaces) CreateWorkspace(ctx context.Context, w Workspace, owner WorkspaceMember) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.workspaces[w.ID] = w
+	m.members[w.ID] = map[string]WorkspaceMember{owner.UID: owner}
+
+	return nil
+}
+
+// UserWorkspaces - returns workspaces where user is member ordered by creation time.
+func (m *MemoryWorkspaces) UserWorkspaces(ctx context.Context, uid string) ([]UserWorkspace, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	workspaces := make([]UserWorkspace, 0)
+	for id, members := range m.members {
+		if member, ok := members[uid]; ok {
+			workspaces = append(workspaces, UserWorkspace{Workspace: m.workspaces[id], Role: member.Role})
+		}
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].CreatedAt.Equal(workspaces[j].CreatedAt) {
+			return workspaces[i].ID < workspaces[j].ID
+		}
+		return workspaces[i].CreatedAt.Before(workspaces[j].CreatedAt)
+	})
+
+	return workspaces, nil
+}
+
+// Member - returns member of workspace.
+func (m *MemoryWorkspaces) Member(ctx context.Context, workspaceID string, uid string) (WorkspaceMember, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	member, ok := m.members[workspaceID][uid]
+	if !ok {
+		return WorkspaceMember{}, utils.ErrNotMember
+	}
+
+	return member, nil
+}
+
+// Members - returns members of workspace ordered by time of adding.
+func (m *MemoryWorkspaces) Members(ctx context.Context, workspaceID string) ([]WorkspaceMember, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	members := make([]WorkspaceMember, 0, len(m.members[workspaceID]))
+	for _, member := range m.members[workspaceID] {
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].AddedAt.Equal(members[j].AddedAt) {
+			return members[i].UID < members[j].UID
+		}
+		return members[i].AddedAt.Before(members[j].AddedAt)
+	})
+
+	return members, nil
+}
+
+// SaveMember - stores member of existing workspace, time of adding of existing member is kept.
+func (m *MemoryWorkspaces) SaveMember(ctx context.Context, member WorkspaceMember) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	members, ok := m.members[member.WorkspaceID]
+	if !ok {
+		return utils.ErrNotMember
+	}
+	if existing, ok := members[member.UID]; ok {
+		member.AddedAt = existing.AddedAt
+	}
+	members[member.UID] = member
+
+	return nil
+}
+
+// DeleteMember - removes member from workspace.
+func (m *MemoryWorkspaces) DeleteMember(ctx context.Context, workspaceID string, uid string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.members[workspaceID], uid)
+
+	return nil
+}
+
+var _ TransferStore = (*MemoryTransfers)(nil)
+
+// MemoryTransfers - in-memory TransferStore used when service runs in memory or file mode.
+type MemoryTransfers struct {
+	codes map[string]TransferCode // by hash
+	mu    sync.Mutex
+}
+
+// NewMemoryTransfers - creates MemoryTransfers.
+func NewMemoryTransfers() *MemoryTransfers {
+	return &MemoryTransfers{codes: map[string]TransferCode{}}
+}
+
+// SaveTransfer - stores code by its hash.
+func (m *MemoryTransfers) SaveTransfer(ctx context.Context, c TransferCode) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.codes[c.Hash] = c
+
+	return nil
+}
+
+// TakeTransfer - removes and returns code with provided hash.
+func (m *MemoryTransfers) TakeTransfer(ctx context.Context, hash string) (TransferCode, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	c, ok := m.codes[hash]
+	if !ok {
+		return TransferCode{}, utils.ErrInvalidTransfer
+	}
+	delete(m.codes, hash)
+
+	return c, nil
+}
+
+// DeleteExpiredTransfers - removes codes expired before provided time.
+func (m *MemoryTransfers) DeleteExpiredTransfers(ctx context.Context, before time.Time) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	n := 0
+	for hash, c := range m.codes {
+		if c.ExpiresAt.Before(before) {
+			delete(m.codes, hash)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+var _ RateStore = (*MemoryRates)(nil)
+
+// MemoryRates - in-memory RateStore, limits are not shared by replicas.
+type MemoryRates struct {
+	buckets map[string]*bucket
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// NewMemoryRates - creates MemoryRates.
+func NewMemoryRates() *MemoryRates {
+	return &MemoryRates{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// TakeToken - takes token from bucket with provided key.
+func (m *MemoryRates) TakeToken(ctx context.Context, key string, capacity int, period time.Duration) (bool, time.Duration, error) {
+	ok, retryAfter := m.take(key, capacity, period, m.now())
+
+	return ok, retryAfter, nil
+}
+
+// DeleteIdleBuckets - removes buckets not used since provided time.
+func (m *MemoryRates) DeleteIdleBuckets(ctx context.Context, before time.Time) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	n := 0
+	for key, b := range m.buckets {
+		if b.updatedAt.Before(before) {
+			delete(m.buckets, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// take - takes token from bucket with provided key at provided time. Bucket is recreated full if its capacity
+// has changed.
+func (m *MemoryRates) take(key string, capacity int, period time.Duration, now time.Time) (bool, time.Duration) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	b, ok := m.buckets[key]
+	if !ok || b.capacity != float64(capacity) {
+		b = newBucket(capacity, period, now)
+		m.buckets[key] = b
+	}
+
+	return b.take(now)
+}
+
+// forget - removes bucket with provided key.
+func (m *MemoryRates) forget(key string) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.buckets, key)
+}
@@ -0,0 +1,411 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// clockSkew - allowed difference between clocks of identity provider and service.
+const clockSkew = time.Minute
+
+// OIDCConfig - settings of OpenID Connect relying party.
+type OIDCConfig struct {
+	RoleMapping  map[string]string // group of identity provider to role of shortener
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	UserClaim    string // claim mapped to user ID, "sub" or "email"
+	GroupsClaim  string
+	Scopes       []string
+}
+
+// Identity - user authenticated by identity provider.
+type Identity struct {
+	UID   string // user ID derived from issuer and user claim
+	Login string // value of user claim
+	Roles []string
+}
+
+// OIDCProvider - OpenID Connect identity provider.
+type OIDCProvider interface {
+	// AuthCodeURL - returns URL of provider login page for authorization code flow with PKCE.
+	AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error)
+	// Exchange - exchanges authorization code for ID token, verifies it and maps its claims to Identity.
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (Identity, error)
+}
+
+// discovery - part of provider metadata served at /.well-known/openid-configuration.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jwk - RSA public key of JWKS.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+var _ OIDCProvider = (*OIDC)(nil)
+
+// OIDC - OpenID Connect relying party. Provider metadata is discovered on first use, signing keys are fetched
+// from JWKS and refetched when ID token is signed by unknown key. Only RS256 ID tokens are accepted.
+type OIDC struct {
+	client   *http.Client
+	meta     *discovery
+	keys     map[string]*rsa.PublicKey
+	now      func() time.Time
+	cfg      OIDCConfig
+	mu       sync.Mutex
+	keysMu   sync.RWMutex
+	fetchJWK sync.Mutex
+}
+
+// NewOIDC - creates OIDC. http.DefaultClient is used if client is nil.
+func NewOIDC(cfg OIDCConfig, client *http.Client) *OIDC {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid"}
+	}
+
+	return &OIDC{
+		client: client,
+		keys:   map[string]*rsa.PublicKey{},
+		now:    time.Now,
+		cfg:    cfg,
+	}
+}
+
+// AuthCodeURL - builds URL of authorization endpoint with S256 code challenge of verifier.
+func (o *OIDC) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	meta, err := o.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", o.cfg.ClientID)
+	q.Set("redirect_uri", o.cfg.RedirectURL)
+	q.Set("scope", strings.Join(o.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", PKCEChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange - redeems code at token endpoint and verifies returned ID token.
+func (o *OIDC) Exchange(ctx context.Context, code string, verifier string, nonce string) (Identity, error) {
+	meta, err := o.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", o.cfg.RedirectURL)
+	form.Set("client_id", o.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if o.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = o.do(req, &tokens); err != nil {
+		return Identity{}, err
+	}
+	if tokens.IDToken == "" {
+		return Identity{}, fmt.Errorf("%w: id_token is missing in token response", utils.ErrInvalidIDToken)
+	}
+
+	claims, err := o.verify(ctx, meta, tokens.IDToken, nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return o.identity(meta, claims)
+}
+
+// verify - checks signature, issuer, audience, expiry and nonce of ID token and returns its claims.
+func (o *OIDC) verify(ctx context.Context, meta *discovery, token string, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, utils.ErrInvalidIDToken
+	}
+
+	var h struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != "RS256" {
+		return nil, utils.ErrInvalidIDToken
+	}
+
+	key, err := o.key(ctx, meta, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, utils.ErrInvalidIDToken
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) != nil {
+		return nil, utils.ErrInvalidIDToken
+	}
+
+	var claims map[string]interface{}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, utils.ErrInvalidIDToken
+	}
+
+	now := o.now()
+	switch {
+	case claims["iss"] != meta.Issuer:
+		return nil, fmt.Errorf("%w: wrong issuer", utils.ErrInvalidIDToken)
+	case !hasAudience(claims["aud"], o.cfg.ClientID):
+		return nil, fmt.Errorf("%w: wrong audience", utils.ErrInvalidIDToken)
+	case !numericAfter(claims["exp"], now.Add(-clockSkew)):
+		return nil, fmt.Errorf("%w: token is expired", utils.ErrInvalidIDToken)
+	case claims["nonce"] != nonce:
+		return nil, fmt.Errorf("%w: wrong nonce", utils.ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// identity - maps user claim to user ID and groups claim to roles.
+func (o *OIDC) identity(meta *discovery, claims map[string]interface{}) (Identity, error) {
+	login, _ := claims[o.cfg.UserClaim].(string)
+	if login == "" {
+		return Identity{}, fmt.Errorf("%w: %s", utils.ErrMissingClaim, o.cfg.UserClaim)
+	}
+	if verified, ok := claims["email_verified"].(bool); o.cfg.UserClaim == "email" && ok && !verified {
+		return Identity{}, fmt.Errorf("%w: email is not verified", utils.ErrMissingClaim)
+	}
+
+	var groups []string
+	switch g := claims[o.cfg.GroupsClaim].(type) {
+	case string:
+		groups = strings.Fields(g)
+	case []interface{}:
+		for _, v := range g {
+			if s, ok := v.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	roles := make([]string, 0)
+	seen := map[string]struct{}{}
+	for _, g := range groups {
+		role, ok := o.cfg.RoleMapping[g]
+		if _, isSeen := seen[role]; !ok || isSeen {
+			continue
+		}
+		seen[role] = struct{}{}
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return Identity{
+		UID:   uuid.NewSHA1(uuid.NameSpaceURL, []byte(meta.Issuer+"#"+login)).String(),
+		Login: login,
+		Roles: roles,
+	}, nil
+}
+
+// discover - fetches provider metadata once. Failed discovery is retried on next call.
+func (o *OIDC) discover(ctx context.Context) (*discovery, error) {
+	defer o.mu.Unlock()
+	o.mu.Lock()
+
+	if o.meta != nil {
+		return o.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(o.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta discovery
+	if err = o.do(req, &meta); err != nil {
+		return nil, err
+	}
+	if meta.Issuer != o.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q of metadata does not match %q", utils.ErrInvalidIDToken, meta.Issuer, o.cfg.Issuer)
+	}
+
+	o.meta = &meta
+
+	return o.meta, nil
+}
+
+// key - returns signing key by its ID, JWKS is refetched if key is unknown.
+func (o *OIDC) key(ctx context.Context, meta *discovery, kid string) (*rsa.PublicKey, error) {
+	o.keysMu.RLock()
+	key, ok := o.keys[kid]
+	o.keysMu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	defer o.fetchJWK.Unlock()
+	o.fetchJWK.Lock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = o.do(req, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	o.keysMu.Lock()
+	o.keys = keys
+	o.keysMu.Unlock()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", utils.ErrInvalidIDToken, kid)
+	}
+
+	return key, nil
+}
+
+// do - sends request to provider and decodes json response into v.
+func (o *OIDC) do(req *http.Request, v interface{}) error {
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s responded with %d", utils.ErrProviderFailed, req.URL.Path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// NewPKCEVerifier - returns random code verifier of PKCE. It's also suitable as state and nonce.
+func NewPKCEVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCEChallenge - returns S256 code challenge of verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ParseRoleMapping - parses mapping of groups to roles in format "group:role,group:role".
+func ParseRoleMapping(s string) (map[string]string, error) {
+	mapping := map[string]string{}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 || i == len(pair)-1 {
+			return nil, fmt.Errorf("%w: %q", utils.ErrWrongRoleMap, pair)
+		}
+
+		mapping[pair[:i]] = pair[i+1:]
+	}
+
+	return mapping, nil
+}
+
+// hasAudience - checks that aud claim, string or array, contains client ID.
+func hasAudience(aud interface{}, clientID string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == clientID
+	case []interface{}:
+		for _, v := range a {
+			if v == clientID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// numericAfter - checks that numeric date claim is after provided time.
+func numericAfter(v interface{}, t time.Time) bool {
+	f, ok := v.(float64)
+	return ok && time.Unix(int64(f), 0).After(t)
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth/oidctest"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// authorize - starts login at identity provider and returns code from redirect.
+func authorize(t *testing.T, o *OIDC, state, nonce, verifier string) string {
+	t.Helper()
+
+	u, err := o.AuthCodeURL(context.Background(), state, nonce, verifier)
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, state, loc.Query().Get("state"))
+
+	return loc.Query().Get("code")
+}
+
+func TestOIDC_Exchange(t *testing.T) {
+	idp := oidctest.NewServer("shortener")
+	defer idp.Close()
+
+	tests := []struct {
+		claims    map[string]interface{}
+		wantErr   error
+		name      string
+		userClaim string
+		verifier  string
+		wantLogin string
+		wantRoles []string
+		rotateKey bool
+	}{
+		{
+			name:      "Claims are mapped to identity",
+			claims:    map[string]interface{}{"sub": "42", "groups": []string{"devs", "admins", "ops", "admins"}},
+			wantLogin: "42",
+			wantRoles: []string{"admin", "editor"},
+		},
+		{
+			name:      "Verified email can be user claim",
+			userClaim: "email",
+			claims:    map[string]interface{}{"sub": "42", "email": "user@example.com", "email_verified": true},
+			wantLogin: "user@example.com",
+			wantRoles: []string{},
+		},
+		{
+			name:      "Unverified email can't be user claim",
+			userClaim: "email",
+			claims:    map[string]interface{}{"sub": "42", "email": "user@example.com", "email_verified": false},
+			wantErr:   utils.ErrMissingClaim,
+		},
+		{
+			name:    "Token without user claim is rejected",
+			claims:  map[string]interface{}{"groups": []string{"admins"}},
+			wantErr: utils.ErrMissingClaim,
+		},
+		{
+			name:    "Token with other nonce is rejected",
+			claims:  map[string]interface{}{"sub": "42", "nonce": "other"},
+			wantErr: utils.ErrInvalidIDToken,
+		},
+		{
+			name:    "Token for other audience is rejected",
+			claims:  map[string]interface{}{"sub": "42", "aud": []string{"other"}},
+			wantErr: utils.ErrInvalidIDToken,
+		},
+		{
+			name:    "Token of other issuer is rejected",
+			claims:  map[string]interface{}{"sub": "42", "iss": "https://evil.example.com"},
+			wantErr: utils.ErrInvalidIDToken,
+		},
+		{
+			name:    "Expired token is rejected",
+			claims:  map[string]interface{}{"sub": "42", "exp": time.Now().Add(-time.Hour).Unix()},
+			wantErr: utils.ErrInvalidIDToken,
+		},
+		{
+			name:      "Token signed by rotated key is accepted",
+			claims:    map[string]interface{}{"sub": "42"},
+			rotateKey: true,
+			wantLogin: "42",
+			wantRoles: []string{},
+		},
+		{
+			name:     "Code can't be exchanged with wrong verifier",
+			claims:   map[string]interface{}{"sub": "42"},
+			verifier: "wrong-verifier",
+			wantErr:  utils.ErrProviderFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOIDC(OIDCConfig{
+				RoleMapping: map[string]string{"admins": "admin", "devs": "editor"},
+				Issuer:      idp.URL,
+				ClientID:    "shortener",
+				RedirectURL: "http://localhost/api/auth/oidc/callback",
+				UserClaim:   tt.userClaim,
+				GroupsClaim: "groups",
+			}, idp.Client())
+			idp.SetClaims(tt.claims)
+
+			verifier, err := NewPKCEVerifier()
+			require.NoError(t, err)
+			code := authorize(t, o, "state", "nonce", verifier)
+
+			if tt.rotateKey {
+				_, err = o.Exchange(context.Background(), code, verifier, "nonce")
+				require.NoError(t, err)
+				idp.RotateKey()
+				code = authorize(t, o, "state", "nonce", verifier)
+			}
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			id, err := o.Exchange(context.Background(), code, verifier, "nonce")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLogin, id.Login)
+			assert.Equal(t, tt.wantRoles, id.Roles)
+
+			again, err := o.Exchange(context.Background(), authorize(t, o, "state", "nonce", verifier), verifier, "nonce")
+			require.NoError(t, err)
+			assert.Equal(t, id.UID, again.UID, "user ID must be stable between logins")
+		})
+	}
+}
+
+func TestOIDC_AuthCodeURL(t *testing.T) {
+	idp := oidctest.NewServer("shortener")
+	defer idp.Close()
+
+	o := NewOIDC(OIDCConfig{
+		Issuer:      idp.URL,
+		ClientID:    "shortener",
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"openid", "email"},
+	}, idp.Client())
+
+	u, err := o.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	q := parsed.Query()
+	assert.Equal(t, idp.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "openid email", q.Get("scope"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, PKCEChallenge("verifier"), q.Get("code_challenge"))
+	assert.Equal(t, "nonce", q.Get("nonce"))
+
+	wrongIssuer := NewOIDC(OIDCConfig{Issuer: idp.URL + "/other", ClientID: "shortener"}, idp.Client())
+	_, err = wrongIssuer.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.ErrorIs(t, err, utils.ErrProviderFailed)
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B.
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestParseRoleMapping(t *testing.T) {
+	tests := []struct {
+		want    map[string]string
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{
+			name: "Empty mapping is parsed",
+			want: map[string]string{},
+		},
+		{
+			name: "Groups are mapped to roles",
+			s:    "admins:admin, devs:editor",
+			want: map[string]string{"admins": "admin", "devs": "editor"},
+		},
+		{
+			name: "Group with colon is mapped to role",
+			s:    "urn:team:ops:admin",
+			want: map[string]string{"urn:team:ops": "admin"},
+		},
+		{
+			name:    "Pair without role can't be parsed",
+			s:       "admins",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoleMapping(tt.s)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, utils.ErrWrongRoleMap)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package oidctest - provides OpenID Connect identity provider for tests, as net/http/httptest does for HTTP servers.
+// It serves discovery, JWKS, authorization and token endpoints, checks PKCE and issues RS256 signed ID tokens.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// authRequest - a representation of authorization request waiting for code redemption.
+type authRequest struct {
+	Challenge   string
+	Nonce       string
+	RedirectURI string
+}
+
+// Server - identity provider listening on local address. Issuer of provider is URL of server.
+type Server struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	claims   map[string]interface{}
+	codes    map[string]authRequest
+	ClientID string
+	keyID    int
+	mu       sync.Mutex
+}
+
+// NewServer - starts identity provider accepting provided client ID. It must be closed by Close.
+func NewServer(clientID string) *Server {
+	s := &Server{
+		claims:   map[string]interface{}{},
+		codes:    map[string]authRequest{},
+		ClientID: clientID,
+	}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// SetClaims - sets claims added to next ID tokens, e.g. sub, email or groups.
+// Standard claims iss, aud, exp, iat and nonce can be overridden as well.
+func (s *Server) SetClaims(claims map[string]interface{}) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.claims = claims
+}
+
+// RotateKey - replaces signing key with a new one with another key ID.
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.key = key
+	s.keyID++
+}
+
+// discovery - serves provider metadata.
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+// jwks - serves current signing key.
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": s.kid(),
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize - logs user in without any page and redirects back with code and state.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.ClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+
+	s.mu.Lock()
+	s.codes[code] = authRequest{Challenge: q.Get("code_challenge"), Nonce: q.Get("nonce"), RedirectURI: q.Get("redirect_uri")}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token - redeems code once if PKCE verifier matches challenge and issues ID token.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	req, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok,
+		r.PostForm.Get("grant_type") != "authorization_code",
+		r.PostForm.Get("redirect_uri") != req.RedirectURI,
+		base64.RawURLEncoding.EncodeToString(sum[:]) != req.Challenge:
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   s.URL,
+		"aud":   s.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": req.Nonce,
+	}
+	for k, v := range s.claims {
+		claims[k] = v
+	}
+
+	idToken, err := s.sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]string{"access_token": randomString(), "token_type": "Bearer", "id_token": idToken})
+}
+
+// sign - signs claims by current key with RS256.
+func (s *Server) sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.kid()})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// kid - returns ID of current key.
+func (s *Server) kid() string {
+	return fmt.Sprintf("key-%d", s.keyID)
+}
+
+// writeJSON - writes v as json response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// randomString - returns random base64url string.
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Route classes with separate rate limits.
+const (
+	RateShorten  = "shorten"  // shortening of single link
+	RateBatch    = "batch"    // shortening of batch of links
+	RateRedirect = "redirect" // expanding of short link
+	RateDelete   = "delete"   // deleting of links
+	RateLogin    = "login"    // logging in with password
+)
+
+// ratePeriod - period in which bucket is refilled with its capacity, so limits are requests per minute.
+const ratePeriod = time.Minute
+
+// RateStore - storage of token buckets.
+type RateStore interface {
+	// TakeToken - refills bucket with provided key for time passed since its last use and takes one token.
+	// Bucket holds capacity tokens and is refilled with capacity tokens per period, new bucket is full.
+	// If bucket is empty returns false and time after which token will be available.
+	TakeToken(ctx context.Context, key string, capacity int, period time.Duration) (bool, time.Duration, error)
+	// DeleteIdleBuckets - removes buckets not used since provided time.
+	DeleteIdleBuckets(ctx context.Context, before time.Time) (int, error)
+}
+
+// RateLimiter - limits rate of requests of clients.
+type RateLimiter interface {
+	// Allow - takes request of client from limit of route class. If limit is exceeded returns false and time
+	// after which request can be retried.
+	Allow(ctx context.Context, class string, client string) (bool, time.Duration)
+}
+
+var _ RateLimiter = (*RateLimits)(nil)
+
+// RateLimits - limits requests per minute of each route class with token buckets kept in RateStore.
+type RateLimits struct {
+	store  RateStore
+	logger *zap.Logger
+	now    func() time.Time
+	limits map[string]int
+}
+
+// NewRateLimits - creates RateLimits. limits are requests per minute by route class, classes without
+// positive limit are not limited.
+func NewRateLimits(s RateStore, limits map[string]int, l *zap.Logger) *RateLimits {
+	return &RateLimits{
+		store:  s,
+		logger: l,
+		now:    time.Now,
+		limits: limits,
+	}
+}
+
+// Allow - takes token from bucket of client in route class. Requests are allowed if store fails, so outage
+// of shared counter doesn't take service down.
+func (r *RateLimits) Allow(ctx context.Context, class string, client string) (bool, time.Duration) {
+	limit := r.limits[class]
+	if limit <= 0 {
+		return true, 0
+	}
+
+	ok, retryAfter, err := r.store.TakeToken(ctx, class+":"+client, limit, ratePeriod)
+	if err != nil {
+		r.logger.Error("rate limit is not checked", zap.String("class", class), zap.Error(err))
+		return true, 0
+	}
+
+	return ok, retryAfter
+}
+
+// PurgeBuckets - removes buckets idle for longer than refill period. Such buckets are full, so removing them
+// doesn't change limits.
+func (r *RateLimits) PurgeBuckets(ctx context.Context) error {
+	n, err := r.store.DeleteIdleBuckets(ctx, r.now().Add(-ratePeriod).UTC())
+	if err != nil {
+		return err
+	}
+
+	r.logger.Info("idle rate limit buckets purged", zap.Int("count", n))
+
+	return nil
+}
+
+// bucket - token bucket refilled with capacity tokens per period.
+type bucket struct {
+	updatedAt time.Time
+	tokens    float64
+	capacity  float64
+	perSecond float64
+}
+
+// newBucket - creates full bucket.
+func newBucket(capacity int, period time.Duration, now time.Time) *bucket {
+	return &bucket{
+		updatedAt: now,
+		tokens:    float64(capacity),
+		capacity:  float64(capacity),
+		perSecond: float64(capacity) / period.Seconds(),
+	}
+}
+
+// take - refills bucket for time passed since last call and takes one token. If bucket is empty returns false
+// and time after which token will be available.
+func (b *bucket) take(now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.perSecond)
+		b.updatedAt = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+
+	return false, wait
+}
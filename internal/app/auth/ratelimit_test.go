@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type failingRates struct{}
+
+func (failingRates) TakeToken(ctx context.Context, key string, capacity int, period time.Duration) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func (failingRates) DeleteIdleBuckets(ctx context.Context, before time.Time) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestRateLimits_Allow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRates()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	r := NewRateLimits(store, map[string]int{RateShorten: 2, RateBatch: 1, RateDelete: 0}, zap.NewNop())
+	r.now = store.now
+
+	for i := 0; i < 2; i++ {
+		ok, _ := r.Allow(ctx, RateShorten, "ip:10.0.0.1")
+		require.True(t, ok)
+	}
+	ok, retryAfter := r.Allow(ctx, RateShorten, "ip:10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	ok, _ = r.Allow(ctx, RateShorten, "ip:10.0.0.2")
+	assert.True(t, ok, "limits of clients must be independent")
+	ok, _ = r.Allow(ctx, RateBatch, "ip:10.0.0.1")
+	assert.True(t, ok, "limits of route classes must be independent")
+	ok, _ = r.Allow(ctx, RateBatch, "ip:10.0.0.1")
+	assert.False(t, ok)
+
+	for i := 0; i < 10; i++ {
+		ok, _ = r.Allow(ctx, RateDelete, "ip:10.0.0.1")
+		require.True(t, ok, "class without limit must not be limited")
+		ok, _ = r.Allow(ctx, RateRedirect, "ip:10.0.0.1")
+		require.True(t, ok, "class without limit must not be limited")
+	}
+
+	now = now.Add(30 * time.Second)
+	ok, _ = r.Allow(ctx, RateShorten, "ip:10.0.0.1")
+	assert.True(t, ok, "bucket must be refilled")
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, r.PurgeBuckets(ctx))
+	assert.Empty(t, store.buckets)
+
+	failing := NewRateLimits(failingRates{}, map[string]int{RateShorten: 1}, zap.NewNop())
+	ok, _ = failing.Allow(ctx, RateShorten, "ip:10.0.0.1")
+	assert.True(t, ok, "requests must be allowed when store fails")
+}
@@ -0,0 +1,341 @@
+// Package auth - issues and verifies signed bearer tokens carrying user ID, expiry and scopes.
+// Tokens are compact JWS (header.claims.signature, base64url encoded) signed by HS256 or EdDSA.
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Scopes of API routes.
+const (
+	ScopeShorten = "shorten" // shortening of links
+	ScopeRead    = "read"    // listing of user links
+	ScopeDelete  = "delete"  // deletion of user links
+	ScopeAdmin   = "admin"   // management of links and users across all users
+)
+
+// RoleAdmin - role of accounts granted ScopeAdmin on login.
+const RoleAdmin = "admin"
+
+// Signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// AllScopes - scopes granted to user identified by cookie.
+var AllScopes = []string{ScopeShorten, ScopeRead, ScopeDelete}
+
+// knownScopes - scopes that can be requested for token or API key. ScopeAdmin is never granted by default.
+var knownScopes = append([]string{ScopeAdmin}, AllScopes...)
+
+// Claims - payload of token.
+type Claims struct {
+	UID       string `json:"sub"`
+	Scope     string `json:"scope"` // space separated scopes
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+}
+
+// Scopes - returns scopes of token.
+func (c Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// Expiry - returns time when token expires.
+func (c Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0).UTC()
+}
+
+// header - header of token.
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// Signer - signs and verifies tokens.
+type Signer interface {
+	Alg() string
+	Sign(payload []byte) ([]byte, error)
+	Verify(payload []byte, sig []byte) bool
+}
+
+// Issuer - issues tokens.
+type Issuer interface {
+	// Issue - issues token for user with provided scopes. Zero ttl means default one.
+	Issue(uid string, scopes []string, ttl time.Duration) (string, Claims, error)
+}
+
+// Verifier - verifies tokens.
+type Verifier interface {
+	// Verify - checks signature and expiry of token and returns its claims.
+	Verify(token string) (Claims, error)
+}
+
+var _ Issuer = (*Tokens)(nil)
+var _ Verifier = (*Tokens)(nil)
+
+// Tokens - issues and verifies tokens signed by Signer.
+type Tokens struct {
+	signer Signer
+	now    func() time.Time
+	ttl    time.Duration
+	maxTTL time.Duration
+}
+
+// NewTokens - creates Tokens. ttl is used when no ttl is requested, longer ttl than maxTTL can't be requested.
+func NewTokens(s Signer, ttl time.Duration, maxTTL time.Duration) *Tokens {
+	return &Tokens{signer: s, ttl: ttl, maxTTL: maxTTL, now: time.Now}
+}
+
+// Issue - issues token for user with provided scopes. Returns utils.ErrUnknownScope if some scope is unknown
+// and utils.ErrWrongTokenTTL if ttl is longer than allowed.
+func (t *Tokens) Issue(uid string, scopes []string, ttl time.Duration) (string, Claims, error) {
+	if ttl == 0 {
+		ttl = t.ttl
+	}
+	if ttl < 0 || (t.maxTTL > 0 && ttl > t.maxTTL) {
+		return "", Claims{}, fmt.Errorf("%w: must be positive and at most %s", utils.ErrWrongTokenTTL, t.maxTTL)
+	}
+
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	now := t.now()
+	claims := Claims{
+		UID:       uid,
+		Scope:     strings.Join(scopes, " "),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	h, err := json.Marshal(header{Alg: t.signer.Alg(), Typ: "JWT"})
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	signingInput := encodeSegment(h) + "." + encodeSegment(c)
+
+	sig, err := t.signer.Sign([]byte(signingInput))
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	return signingInput + "." + encodeSegment(sig), claims, nil
+}
+
+// Verify - checks algorithm, signature and expiry of token and returns its claims.
+// Returns utils.ErrInvalidToken or utils.ErrTokenExpired.
+func (t *Tokens) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, utils.ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != t.signer.Alg() {
+		return Claims{}, utils.ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !t.signer.Verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return Claims{}, utils.ErrInvalidToken
+	}
+
+	var c Claims
+	if err = decodeSegment(parts[1], &c); err != nil || c.UID == "" {
+		return Claims{}, utils.ErrInvalidToken
+	}
+
+	if !t.now().Before(c.Expiry()) {
+		return Claims{}, utils.ErrTokenExpired
+	}
+
+	return c, nil
+}
+
+// NormalizeScopes - checks that scopes are known and returns them sorted without duplicates.
+// Empty scopes are replaced by AllScopes.
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		scopes = AllScopes
+	}
+
+	known := make(map[string]bool, len(knownScopes))
+	for _, s := range knownScopes {
+		known[s] = true
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if !known[s] {
+			return nil, fmt.Errorf("%w: %q", utils.ErrUnknownScope, s)
+		}
+		if seen[s] {
+			continue
+		}
+
+		seen[s] = true
+		result = append(result, s)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// RoleScopes - returns scopes granted to account with provided roles: AllScopes and ScopeAdmin for RoleAdmin.
+func RoleScopes(roles []string) []string {
+	if !HasScope(roles, RoleAdmin) {
+		return AllScopes
+	}
+
+	return append([]string{ScopeAdmin}, AllScopes...)
+}
+
+// HasScope - reports whether scopes contain provided one.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hmacSigner - Signer using HMAC-SHA256.
+type hmacSigner struct {
+	secret []byte
+}
+
+// NewHMACSigner - creates HS256 Signer with provided secret.
+func NewHMACSigner(secret []byte) Signer {
+	return &hmacSigner{secret: secret}
+}
+
+// Alg - returns HS256.
+func (s *hmacSigner) Alg() string {
+	return AlgHS256
+}
+
+// Sign - signs payload by HMAC-SHA256.
+func (s *hmacSigner) Sign(payload []byte) ([]byte, error) {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write(payload)
+
+	return m.Sum(nil), nil
+}
+
+// Verify - checks HMAC-SHA256 signature of payload.
+func (s *hmacSigner) Verify(payload []byte, sig []byte) bool {
+	expected, _ := s.Sign(payload)
+	return hmac.Equal(expected, sig)
+}
+
+// ed25519Signer - Signer using Ed25519.
+type ed25519Signer struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewEd25519Signer - creates EdDSA Signer with provided private key.
+func NewEd25519Signer(private ed25519.PrivateKey) Signer {
+	return &ed25519Signer{private: private, public: private.Public().(ed25519.PublicKey)}
+}
+
+// Alg - returns EdDSA.
+func (s *ed25519Signer) Alg() string {
+	return AlgEdDSA
+}
+
+// Sign - signs payload by Ed25519.
+func (s *ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	return ed25519.Sign(s.private, payload), nil
+}
+
+// Verify - checks Ed25519 signature of payload.
+func (s *ed25519Signer) Verify(payload []byte, sig []byte) bool {
+	return ed25519.Verify(s.public, payload, sig)
+}
+
+// LoadSigner - creates Signer for provided algorithm. HS256 uses secret, EdDSA uses PKCS #8 PEM private key
+// from keyFile, which can be generated by "openssl genpkey -algorithm ed25519".
+// If HS256 secret is empty, random one is generated, so tokens are valid until restart only. Returns Signer
+// and whether it is ephemeral.
+func LoadSigner(alg string, secret string, keyFile string) (Signer, bool, error) {
+	switch alg {
+	case "", AlgHS256:
+		if secret != "" {
+			return NewHMACSigner([]byte(secret)), false, nil
+		}
+
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, false, err
+		}
+
+		return NewHMACSigner(random), true, nil
+	case AlgEdDSA:
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, false, err
+		}
+
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return nil, false, fmt.Errorf("%w: %s is not PEM encoded", utils.ErrWrongSigningKey, keyFile)
+		}
+
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %v", utils.ErrWrongSigningKey, err)
+		}
+
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, false, fmt.Errorf("%w: %s is not Ed25519 key", utils.ErrWrongSigningKey, keyFile)
+		}
+
+		return NewEd25519Signer(private), false, nil
+	default:
+		return nil, false, fmt.Errorf("%w: unknown algorithm %q", utils.ErrWrongSigningKey, alg)
+	}
+}
+
+// encodeSegment - encodes segment of token.
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeSegment - decodes json segment of token.
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
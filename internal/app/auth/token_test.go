@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestTokens_IssueAndVerify(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		signer Signer
+		name   string
+	}{
+		{
+			name:   "HS256 token can be issued and verified",
+			signer: NewHMACSigner([]byte("secret")),
+		},
+		{
+			name:   "EdDSA token can be issued and verified",
+			signer: NewEd25519Signer(private),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := NewTokens(tt.signer, time.Hour, 24*time.Hour)
+
+			token, issued, err := tokens.Issue("user", []string{ScopeRead, ScopeShorten, ScopeRead}, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{ScopeRead, ScopeShorten}, issued.Scopes())
+
+			claims, err := tokens.Verify(token)
+			require.NoError(t, err)
+			assert.Equal(t, issued, claims)
+			assert.Equal(t, "user", claims.UID)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), claims.Expiry(), 2*time.Second)
+		})
+	}
+}
+
+func TestTokens_Verify(t *testing.T) {
+	tokens := NewTokens(NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+	token, _, err := tokens.Issue("user", nil, 0)
+	require.NoError(t, err)
+
+	expired := NewTokens(NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	expiredToken, _, err := expired.Issue("user", nil, 0)
+	require.NoError(t, err)
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherAlgToken, _, err := NewTokens(NewEd25519Signer(private), time.Hour, 0).Issue("user", nil, 0)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+
+	tests := []struct {
+		wantErr error
+		name    string
+		token   string
+	}{
+		{
+			name:    "Expired token is rejected",
+			token:   expiredToken,
+			wantErr: utils.ErrTokenExpired,
+		},
+		{
+			name:    "Token signed by other secret is rejected",
+			token:   mustIssue(t, NewTokens(NewHMACSigner([]byte("other")), time.Hour, 0)),
+			wantErr: utils.ErrInvalidToken,
+		},
+		{
+			name:    "Token with other algorithm is rejected",
+			token:   otherAlgToken,
+			wantErr: utils.ErrInvalidToken,
+		},
+		{
+			name:    "Token with tampered claims is rejected",
+			token:   parts[0] + "." + encodeSegment([]byte(`{"sub":"admin","scope":"read","exp":9999999999}`)) + "." + parts[2],
+			wantErr: utils.ErrInvalidToken,
+		},
+		{
+			name:    "Malformed token is rejected",
+			token:   "token",
+			wantErr: utils.ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tokens.Verify(tt.token)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTokens_Issue(t *testing.T) {
+	tokens := NewTokens(NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+
+	tests := []struct {
+		wantErr error
+		name    string
+		scopes  []string
+		ttl     time.Duration
+	}{
+		{
+			name:   "All scopes are granted when scopes are not requested",
+			scopes: nil,
+		},
+		{
+			name:    "Unknown scope can't be requested",
+			scopes:  []string{"superuser"},
+			wantErr: utils.ErrUnknownScope,
+		},
+		{
+			name:    "Token longer than max ttl can't be requested",
+			ttl:     48 * time.Hour,
+			wantErr: utils.ErrWrongTokenTTL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, claims, err := tokens.Issue("user", tt.scopes, tt.ttl)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{ScopeDelete, ScopeRead, ScopeShorten}, claims.Scopes())
+		})
+	}
+}
+
+func TestLoadSigner(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	tests := []struct {
+		wantErr       error
+		name          string
+		alg           string
+		secret        string
+		keyFile       string
+		wantAlg       string
+		wantEphemeral bool
+	}{
+		{
+			name:    "HS256 signer uses provided secret",
+			alg:     AlgHS256,
+			secret:  "secret",
+			wantAlg: AlgHS256,
+		},
+		{
+			name:          "HS256 signer without secret is ephemeral",
+			wantAlg:       AlgHS256,
+			wantEphemeral: true,
+		},
+		{
+			name:    "EdDSA signer reads PEM key",
+			alg:     AlgEdDSA,
+			keyFile: keyFile,
+			wantAlg: AlgEdDSA,
+		},
+		{
+			name:    "Unknown algorithm is rejected",
+			alg:     "RS256",
+			wantErr: utils.ErrWrongSigningKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, isEphemeral, err := LoadSigner(tt.alg, tt.secret, tt.keyFile)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, s.Alg())
+			assert.Equal(t, tt.wantEphemeral, isEphemeral)
+		})
+	}
+}
+
+func mustIssue(t *testing.T, tokens *Tokens) string {
+	token, _, err := tokens.Issue("user", nil, 0)
+	require.NoError(t, err)
+
+	return token
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Modes of transfer code redemption.
+const (
+	TransferAdopt = "adopt" // device switches to transferred uid, its own links stay with its previous uid
+	TransferMerge = "merge" // links of device are moved to transferred uid, then device switches to it
+)
+
+// transferAlphabet - alphabet of transfer codes without look-alike characters, so code can be typed
+// from screen of another device.
+const transferAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// transferCodeLen - length of transfer code, 50 bits of randomness.
+const transferCodeLen = 10
+
+// TransferCode - one-time code transferring uid to another device. Code itself is never stored,
+// only its SHA-256 hash.
+type TransferCode struct {
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Hash      string
+	UID       string
+}
+
+// TransferStore - storage of transfer codes.
+type TransferStore interface {
+	// SaveTransfer - stores new transfer code.
+	SaveTransfer(ctx context.Context, c TransferCode) error
+	// TakeTransfer - removes transfer code with provided hash and returns it or utils.ErrInvalidTransfer,
+	// so code can be taken only once.
+	TakeTransfer(ctx context.Context, hash string) (TransferCode, error)
+	// DeleteExpiredTransfers - removes codes expired before provided time and returns their count.
+	DeleteExpiredTransfers(ctx context.Context, before time.Time) (int, error)
+}
+
+// TransferManager - transfers identity of anonymous user between devices.
+type TransferManager interface {
+	// Issue - creates one-time code transferring uid, which is returned with its expiration time.
+	Issue(ctx context.Context, uid string) (string, TransferCode, error)
+	// Redeem - takes code on device of user uid and returns transferred uid with count of merged links.
+	Redeem(ctx context.Context, code string, uid string, mode string) (string, int, error)
+}
+
+var _ TransferManager = (*Transfers)(nil)
+
+// Transfers - manages transfer codes stored in TransferStore.
+type Transfers struct {
+	store  TransferStore
+	merger LinkMerger
+	logger *zap.Logger
+	now    func() time.Time
+	ttl    time.Duration
+}
+
+// NewTransfers - creates Transfers. Codes expire after ttl.
+func NewTransfers(s TransferStore, m LinkMerger, ttl time.Duration, l *zap.Logger) *Transfers {
+	return &Transfers{
+		store:  s,
+		merger: m,
+		logger: l,
+		now:    time.Now,
+		ttl:    ttl,
+	}
+}
+
+// Issue - stores hash of random code transferring uid.
+func (t *Transfers) Issue(ctx context.Context, uid string) (string, TransferCode, error) {
+	code, err := randomTransferCode()
+	if err != nil {
+		return "", TransferCode{}, err
+	}
+
+	now := t.now().UTC()
+	c := TransferCode{
+		CreatedAt: now,
+		ExpiresAt: now.Add(t.ttl),
+		Hash:      HashSecret(code),
+		UID:       uid,
+	}
+	if err = t.store.SaveTransfer(ctx, c); err != nil {
+		return "", TransferCode{}, err
+	}
+
+	t.logger.Info("transfer code issued", zap.String("uid", uid), zap.Time("expires_at", c.ExpiresAt))
+
+	return code, c, nil
+}
+
+// Redeem - takes not expired code and, in TransferMerge mode, moves links of user uid to transferred uid.
+// Code is case-insensitive and may be grouped by dashes or spaces. Returns utils.ErrTransferMode for unknown mode
+// and utils.ErrInvalidTransfer for unknown, used or expired code. If merge fails, code is stored back,
+// so it can be redeemed again.
+func (t *Transfers) Redeem(ctx context.Context, code string, uid string, mode string) (string, int, error) {
+	if mode != TransferAdopt && mode != TransferMerge {
+		return "", 0, utils.ErrTransferMode
+	}
+
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(code))
+	c, err := t.store.TakeTransfer(ctx, HashSecret(code))
+	if err != nil {
+		return "", 0, err
+	}
+	if !t.now().Before(c.ExpiresAt) {
+		return "", 0, utils.ErrInvalidTransfer
+	}
+
+	merged := 0
+	if mode == TransferMerge && t.merger != nil && uid != "" && uid != c.UID {
+		merged, err = t.merger.MergeUserLinks(ctx, uid, c.UID)
+		if err != nil {
+			if errSave := t.store.SaveTransfer(ctx, c); errSave != nil {
+				t.logger.Error("could not restore transfer code", zap.String("uid", c.UID), zap.Error(errSave))
+			}
+			return "", 0, err
+		}
+	}
+
+	t.logger.Info("transfer code redeemed", zap.String("uid", c.UID), zap.String("from", uid),
+		zap.String("mode", mode), zap.Int("merged", merged))
+
+	return c.UID, merged, nil
+}
+
+// PurgeCodes - removes expired transfer codes from store.
+func (t *Transfers) PurgeCodes(ctx context.Context) error {
+	n, err := t.store.DeleteExpiredTransfers(ctx, t.now().UTC())
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("expired transfer codes purged", zap.Int("count", n))
+
+	return nil
+}
+
+// randomTransferCode - generates code of transferCodeLen characters of transferAlphabet.
+func randomTransferCode() (string, error) {
+	b := make([]byte, transferCodeLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = transferAlphabet[int(b[i])%len(transferAlphabet)]
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestTransfers_Redeem(t *testing.T) {
+	tests := []struct {
+		wantErr    error
+		code       func(code string) string
+		name       string
+		mode       string
+		elapsed    time.Duration
+		wantMerged map[string]string
+	}{
+		{
+			name:       "Code can be redeemed with adopting identity",
+			mode:       TransferAdopt,
+			wantMerged: map[string]string{},
+		},
+		{
+			name:       "Code can be redeemed with merging links",
+			mode:       TransferMerge,
+			wantMerged: map[string]string{"phone": "laptop"},
+		},
+		{
+			name: "Code is case-insensitive and can be grouped by dashes",
+			mode: TransferAdopt,
+			code: func(code string) string {
+				return strings.ToLower(code[:5]) + "-" + code[5:]
+			},
+			wantMerged: map[string]string{},
+		},
+		{
+			name:    "Expired code can't be redeemed",
+			mode:    TransferMerge,
+			elapsed: 11 * time.Minute,
+			wantErr: utils.ErrInvalidTransfer,
+		},
+		{
+			name: "Unknown code can't be redeemed",
+			mode: TransferMerge,
+			code: func(code string) string {
+				return "AAAAAAAAAA"
+			},
+			wantErr: utils.ErrInvalidTransfer,
+		},
+		{
+			name:    "Code can't be redeemed in unknown mode",
+			mode:    "steal",
+			wantErr: utils.ErrTransferMode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merger := &mergerMock{merged: map[string]string{}}
+			transfers := NewTransfers(NewMemoryTransfers(), merger, 10*time.Minute, zap.NewNop())
+			now := time.Now()
+			transfers.now = func() time.Time { return now }
+
+			code, c, err := transfers.Issue(context.Background(), "laptop")
+			require.NoError(t, err)
+			assert.Len(t, code, transferCodeLen)
+			assert.Equal(t, now.UTC().Add(10*time.Minute), c.ExpiresAt)
+			assert.NotEqual(t, code, c.Hash)
+
+			if tt.code != nil {
+				code = tt.code(code)
+			}
+			transfers.now = func() time.Time { return now.Add(tt.elapsed) }
+
+			uid, _, err := transfers.Redeem(context.Background(), code, "phone", tt.mode)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "laptop", uid)
+			assert.Equal(t, tt.wantMerged, merger.merged)
+
+			_, _, err = transfers.Redeem(context.Background(), code, "tablet", tt.mode)
+			assert.ErrorIs(t, err, utils.ErrInvalidTransfer, "code must be redeemed only once")
+		})
+	}
+}
+
+func TestTransfers_PurgeCodes(t *testing.T) {
+	store := NewMemoryTransfers()
+	transfers := NewTransfers(store, nil, time.Minute, zap.NewNop())
+
+	_, _, err := transfers.Issue(context.Background(), "expired")
+	require.NoError(t, err)
+	transfers.ttl = time.Hour
+	code, _, err := transfers.Issue(context.Background(), "active")
+	require.NoError(t, err)
+
+	now := time.Now()
+	transfers.now = func() time.Time { return now.Add(2 * time.Minute) }
+	require.NoError(t, transfers.PurgeCodes(context.Background()))
+	assert.Len(t, store.codes, 1)
+
+	uid, _, err := transfers.Redeem(context.Background(), code, "", TransferAdopt)
+	require.NoError(t, err)
+	assert.Equal(t, "active", uid)
+}
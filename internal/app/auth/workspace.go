@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Roles of workspace members. Viewer lists links of workspace, editor also shortens and deletes them
+// and owner also manages members.
+const (
+	WorkspaceViewer = "viewer"
+	WorkspaceEditor = "editor"
+	WorkspaceOwner  = "owner"
+)
+
+// maxWorkspaceNameLen - max length of workspace name in runes.
+const maxWorkspaceNameLen = 64
+
+// workspaceRanks - ranks of workspace roles, member with higher rank has all permissions of lower ranks.
+var workspaceRanks = map[string]int{
+	WorkspaceViewer: 1,
+	WorkspaceEditor: 2,
+	WorkspaceOwner:  3,
+}
+
+// Workspace - team sharing links. ID of workspace is used as user ID of its links.
+type Workspace struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+}
+
+// WorkspaceMember - membership of user in workspace.
+type WorkspaceMember struct {
+	AddedAt     time.Time `json:"added_at"`
+	WorkspaceID string    `json:"workspace_id"`
+	UID         string    `json:"uid"`
+	Role        string    `json:"role"`
+}
+
+// UserWorkspace - workspace with role of user in it.
+type UserWorkspace struct {
+	Workspace
+	Role string `json:"role"`
+}
+
+// WorkspaceStore - storage of workspaces and their members.
+type WorkspaceStore interface {
+	// CreateWorkspace - stores new workspace with its first member.
+	CreateWorkspace(ctx context.Context, w Workspace, owner WorkspaceMember) error
+	// UserWorkspaces - returns workspaces of user ordered by creation time.
+	UserWorkspaces(ctx context.Context, uid string) ([]UserWorkspace, error)
+	// Member - returns member of workspace or utils.ErrNotMember.
+	Member(ctx context.Context, workspaceID string, uid string) (WorkspaceMember, error)
+	// Members - returns members of workspace ordered by time of adding.
+	Members(ctx context.Context, workspaceID string) ([]WorkspaceMember, error)
+	// SaveMember - adds member to workspace or changes role of existing one, time of adding is kept.
+	SaveMember(ctx context.Context, m WorkspaceMember) error
+	// DeleteMember - removes member from workspace.
+	DeleteMember(ctx context.Context, workspaceID string, uid string) error
+}
+
+// WorkspaceAuthorizer - checks permissions of users in workspaces.
+type WorkspaceAuthorizer interface {
+	// Authorize - returns member of workspace if user has at least provided role in it, utils.ErrNotMember
+	// if user is not member of workspace and utils.ErrForbidden if role of user is lower.
+	Authorize(ctx context.Context, workspaceID string, uid string, role string) (WorkspaceMember, error)
+}
+
+// WorkspaceManager - creates workspaces and manages their members.
+type WorkspaceManager interface {
+	WorkspaceAuthorizer
+	// Create - creates workspace owned by user.
+	Create(ctx context.Context, uid string, name string) (Workspace, error)
+	// List - returns workspaces of user.
+	List(ctx context.Context, uid string) ([]UserWorkspace, error)
+	// Members - returns members of workspace, if user is its member.
+	Members(ctx context.Context, uid string, workspaceID string) ([]WorkspaceMember, error)
+	// SetMember - adds member with role to workspace or changes role of member, if user is owner of workspace.
+	SetMember(ctx context.Context, uid string, workspaceID string, memberUID string, role string) (WorkspaceMember, error)
+	// RemoveMember - removes member from workspace, if user is owner of workspace or member itself.
+	RemoveMember(ctx context.Context, uid string, workspaceID string, memberUID string) error
+}
+
+var _ WorkspaceManager = (*Workspaces)(nil)
+
+// Workspaces - manages workspaces stored in WorkspaceStore. Changes of membership are logged.
+type Workspaces struct {
+	store  WorkspaceStore
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewWorkspaces - creates Workspaces.
+func NewWorkspaces(s WorkspaceStore, l *zap.Logger) *Workspaces {
+	return &Workspaces{
+		store:  s,
+		logger: l,
+		now:    time.Now,
+	}
+}
+
+// Create - validates name and stores workspace with user as its owner.
+// Returns utils.ErrWorkspaceName if name is empty or longer than 64 characters.
+func (ws *Workspaces) Create(ctx context.Context, uid string, name string) (Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWorkspaceNameLen {
+		return Workspace{}, utils.ErrWorkspaceName
+	}
+
+	now := ws.now().UTC()
+	w := Workspace{CreatedAt: now, ID: uuid.New().String(), Name: name}
+	owner := WorkspaceMember{AddedAt: now, WorkspaceID: w.ID, UID: uid, Role: WorkspaceOwner}
+	if err := ws.store.CreateWorkspace(ctx, w, owner); err != nil {
+		return Workspace{}, err
+	}
+
+	ws.logger.Info("workspace created", zap.String("workspace", w.ID), zap.String("uid", uid))
+
+	return w, nil
+}
+
+// List - returns workspaces of user with role of user in each of them.
+func (ws *Workspaces) List(ctx context.Context, uid string) ([]UserWorkspace, error) {
+	return ws.store.UserWorkspaces(ctx, uid)
+}
+
+// Authorize - compares role of user in workspace with required one. Workspace ID which is not UUID
+// is reported as utils.ErrNotMember.
+func (ws *Workspaces) Authorize(ctx context.Context, workspaceID string, uid string, role string) (WorkspaceMember, error) {
+	if _, err := uuid.Parse(workspaceID); err != nil {
+		return WorkspaceMember{}, utils.ErrNotMember
+	}
+
+	m, err := ws.store.Member(ctx, workspaceID, uid)
+	if err != nil {
+		return WorkspaceMember{}, err
+	}
+	if workspaceRanks[m.Role] < workspaceRanks[role] {
+		return WorkspaceMember{}, utils.ErrForbidden
+	}
+
+	return m, nil
+}
+
+// Members - returns members of workspace to any of its members.
+func (ws *Workspaces) Members(ctx context.Context, uid string, workspaceID string) ([]WorkspaceMember, error) {
+	if _, err := ws.Authorize(ctx, workspaceID, uid, WorkspaceViewer); err != nil {
+		return nil, err
+	}
+
+	return ws.store.Members(ctx, workspaceID)
+}
+
+// SetMember - adds member to workspace or changes role of member. Only owner can do it and the last owner
+// can't be demoted. Returns utils.ErrWrongRole for unknown role and utils.ErrWrongUID if memberUID is not UUID.
+func (ws *Workspaces) SetMember(
+	ctx context.Context,
+	uid string,
+	workspaceID string,
+	memberUID string,
+	role string,
+) (WorkspaceMember, error) {
+	if _, ok := workspaceRanks[role]; !ok {
+		return WorkspaceMember{}, utils.ErrWrongRole
+	}
+	if _, err := uuid.Parse(memberUID); err != nil {
+		return WorkspaceMember{}, utils.ErrWrongUID
+	}
+	if _, err := ws.Authorize(ctx, workspaceID, uid, WorkspaceOwner); err != nil {
+		return WorkspaceMember{}, err
+	}
+
+	m, err := ws.store.Member(ctx, workspaceID, memberUID)
+	if errors.Is(err, utils.ErrNotMember) {
+		m = WorkspaceMember{AddedAt: ws.now().UTC(), WorkspaceID: workspaceID, UID: memberUID}
+		err = nil
+	}
+	if err != nil {
+		return WorkspaceMember{}, err
+	}
+
+	if m.Role == WorkspaceOwner && role != WorkspaceOwner {
+		if err = ws.keepOwner(ctx, workspaceID); err != nil {
+			return WorkspaceMember{}, err
+		}
+	}
+
+	m.Role = role
+	if err = ws.store.SaveMember(ctx, m); err != nil {
+		return WorkspaceMember{}, err
+	}
+
+	ws.logger.Info("workspace member saved", zap.String("workspace", workspaceID), zap.String("uid", uid),
+		zap.String("member", memberUID), zap.String("role", role))
+
+	return m, nil
+}
+
+// RemoveMember - removes member from workspace. Owner can remove anyone and any member can leave workspace,
+// but the last owner can't.
+func (ws *Workspaces) RemoveMember(ctx context.Context, uid string, workspaceID string, memberUID string) error {
+	required := WorkspaceOwner
+	if uid == memberUID {
+		required = WorkspaceViewer
+	}
+	if _, err := ws.Authorize(ctx, workspaceID, uid, required); err != nil {
+		return err
+	}
+
+	m, err := ws.store.Member(ctx, workspaceID, memberUID)
+	if err != nil {
+		return err
+	}
+	if m.Role == WorkspaceOwner {
+		if err = ws.keepOwner(ctx, workspaceID); err != nil {
+			return err
+		}
+	}
+
+	if err = ws.store.DeleteMember(ctx, workspaceID, memberUID); err != nil {
+		return err
+	}
+
+	ws.logger.Info("workspace member removed", zap.String("workspace", workspaceID), zap.String("uid", uid),
+		zap.String("member", memberUID))
+
+	return nil
+}
+
+// keepOwner - returns utils.ErrLastOwner if workspace has only one owner, so links of workspace are never left
+// without anyone managing them.
+func (ws *Workspaces) keepOwner(ctx context.Context, workspaceID string) error {
+	members, err := ws.store.Members(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+	for _, m := range members {
+		if m.Role == WorkspaceOwner {
+			owners++
+		}
+	}
+	if owners < 2 {
+		return utils.ErrLastOwner
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestWorkspaces_Create(t *testing.T) {
+	tests := []struct {
+		wantErr error
+		name    string
+		wsName  string
+	}{
+		{
+			name:   "Workspace can be created",
+			wsName: " Marketing ",
+		},
+		{
+			name:    "Workspace with empty name can't be created",
+			wsName:  "  ",
+			wantErr: utils.ErrWorkspaceName,
+		},
+		{
+			name:    "Workspace with too long name can't be created",
+			wsName:  string(make([]rune, maxWorkspaceNameLen+1)),
+			wantErr: utils.ErrWorkspaceName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := NewWorkspaces(NewMemoryWorkspaces(), zap.NewNop())
+			uid := uuid.NewString()
+
+			w, err := ws.Create(context.Background(), uid, tt.wsName)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "Marketing", w.Name)
+
+			list, err := ws.List(context.Background(), uid)
+			require.NoError(t, err)
+			assert.Equal(t, []UserWorkspace{{Workspace: w, Role: WorkspaceOwner}}, list)
+
+			list, err = ws.List(context.Background(), uuid.NewString())
+			require.NoError(t, err)
+			assert.Empty(t, list)
+		})
+	}
+}
+
+func TestWorkspaces_Authorize(t *testing.T) {
+	ctx := context.Background()
+	ws := NewWorkspaces(NewMemoryWorkspaces(), zap.NewNop())
+	owner, editor, viewer := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	w, err := ws.Create(ctx, owner, "Marketing")
+	require.NoError(t, err)
+	_, err = ws.SetMember(ctx, owner, w.ID, editor, WorkspaceEditor)
+	require.NoError(t, err)
+	_, err = ws.SetMember(ctx, owner, w.ID, viewer, WorkspaceViewer)
+	require.NoError(t, err)
+
+	tests := []struct {
+		wantErr     error
+		name        string
+		workspaceID string
+		uid         string
+		role        string
+	}{
+		{
+			name:        "Owner has editor permissions",
+			workspaceID: w.ID,
+			uid:         owner,
+			role:        WorkspaceEditor,
+		},
+		{
+			name:        "Editor has editor permissions",
+			workspaceID: w.ID,
+			uid:         editor,
+			role:        WorkspaceEditor,
+		},
+		{
+			name:        "Viewer has viewer permissions",
+			workspaceID: w.ID,
+			uid:         viewer,
+			role:        WorkspaceViewer,
+		},
+		{
+			name:        "Viewer has no editor permissions",
+			workspaceID: w.ID,
+			uid:         viewer,
+			role:        WorkspaceEditor,
+			wantErr:     utils.ErrForbidden,
+		},
+		{
+			name:        "Editor has no owner permissions",
+			workspaceID: w.ID,
+			uid:         editor,
+			role:        WorkspaceOwner,
+			wantErr:     utils.ErrForbidden,
+		},
+		{
+			name:        "Outsider has no permissions",
+			workspaceID: w.ID,
+			uid:         uuid.NewString(),
+			role:        WorkspaceViewer,
+			wantErr:     utils.ErrNotMember,
+		},
+		{
+			name:        "Workspace ID must be UUID",
+			workspaceID: "marketing",
+			uid:         owner,
+			role:        WorkspaceViewer,
+			wantErr:     utils.ErrNotMember,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ws.Authorize(ctx, tt.workspaceID, tt.uid, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.workspaceID, m.WorkspaceID)
+			assert.Equal(t, tt.uid, m.UID)
+		})
+	}
+}
+
+func TestWorkspaces_Members(t *testing.T) {
+	ctx := context.Background()
+	ws := NewWorkspaces(NewMemoryWorkspaces(), zap.NewNop())
+	owner, editor := uuid.NewString(), uuid.NewString()
+
+	w, err := ws.Create(ctx, owner, "Marketing")
+	require.NoError(t, err)
+
+	_, err = ws.SetMember(ctx, owner, w.ID, editor, "admin")
+	assert.ErrorIs(t, err, utils.ErrWrongRole)
+	_, err = ws.SetMember(ctx, owner, w.ID, "not-uuid", WorkspaceEditor)
+	assert.ErrorIs(t, err, utils.ErrWrongUID)
+
+	_, err = ws.SetMember(ctx, owner, w.ID, editor, WorkspaceEditor)
+	require.NoError(t, err)
+	_, err = ws.SetMember(ctx, editor, w.ID, uuid.NewString(), WorkspaceViewer)
+	assert.ErrorIs(t, err, utils.ErrForbidden, "editor can't add members")
+
+	_, err = ws.SetMember(ctx, owner, w.ID, owner, WorkspaceViewer)
+	assert.ErrorIs(t, err, utils.ErrLastOwner, "the last owner can't be demoted")
+	err = ws.RemoveMember(ctx, owner, w.ID, owner)
+	assert.ErrorIs(t, err, utils.ErrLastOwner, "the last owner can't leave")
+
+	_, err = ws.SetMember(ctx, owner, w.ID, editor, WorkspaceOwner)
+	require.NoError(t, err)
+	require.NoError(t, ws.RemoveMember(ctx, owner, w.ID, owner), "owner can leave when another owner remains")
+
+	members, err := ws.Members(ctx, editor, w.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	assert.Equal(t, WorkspaceOwner, members[0].Role)
+
+	_, err = ws.Members(ctx, owner, w.ID)
+	assert.ErrorIs(t, err, utils.ErrNotMember, "removed member can't list members")
+}
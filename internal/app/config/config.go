@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -17,9 +22,153 @@ type config struct {
 	FileStoragePath string `env:"FILE_STORAGE_PATH" envDefault:"" json:"file_storage_path"`         // path to file with stored URLs when using memory mode
 	DatabaseDSN     string `env:"DATABASE_DSN" envDefault:"" json:"database_dsn"`                   // dsn used to establish connection with database
 	JSONConfigPath  string `env:"CONFIG" envDefault:""`                                             // a path to config file
-	TrustedSubnet   string `env:"TRUSTED_SUBNET" envDefault:"" json:"trusted_subnet"`               // a trusted IP address
+	TrustedSubnet   string `env:"TRUSTED_SUBNET" envDefault:"" json:"trusted_subnet"`               // comma separated IPv4/IPv6 CIDRs allowed to internal API
 	GRPCPort        string `env:"GRPC_PORT" envDefault:"" json:"grpc_port"`                         // a port on which gRPC will be started
 	EnableHTTPS     bool   `env:"ENABLE_HTTPS" envDefault:"" json:"enable_https"`                   // a value used to determine http or https server will be run
+
+	CookieKeys      string   `env:"COOKIE_KEYS" envDefault:"" json:"cookie_keys"`              // cipher keys of uid cookie in format "id:hex,id:hex"
+	CookieKeysFile  string   `env:"COOKIE_KEYS_FILE" envDefault:"" json:"cookie_keys_file"`    // path to json file with cipher keys of uid cookie
+	CookieActiveKey string   `env:"COOKIE_ACTIVE_KEY" envDefault:"" json:"cookie_active_key"`  // ID of key used to encrypt new cookies
+	CookieName      string   `env:"COOKIE_NAME" envDefault:"uid" json:"cookie_name"`           // name of cookie with encrypted user ID
+	CookieDomain    string   `env:"COOKIE_DOMAIN" envDefault:"" json:"cookie_domain"`          // domain of uid cookie, host-only cookie when empty
+	CookieTTL       Duration `env:"COOKIE_TTL" envDefault:"720h" json:"cookie_ttl"`            // lifetime of uid cookie
+	CookieSliding   bool     `env:"COOKIE_SLIDING" envDefault:"true" json:"cookie_sliding"`    // prolong uid cookie when half of its lifetime has passed
+	CookieSameSite  string   `env:"COOKIE_SAME_SITE" envDefault:"lax" json:"cookie_same_site"` // SameSite of uid cookie: lax, strict or none
+	CookieSecure    string   `env:"COOKIE_SECURE" envDefault:"" json:"cookie_secure"`          // "true" or "false", follows ENABLE_HTTPS when empty
+
+	CSRFMode           string `env:"CSRF_MODE" envDefault:"origin" json:"csrf_mode"`                 // CSRF protection of public routes: off, origin, token or strict
+	CSRFAdminMode      string `env:"CSRF_ADMIN_MODE" envDefault:"origin" json:"csrf_admin_mode"`     // CSRF protection of admin routes: off, origin, token or strict
+	CSRFTrustedOrigins string `env:"CSRF_TRUSTED_ORIGINS" envDefault:"" json:"csrf_trusted_origins"` // comma separated origins allowed besides BASE_URL and own host
+
+	CORSAllowedOrigins   string   `env:"CORS_ALLOWED_ORIGINS" envDefault:"" json:"cors_allowed_origins"`                                                  // comma separated origins allowed to call API from browser, "https://*.example.com" allows subdomains, empty disables CORS
+	CORSAllowedMethods   string   `env:"CORS_ALLOWED_METHODS" envDefault:"GET,POST,PUT,DELETE" json:"cors_allowed_methods"`                               // comma separated methods allowed in cross-origin requests
+	CORSAllowedHeaders   string   `env:"CORS_ALLOWED_HEADERS" envDefault:"Content-Type,Authorization,X-API-Key,X-CSRF-Token" json:"cors_allowed_headers"` // comma separated request headers allowed in cross-origin requests
+	CORSExposedHeaders   string   `env:"CORS_EXPOSED_HEADERS" envDefault:"Location" json:"cors_exposed_headers"`                                          // comma separated response headers readable by scripts of allowed origins
+	CORSAllowCredentials bool     `env:"CORS_ALLOW_CREDENTIALS" envDefault:"false" json:"cors_allow_credentials"`                                         // allow cookies in cross-origin requests of listed origins (not "*"), they are trusted by CSRF protection then
+	CORSMaxAge           Duration `env:"CORS_MAX_AGE" envDefault:"10m" json:"cors_max_age"`                                                               // how long browser caches result of preflight request
+
+	HSTSMaxAge            Duration `env:"HSTS_MAX_AGE" envDefault:"8760h" json:"hsts_max_age"`                                                            // max-age of Strict-Transport-Security sent when ENABLE_HTTPS is on, 0 disables header
+	ContentSecurityPolicy string   `env:"CONTENT_SECURITY_POLICY" envDefault:"default-src 'none'; frame-ancestors 'none'" json:"content_security_policy"` // Content-Security-Policy of responses, empty disables header
+	ReferrerPolicy        string   `env:"REFERRER_POLICY" envDefault:"strict-origin-when-cross-origin" json:"referrer_policy"`                            // Referrer-Policy of responses, empty disables header
+
+	TokenAlgorithm string   `env:"TOKEN_ALGORITHM" envDefault:"HS256" json:"token_algorithm"` // HS256 or EdDSA
+	TokenSecret    string   `env:"TOKEN_SECRET" envDefault:"" json:"token_secret"`            // secret of HS256 tokens
+	TokenKeyFile   string   `env:"TOKEN_KEY_FILE" envDefault:"" json:"token_key_file"`        // path to PEM Ed25519 private key of EdDSA tokens
+	TokenTTL       Duration `env:"TOKEN_TTL" envDefault:"24h" json:"token_ttl"`               // lifetime of token when it's not requested
+	TokenMaxTTL    Duration `env:"TOKEN_MAX_TTL" envDefault:"720h" json:"token_max_ttl"`      // max lifetime of token which can be requested
+
+	APIKeyRateLimit int `env:"API_KEY_RATE_LIMIT" envDefault:"600" json:"api_key_rate_limit"` // requests per minute allowed to API key when its own limit is not set
+
+	RateLimitShorten  int      `env:"RATE_LIMIT_SHORTEN" envDefault:"60" json:"rate_limit_shorten"`    // requests per minute of client shortening single links, 0 disables limit
+	RateLimitBatch    int      `env:"RATE_LIMIT_BATCH" envDefault:"10" json:"rate_limit_batch"`        // requests per minute of client shortening batches of links, 0 disables limit
+	RateLimitRedirect int      `env:"RATE_LIMIT_REDIRECT" envDefault:"600" json:"rate_limit_redirect"` // requests per minute of client expanding short links, 0 disables limit
+	RateLimitDelete   int      `env:"RATE_LIMIT_DELETE" envDefault:"30" json:"rate_limit_delete"`      // requests per minute of client deleting links, 0 disables limit
+	RateLimitLogin    int      `env:"RATE_LIMIT_LOGIN" envDefault:"10" json:"rate_limit_login"`        // requests per minute of client logging in with password, 0 disables limit
+	RateLimitShared   bool     `env:"RATE_LIMIT_SHARED" envDefault:"false" json:"rate_limit_shared"`   // keep rate limits in database, so replicas share them
+	TrustedProxies    CIDRList `env:"TRUSTED_PROXIES" envDefault:"" json:"trusted_proxies"`            // comma separated CIDRs of proxies whose X-Forwarded-For and X-Real-IP are trusted
+
+	QuotaMaxLinks int `env:"QUOTA_MAX_LINKS" envDefault:"0" json:"quota_max_links"`    // max amount of active links of user, 0 disables quota
+	QuotaMaxBatch int `env:"QUOTA_MAX_BATCH" envDefault:"1000" json:"quota_max_batch"` // max amount of links in one batch, 0 disables quota
+	QuotaMaxDaily int `env:"QUOTA_MAX_DAILY" envDefault:"0" json:"quota_max_daily"`    // max amount of links created by user per UTC day, 0 disables quota
+
+	GRPCLegacyErrors   bool     `env:"GRPC_LEGACY_ERRORS" envDefault:"false" json:"grpc_legacy_errors"`  // report errors of gRPC methods only in error field of responses, as before status codes were used
+	GRPCReflection     bool     `env:"GRPC_REFLECTION" envDefault:"false" json:"grpc_reflection"`        // register gRPC server reflection, so tools like grpcurl can list services
+	GRPCHealthInterval Duration `env:"GRPC_HEALTH_INTERVAL" envDefault:"5s" json:"grpc_health_interval"` // how often storage is checked for grpc.health.v1 service
+
+	GRPCCertFile          string `env:"GRPC_CERT_FILE" envDefault:"" json:"grpc_cert_file"`                          // path to PEM certificate of gRPC listener, TLS is on when it's set or when ENABLE_HTTPS is on
+	GRPCKeyFile           string `env:"GRPC_KEY_FILE" envDefault:"" json:"grpc_key_file"`                            // path to PEM private key of GRPC_CERT_FILE
+	GRPCClientCAFile      string `env:"GRPC_CLIENT_CA_FILE" envDefault:"" json:"grpc_client_ca_file"`                // path to PEM CA verifying client certificates of gRPC, enables mutual TLS
+	GRPCRequireClientCert bool   `env:"GRPC_REQUIRE_CLIENT_CERT" envDefault:"false" json:"grpc_require_client_cert"` // reject gRPC connections without client certificate signed by GRPC_CLIENT_CA_FILE
+	GRPCAdminCertNames    string `env:"GRPC_ADMIN_CERT_NAMES" envDefault:"" json:"grpc_admin_cert_names"`            // comma separated common names or SANs of client certificates granted admin role
+
+	SessionTTL      Duration `env:"SESSION_TTL" envDefault:"720h" json:"session_ttl"`            // lifetime of login session of account
+	TransferCodeTTL Duration `env:"TRANSFER_CODE_TTL" envDefault:"10m" json:"transfer_code_ttl"` // lifetime of one-time code transferring uid to another device
+
+	OIDCIssuer       string `env:"OIDC_ISSUER" envDefault:"" json:"oidc_issuer"`                     // issuer URL of identity provider, empty disables OIDC login
+	OIDCClientID     string `env:"OIDC_CLIENT_ID" envDefault:"" json:"oidc_client_id"`               // client ID registered at identity provider
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET" envDefault:"" json:"oidc_client_secret"`       // client secret, empty for public client
+	OIDCRedirectURL  string `env:"OIDC_REDIRECT_URL" envDefault:"" json:"oidc_redirect_url"`         // URL of /api/auth/oidc/callback registered at identity provider
+	OIDCScopes       string `env:"OIDC_SCOPES" envDefault:"openid email profile" json:"oidc_scopes"` // space separated scopes requested from identity provider
+	OIDCUserClaim    string `env:"OIDC_USER_CLAIM" envDefault:"sub" json:"oidc_user_claim"`          // claim mapped to user ID, "sub" or "email"
+	OIDCGroupsClaim  string `env:"OIDC_GROUPS_CLAIM" envDefault:"groups" json:"oidc_groups_claim"`   // claim with groups of user
+	OIDCRoles        string `env:"OIDC_ROLES" envDefault:"" json:"oidc_roles"`                       // mapping of groups to roles in format "group:role,group:role"
+
+	RetentionDays      int      `env:"RETENTION_DAYS" envDefault:"0" json:"retention_days"`                // days after which soft deleted links are purged, 0 disables purging
+	QuarantineDays     int      `env:"QUARANTINE_DAYS" envDefault:"0" json:"quarantine_days"`              // days during which keys of purged links can't be reused
+	RetentionBatchSize int      `env:"RETENTION_BATCH_SIZE" envDefault:"1000" json:"retention_batch_size"` // max amount of links purged in one batch
+	RetentionInterval  Duration `env:"RETENTION_INTERVAL" envDefault:"1h" json:"retention_interval"`       // interval between background purges
+
+	DeleteQueueSize    int      `env:"DELETE_QUEUE_SIZE" envDefault:"1000" json:"delete_queue_size"`        // max amount of pending delete requests
+	DeleteWorkers      int      `env:"DELETE_WORKERS" envDefault:"4" json:"delete_workers"`                 // amount of workers processing delete requests
+	DeleteRetries      int      `env:"DELETE_RETRIES" envDefault:"3" json:"delete_retries"`                 // amount of retries of failed delete request
+	DeleteRetryBackoff Duration `env:"DELETE_RETRY_BACKOFF" envDefault:"100ms" json:"delete_retry_backoff"` // initial pause before retry, doubled on each retry
+
+	JobWorkers           int      `env:"JOB_WORKERS" envDefault:"2" json:"job_workers"`                        // amount of workers processing background jobs
+	JobMaxAttempts       int      `env:"JOB_MAX_ATTEMPTS" envDefault:"5" json:"job_max_attempts"`              // attempts after which job is dead-lettered
+	JobRetryBackoff      Duration `env:"JOB_RETRY_BACKOFF" envDefault:"1s" json:"job_retry_backoff"`           // initial pause before retry of failed job
+	JobVisibilityTimeout Duration `env:"JOB_VISIBILITY_TIMEOUT" envDefault:"1m" json:"job_visibility_timeout"` // time after which not completed job can be claimed again
+	JobPollInterval      Duration `env:"JOB_POLL_INTERVAL" envDefault:"1s" json:"job_poll_interval"`           // pause of worker when there are no ready jobs
+	JobDeadTTL           Duration `env:"JOB_DEAD_TTL" envDefault:"168h" json:"job_dead_ttl"`                   // time after which dead jobs are removed, 0 keeps them
+
+	UserURLsPageSize    int `env:"USER_URLS_PAGE_SIZE" envDefault:"100" json:"user_urls_page_size"`          // amount of user links on page when limit is not provided
+	UserURLsMaxPageSize int `env:"USER_URLS_MAX_PAGE_SIZE" envDefault:"1000" json:"user_urls_max_page_size"` // max amount of user links on page
+
+	SchedulerTick       Duration `env:"SCHEDULER_TICK" envDefault:"10s" json:"scheduler_tick"`              // how often scheduler checks leadership and due tasks
+	TTLPurgeInterval    Duration `env:"TTL_PURGE_INTERVAL" envDefault:"1h" json:"ttl_purge_interval"`       // interval between purges of records with expired TTL
+	StatsRollupInterval Duration `env:"STATS_ROLLUP_INTERVAL" envDefault:"5m" json:"stats_rollup_interval"` // interval between rollups of links and users counts
+}
+
+// Duration - time.Duration which can be parsed from strings like "1h30m" in env variables and json config.
+// Cron-like intervals "@hourly", "@daily", "@weekly" and "@every 1h30m" are accepted as well.
+type Duration time.Duration
+
+// cronIntervals - intervals that can be set by cron-like names.
+var cronIntervals = map[string]time.Duration{
+	"@hourly": time.Hour,
+	"@daily":  24 * time.Hour,
+	"@weekly": 7 * 24 * time.Hour,
+}
+
+// UnmarshalText - parses Duration via time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	spec := strings.TrimSpace(string(text))
+	if v, ok := cronIntervals[spec]; ok {
+		*d = Duration(v)
+		return nil
+	}
+
+	v, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every")))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+// CIDRList - comma separated IPv4 and IPv6 CIDRs. Address without prefix length is accepted as network of single
+// host.
+type CIDRList string
+
+// UnmarshalText - validates every CIDR of list, so config with malformed CIDR is not loaded.
+func (l *CIDRList) UnmarshalText(text []byte) error {
+	for _, cidr := range splitList(string(text)) {
+		if !strings.Contains(cidr, "/") {
+			if net.ParseIP(cidr) == nil {
+				return &net.ParseError{Type: "CIDR address", Text: cidr}
+			}
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return err
+		}
+	}
+
+	*l = CIDRList(text)
+
+	return nil
 }
 
 // OptionConfig - callback that can be provided to NewConfig to construct config with non default params.
@@ -100,6 +249,155 @@ func WithGRPCPort(s string) OptionConfig {
 	}
 }
 
+// WithRetentionDays - Generate config with RetentionDays.
+func WithRetentionDays(days int) OptionConfig {
+	return func(c *config) {
+		c.RetentionDays = days
+	}
+}
+
+// WithQuarantineDays - Generate config with QuarantineDays.
+func WithQuarantineDays(days int) OptionConfig {
+	return func(c *config) {
+		c.QuarantineDays = days
+	}
+}
+
+// WithRetentionBatchSize - Generate config with RetentionBatchSize.
+func WithRetentionBatchSize(size int) OptionConfig {
+	return func(c *config) {
+		c.RetentionBatchSize = size
+	}
+}
+
+// WithDeleteQueueSize - Generate config with DeleteQueueSize.
+func WithDeleteQueueSize(size int) OptionConfig {
+	return func(c *config) {
+		c.DeleteQueueSize = size
+	}
+}
+
+// WithDeleteWorkers - Generate config with DeleteWorkers.
+func WithDeleteWorkers(workers int) OptionConfig {
+	return func(c *config) {
+		c.DeleteWorkers = workers
+	}
+}
+
+// WithJobWorkers - Generate config with JobWorkers.
+func WithJobWorkers(workers int) OptionConfig {
+	return func(c *config) {
+		c.JobWorkers = workers
+	}
+}
+
+// WithJobDeadTTL - Generate config with JobDeadTTL.
+func WithJobDeadTTL(ttl time.Duration) OptionConfig {
+	return func(c *config) {
+		c.JobDeadTTL = Duration(ttl)
+	}
+}
+
+// WithJobMaxAttempts - Generate config with JobMaxAttempts.
+func WithJobMaxAttempts(attempts int) OptionConfig {
+	return func(c *config) {
+		c.JobMaxAttempts = attempts
+	}
+}
+
+// WithCookieKeysFile - Generate config with CookieKeysFile.
+func WithCookieKeysFile(path string) OptionConfig {
+	return func(c *config) {
+		c.CookieKeysFile = path
+	}
+}
+
+// WithCookieName - Generate config with CookieName.
+func WithCookieName(name string) OptionConfig {
+	return func(c *config) {
+		c.CookieName = name
+	}
+}
+
+// WithCookieTTL - Generate config with CookieTTL.
+func WithCookieTTL(ttl time.Duration) OptionConfig {
+	return func(c *config) {
+		c.CookieTTL = Duration(ttl)
+	}
+}
+
+// WithCookieSliding - Generate config with CookieSliding.
+func WithCookieSliding(isSliding bool) OptionConfig {
+	return func(c *config) {
+		c.CookieSliding = isSliding
+	}
+}
+
+// WithCookieSecure - Generate config with CookieSecure.
+func WithCookieSecure(secure string) OptionConfig {
+	return func(c *config) {
+		c.CookieSecure = secure
+	}
+}
+
+// WithCSRFTrustedOrigins - Generate config with CSRFTrustedOrigins.
+func WithCSRFTrustedOrigins(origins string) OptionConfig {
+	return func(c *config) {
+		c.CSRFTrustedOrigins = origins
+	}
+}
+
+// WithCORSAllowedOrigins - Generate config with CORSAllowedOrigins.
+func WithCORSAllowedOrigins(origins string) OptionConfig {
+	return func(c *config) {
+		c.CORSAllowedOrigins = origins
+	}
+}
+
+// WithCORSAllowCredentials - Generate config with CORSAllowCredentials.
+func WithCORSAllowCredentials(isAllowed bool) OptionConfig {
+	return func(c *config) {
+		c.CORSAllowCredentials = isAllowed
+	}
+}
+
+// WithTrustedProxies - Generate config with TrustedProxies.
+func WithTrustedProxies(proxies string) OptionConfig {
+	return func(c *config) {
+		c.TrustedProxies = CIDRList(proxies)
+	}
+}
+
+// WithGRPCLegacyErrors - Generate config with GRPCLegacyErrors.
+func WithGRPCLegacyErrors(isLegacy bool) OptionConfig {
+	return func(c *config) {
+		c.GRPCLegacyErrors = isLegacy
+	}
+}
+
+// WithGRPCReflection - Generate config with GRPCReflection.
+func WithGRPCReflection(isEnabled bool) OptionConfig {
+	return func(c *config) {
+		c.GRPCReflection = isEnabled
+	}
+}
+
+// WithGRPCTLS - Generate config with GRPCCertFile, GRPCKeyFile and GRPCClientCAFile.
+func WithGRPCTLS(certFile string, keyFile string, clientCAFile string) OptionConfig {
+	return func(c *config) {
+		c.GRPCCertFile = certFile
+		c.GRPCKeyFile = keyFile
+		c.GRPCClientCAFile = clientCAFile
+	}
+}
+
+// WithGRPCAdminCertNames - Generate config with GRPCAdminCertNames.
+func WithGRPCAdminCertNames(names string) OptionConfig {
+	return func(c *config) {
+		c.GRPCAdminCertNames = names
+	}
+}
+
 // ServerAddress - Get ServerAddress from config.
 func ServerAddress() string {
 	return cfg.ServerAddress
@@ -130,16 +428,436 @@ func JSONConfigPath() string {
 	return cfg.JSONConfigPath
 }
 
-// TrustedSubnet - get trusted IP.
+// TrustedSubnet - get trusted subnets as configured.
 func TrustedSubnet() string {
 	return cfg.TrustedSubnet
 }
 
+// TrustedSubnets - get CIDRs of trusted subnets.
+func TrustedSubnets() []string {
+	return splitList(cfg.TrustedSubnet)
+}
+
 // GRPCPort - get port on which gRPC is started.
 func GRPCPort() string {
 	return cfg.GRPCPort
 }
 
+// CookieName - get name of cookie with encrypted user ID.
+func CookieName() string {
+	return cfg.CookieName
+}
+
+// CookieDomain - get domain of uid cookie, empty for host-only cookie.
+func CookieDomain() string {
+	return cfg.CookieDomain
+}
+
+// CookieTTL - get lifetime of uid cookie.
+func CookieTTL() time.Duration {
+	return time.Duration(cfg.CookieTTL)
+}
+
+// CookieSliding - reports whether uid cookie is prolonged when half of its lifetime has passed.
+func CookieSliding() bool {
+	return cfg.CookieSliding
+}
+
+// CookieSameSite - get SameSite mode of uid cookie. Unknown values are treated as lax.
+func CookieSameSite() http.SameSite {
+	switch strings.ToLower(cfg.CookieSameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// CookieSecure - reports whether uid cookie is sent only over HTTPS. Follows EnableHTTPS unless set explicitly.
+func CookieSecure() bool {
+	if secure, err := strconv.ParseBool(cfg.CookieSecure); err == nil {
+		return secure
+	}
+
+	return cfg.EnableHTTPS
+}
+
+// RateLimitShorten - get requests per minute of client shortening single links.
+func RateLimitShorten() int {
+	return cfg.RateLimitShorten
+}
+
+// RateLimitBatch - get requests per minute of client shortening batches of links.
+func RateLimitBatch() int {
+	return cfg.RateLimitBatch
+}
+
+// RateLimitRedirect - get requests per minute of client expanding short links.
+func RateLimitRedirect() int {
+	return cfg.RateLimitRedirect
+}
+
+// RateLimitDelete - get requests per minute of client deleting links.
+func RateLimitDelete() int {
+	return cfg.RateLimitDelete
+}
+
+// RateLimitLogin - get requests per minute of client logging in with password.
+func RateLimitLogin() int {
+	return cfg.RateLimitLogin
+}
+
+// RateLimitShared - reports whether rate limits are kept in database and shared by replicas.
+func RateLimitShared() bool {
+	return cfg.RateLimitShared
+}
+
+// TrustedProxies - get CIDRs of proxies whose X-Forwarded-For and X-Real-IP headers are trusted.
+func TrustedProxies() []string {
+	return splitList(string(cfg.TrustedProxies))
+}
+
+// QuotaMaxLinks - get max amount of active links of user.
+func QuotaMaxLinks() int {
+	return cfg.QuotaMaxLinks
+}
+
+// QuotaMaxBatch - get max amount of links in one batch.
+func QuotaMaxBatch() int {
+	return cfg.QuotaMaxBatch
+}
+
+// QuotaMaxDaily - get max amount of links created by user per day.
+func QuotaMaxDaily() int {
+	return cfg.QuotaMaxDaily
+}
+
+// GRPCLegacyErrors - get whether gRPC methods report errors in error field of responses instead of status.
+// Deprecated mode kept for one release, so clients can switch to status codes.
+func GRPCLegacyErrors() bool {
+	return cfg.GRPCLegacyErrors
+}
+
+// GRPCReflection - get whether gRPC server reflection is registered.
+func GRPCReflection() bool {
+	return cfg.GRPCReflection
+}
+
+// GRPCHealthInterval - get how often storage is checked for gRPC health service.
+func GRPCHealthInterval() time.Duration {
+	return time.Duration(cfg.GRPCHealthInterval)
+}
+
+// GRPCCertFile - get path to PEM certificate of gRPC listener.
+func GRPCCertFile() string {
+	return cfg.GRPCCertFile
+}
+
+// GRPCKeyFile - get path to PEM private key of gRPC listener.
+func GRPCKeyFile() string {
+	return cfg.GRPCKeyFile
+}
+
+// GRPCTLS - get whether gRPC listener uses TLS.
+func GRPCTLS() bool {
+	return cfg.GRPCCertFile != "" || cfg.EnableHTTPS
+}
+
+// GRPCClientCAFile - get path to PEM CA verifying client certificates of gRPC.
+func GRPCClientCAFile() string {
+	return cfg.GRPCClientCAFile
+}
+
+// GRPCRequireClientCert - get whether gRPC connections without client certificate are rejected.
+func GRPCRequireClientCert() bool {
+	return cfg.GRPCRequireClientCert
+}
+
+// GRPCAdminCertNames - get common names or SANs of client certificates granted admin role.
+func GRPCAdminCertNames() []string {
+	return splitList(cfg.GRPCAdminCertNames)
+}
+
+// CSRFMode - get mode of CSRF protection of public routes.
+func CSRFMode() string {
+	return cfg.CSRFMode
+}
+
+// CSRFAdminMode - get mode of CSRF protection of admin routes.
+func CSRFAdminMode() string {
+	return cfg.CSRFAdminMode
+}
+
+// CSRFTrustedOrigins - get origins allowed to make state-changing requests besides BaseURL and own host.
+func CSRFTrustedOrigins() []string {
+	origins := splitList(cfg.CSRFTrustedOrigins)
+	for i := range origins {
+		origins[i] = strings.TrimSuffix(origins[i], "/")
+	}
+
+	return origins
+}
+
+// CORSAllowedOrigins - get origins allowed to call API from browser. Origin may contain wildcard subdomain
+// like "https://*.example.com", "*" allows any origin.
+func CORSAllowedOrigins() []string {
+	origins := splitList(cfg.CORSAllowedOrigins)
+	for i := range origins {
+		origins[i] = strings.TrimSuffix(origins[i], "/")
+	}
+
+	return origins
+}
+
+// CORSAllowedMethods - get methods allowed in cross-origin requests.
+func CORSAllowedMethods() []string {
+	return splitList(cfg.CORSAllowedMethods)
+}
+
+// CORSAllowedHeaders - get request headers allowed in cross-origin requests.
+func CORSAllowedHeaders() []string {
+	return splitList(cfg.CORSAllowedHeaders)
+}
+
+// CORSExposedHeaders - get response headers readable by scripts of allowed origins.
+func CORSExposedHeaders() []string {
+	return splitList(cfg.CORSExposedHeaders)
+}
+
+// CORSAllowCredentials - reports whether cookies are allowed in cross-origin requests.
+func CORSAllowCredentials() bool {
+	return cfg.CORSAllowCredentials
+}
+
+// CORSMaxAge - get how long browser caches result of preflight request.
+func CORSMaxAge() time.Duration {
+	return time.Duration(cfg.CORSMaxAge)
+}
+
+// HSTSMaxAge - get max-age of Strict-Transport-Security header. Zero when HTTPS is off, because browsers
+// ignore header received over plain HTTP.
+func HSTSMaxAge() time.Duration {
+	if !cfg.EnableHTTPS {
+		return 0
+	}
+
+	return time.Duration(cfg.HSTSMaxAge)
+}
+
+// ContentSecurityPolicy - get Content-Security-Policy of responses.
+func ContentSecurityPolicy() string {
+	return cfg.ContentSecurityPolicy
+}
+
+// ReferrerPolicy - get Referrer-Policy of responses.
+func ReferrerPolicy() string {
+	return cfg.ReferrerPolicy
+}
+
+// splitList - splits comma separated list, skipping empty items.
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// CookieKeys - get cipher keys of uid cookie in format "id:hex,id:hex".
+func CookieKeys() string {
+	return cfg.CookieKeys
+}
+
+// CookieKeysFile - get path to json file with cipher keys of uid cookie.
+func CookieKeysFile() string {
+	return cfg.CookieKeysFile
+}
+
+// CookieActiveKey - get ID of key used to encrypt new cookies.
+func CookieActiveKey() string {
+	return cfg.CookieActiveKey
+}
+
+// TokenAlgorithm - get algorithm of bearer tokens.
+func TokenAlgorithm() string {
+	return cfg.TokenAlgorithm
+}
+
+// TokenSecret - get secret of HS256 bearer tokens.
+func TokenSecret() string {
+	return cfg.TokenSecret
+}
+
+// TokenKeyFile - get path to PEM Ed25519 private key of EdDSA bearer tokens.
+func TokenKeyFile() string {
+	return cfg.TokenKeyFile
+}
+
+// TokenTTL - get lifetime of bearer token when it's not requested.
+func TokenTTL() time.Duration {
+	return time.Duration(cfg.TokenTTL)
+}
+
+// TokenMaxTTL - get max lifetime of bearer token which can be requested.
+func TokenMaxTTL() time.Duration {
+	return time.Duration(cfg.TokenMaxTTL)
+}
+
+// APIKeyRateLimit - get requests per minute allowed to API key when its own limit is not set.
+func APIKeyRateLimit() int {
+	return cfg.APIKeyRateLimit
+}
+
+// SessionTTL - get lifetime of login session of account.
+func SessionTTL() time.Duration {
+	return time.Duration(cfg.SessionTTL)
+}
+
+// TransferCodeTTL - get lifetime of one-time code transferring uid to another device.
+func TransferCodeTTL() time.Duration {
+	return time.Duration(cfg.TransferCodeTTL)
+}
+
+// OIDCIssuer - get issuer URL of identity provider. Empty issuer disables OIDC login.
+func OIDCIssuer() string {
+	return cfg.OIDCIssuer
+}
+
+// OIDCClientID - get client ID registered at identity provider.
+func OIDCClientID() string {
+	return cfg.OIDCClientID
+}
+
+// OIDCClientSecret - get client secret registered at identity provider.
+func OIDCClientSecret() string {
+	return cfg.OIDCClientSecret
+}
+
+// OIDCRedirectURL - get URL of OIDC callback registered at identity provider.
+func OIDCRedirectURL() string {
+	return cfg.OIDCRedirectURL
+}
+
+// OIDCScopes - get scopes requested from identity provider.
+func OIDCScopes() []string {
+	return strings.Fields(cfg.OIDCScopes)
+}
+
+// OIDCUserClaim - get claim mapped to user ID.
+func OIDCUserClaim() string {
+	return cfg.OIDCUserClaim
+}
+
+// OIDCGroupsClaim - get claim with groups of user.
+func OIDCGroupsClaim() string {
+	return cfg.OIDCGroupsClaim
+}
+
+// OIDCRoles - get mapping of groups to roles in format "group:role,group:role".
+func OIDCRoles() string {
+	return cfg.OIDCRoles
+}
+
+// RetentionDays - get days after which soft deleted links are purged.
+func RetentionDays() int {
+	return cfg.RetentionDays
+}
+
+// QuarantineDays - get days during which keys of purged links can't be reused.
+func QuarantineDays() int {
+	return cfg.QuarantineDays
+}
+
+// RetentionBatchSize - get max amount of links purged in one batch.
+func RetentionBatchSize() int {
+	return cfg.RetentionBatchSize
+}
+
+// RetentionInterval - get interval between background purges.
+func RetentionInterval() time.Duration {
+	return time.Duration(cfg.RetentionInterval)
+}
+
+// DeleteQueueSize - get max amount of pending delete requests.
+func DeleteQueueSize() int {
+	return cfg.DeleteQueueSize
+}
+
+// DeleteWorkers - get amount of workers processing delete requests.
+func DeleteWorkers() int {
+	return cfg.DeleteWorkers
+}
+
+// DeleteRetries - get amount of retries of failed delete request.
+func DeleteRetries() int {
+	return cfg.DeleteRetries
+}
+
+// DeleteRetryBackoff - get initial pause before retry of failed delete request.
+func DeleteRetryBackoff() time.Duration {
+	return time.Duration(cfg.DeleteRetryBackoff)
+}
+
+// JobWorkers - get amount of workers processing background jobs.
+func JobWorkers() int {
+	return cfg.JobWorkers
+}
+
+// JobMaxAttempts - get amount of attempts after which job is dead-lettered.
+func JobMaxAttempts() int {
+	return cfg.JobMaxAttempts
+}
+
+// JobRetryBackoff - get initial pause before retry of failed job.
+func JobRetryBackoff() time.Duration {
+	return time.Duration(cfg.JobRetryBackoff)
+}
+
+// JobVisibilityTimeout - get time after which not completed job can be claimed again.
+func JobVisibilityTimeout() time.Duration {
+	return time.Duration(cfg.JobVisibilityTimeout)
+}
+
+// JobPollInterval - get pause of worker when there are no ready jobs.
+func JobPollInterval() time.Duration {
+	return time.Duration(cfg.JobPollInterval)
+}
+
+// JobDeadTTL - get time after which dead jobs are removed.
+func JobDeadTTL() time.Duration {
+	return time.Duration(cfg.JobDeadTTL)
+}
+
+// UserURLsPageSize - get amount of user links on page when limit is not provided.
+func UserURLsPageSize() int {
+	return cfg.UserURLsPageSize
+}
+
+// UserURLsMaxPageSize - get max amount of user links on page.
+func UserURLsMaxPageSize() int {
+	return cfg.UserURLsMaxPageSize
+}
+
+// SchedulerTick - get how often scheduler checks leadership and due tasks.
+func SchedulerTick() time.Duration {
+	return time.Duration(cfg.SchedulerTick)
+}
+
+// TTLPurgeInterval - get interval between purges of records with expired TTL.
+func TTLPurgeInterval() time.Duration {
+	return time.Duration(cfg.TTLPurgeInterval)
+}
+
+// StatsRollupInterval - get interval between rollups of links and users counts.
+func StatsRollupInterval() time.Duration {
+	return time.Duration(cfg.StatsRollupInterval)
+}
+
 // SetJSONValues - set config zero values to json.config values.
 func (c *config) SetJSONValues() {
 	// Open jsonFile
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -68,6 +69,61 @@ func TestNewConfig(t *testing.T) {
 				BaseURL:         "http://localhost:8080",
 				FileStoragePath: "",
 				DatabaseDSN:     "",
+				CookieName:      "uid",
+				CookieTTL:       Duration(720 * time.Hour),
+				CookieSliding:   true,
+				CookieSameSite:  "lax",
+				CSRFMode:        "origin",
+				CSRFAdminMode:   "origin",
+
+				CORSAllowedMethods:    "GET,POST,PUT,DELETE",
+				CORSAllowedHeaders:    "Content-Type,Authorization,X-API-Key,X-CSRF-Token",
+				CORSExposedHeaders:    "Location",
+				CORSMaxAge:            Duration(10 * time.Minute),
+				HSTSMaxAge:            Duration(8760 * time.Hour),
+				ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+				ReferrerPolicy:        "strict-origin-when-cross-origin",
+
+				TokenAlgorithm:  "HS256",
+				TokenTTL:        Duration(24 * time.Hour),
+				TokenMaxTTL:     Duration(720 * time.Hour),
+				APIKeyRateLimit: 600,
+
+				RateLimitShorten:  60,
+				RateLimitBatch:    10,
+				RateLimitRedirect: 600,
+				RateLimitDelete:   30,
+				RateLimitLogin:    10,
+
+				QuotaMaxBatch: 1000,
+
+				GRPCHealthInterval: Duration(5 * time.Second),
+
+				SessionTTL:         Duration(720 * time.Hour),
+				TransferCodeTTL:    Duration(10 * time.Minute),
+				OIDCScopes:         "openid email profile",
+				OIDCUserClaim:      "sub",
+				OIDCGroupsClaim:    "groups",
+				RetentionBatchSize: 1000,
+				RetentionInterval:  Duration(time.Hour),
+				DeleteQueueSize:    1000,
+				DeleteWorkers:      4,
+				DeleteRetries:      3,
+				DeleteRetryBackoff: Duration(100 * time.Millisecond),
+
+				JobWorkers:           2,
+				JobMaxAttempts:       5,
+				JobRetryBackoff:      Duration(time.Second),
+				JobVisibilityTimeout: Duration(time.Minute),
+				JobPollInterval:      Duration(time.Second),
+				JobDeadTTL:           Duration(168 * time.Hour),
+
+				UserURLsPageSize:    100,
+				UserURLsMaxPageSize: 1000,
+
+				SchedulerTick:       Duration(10 * time.Second),
+				TTLPurgeInterval:    Duration(time.Hour),
+				StatsRollupInterval: Duration(5 * time.Minute),
 			},
 		},
 	}
@@ -300,6 +356,65 @@ func TestTrustedSubnet(t *testing.T) {
 	}
 }
 
+func TestTrustedSubnets(t *testing.T) {
+	tests := []struct {
+		name   string
+		subnet string
+		want   []string
+	}{
+		{
+			name:   "Empty trusted subnet has no CIDRs",
+			subnet: "",
+			want:   []string{},
+		},
+		{
+			name:   "Trusted subnet can contain several IPv4 and IPv6 CIDRs",
+			subnet: "10.0.0.0/8, fd00::/8,,192.168.1.1",
+			want:   []string{"10.0.0.0/8", "fd00::/8", "192.168.1.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			NewConfig(WithTrustedSubnet(tt.subnet))
+			defer NewConfig(WithTrustedSubnet(""))
+
+			assert.Equal(t, tt.want, TrustedSubnets())
+		})
+	}
+}
+
+func TestGRPCTLS(t *testing.T) {
+	tests := []struct {
+		name        string
+		certFile    string
+		enableHTTPS bool
+		want        bool
+	}{
+		{
+			name: "gRPC is plaintext by default",
+			want: false,
+		},
+		{
+			name:     "gRPC uses TLS when certificate is configured",
+			certFile: "grpc.crt",
+			want:     true,
+		},
+		{
+			name:        "gRPC uses TLS when HTTPS is enabled",
+			enableHTTPS: true,
+			want:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			NewConfig(WithGRPCTLS(tt.certFile, "", ""), WithEnableHTTPS(tt.enableHTTPS))
+			defer NewConfig(WithGRPCTLS("", "", ""), WithEnableHTTPS(false))
+
+			assert.Equal(t, tt.want, GRPCTLS())
+		})
+	}
+}
+
 func TestWithGRPCPort(t *testing.T) {
 	tests := []struct {
 		name string
@@ -334,3 +449,105 @@ func TestGRPCPort(t *testing.T) {
 		})
 	}
 }
+
+func TestWithRetentionDays(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{
+			name: "Config WithRetentionDays and WithQuarantineDays can be created",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig(WithRetentionDays(30), WithQuarantineDays(7), WithRetentionBatchSize(10))
+			assert.Equal(t, 30, RetentionDays())
+			assert.Equal(t, 7, QuarantineDays())
+			assert.Equal(t, 10, RetentionBatchSize())
+			c.RetentionDays, c.QuarantineDays, c.RetentionBatchSize = 0, 0, 1000
+		})
+	}
+}
+
+func TestCIDRList_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{
+			name: "CIDRs and addresses of single hosts are accepted",
+			text: "10.0.0.0/8, 2001:db8::/32,192.0.2.1,::1",
+		},
+		{
+			name: "Empty list is accepted",
+		},
+		{
+			name:    "Error is returned on malformed CIDR",
+			text:    "10.0.0.0/8,10.0.0.0/33",
+			wantErr: true,
+		},
+		{
+			name:    "Error is returned on malformed address",
+			text:    "proxy",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l CIDRList
+			err := l.UnmarshalText([]byte(tt.text))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, l)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, CIDRList(tt.text), l)
+		})
+	}
+}
+
+func TestDuration_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "Duration can be parsed from string",
+			text: "1h30m",
+			want: 90 * time.Minute,
+		},
+		{
+			name: "Duration can be parsed from cron-like name",
+			text: "@daily",
+			want: 24 * time.Hour,
+		},
+		{
+			name: "Duration can be parsed from cron-like every",
+			text: "@every 15m",
+			want: 15 * time.Minute,
+		},
+		{
+			name:    "Error is returned on malformed duration",
+			text:    "week",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Duration
+			err := d.UnmarshalText([]byte(tt.text))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, time.Duration(d))
+		})
+	}
+}
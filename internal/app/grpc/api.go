@@ -2,11 +2,19 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
 	pb "github.com/sergalkin/go-url-shortener.git/internal/app/grpc/proto"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
@@ -21,17 +29,75 @@ type server struct {
 	internalService service.Internal
 	shortenService  service.URLShorten
 	expandService   service.URLExpand
+	adminService    service.Admin
+	deletes         storage.DeleteQueuer
+	workspaces      auth.WorkspaceAuthorizer
+	quotas          service.Quotas
+	limiter         auth.RateLimiter
+	logger          *zap.Logger
 }
 
-// NewServer - creates new gRPC server.
-func NewServer(db storage.DB, internal service.Internal, shortService service.URLShorten, expand service.URLExpand) *grpc.Server {
-	s := grpc.NewServer()
+// NewServer - creates new gRPC server with opts. Calls carrying x-api-key metadata are authenticated by keys, calls
+// carrying bearer token are authenticated by verifier, calls over mutual TLS are authenticated by client certificate,
+// internal methods are available only from trusted subnet or with verified client certificate and calls
+// of shortening, expanding and deleting methods are limited by limiter. Calls are logged by l and panics of
+// handlers are recovered. Batches are checked against quotas, so shortService should check quotas of single links
+// as well. Deletes of links are put in deletes queue. h is registered as grpc.health.v1 service, server reflection
+// is registered if GRPC_REFLECTION is on.
+func NewServer(
+	db storage.DB,
+	internal service.Internal,
+	shortService service.URLShorten,
+	expand service.URLExpand,
+	admin service.Admin,
+	deletes storage.DeleteQueuer,
+	keys auth.KeyAuthenticator,
+	workspaces auth.WorkspaceAuthorizer,
+	limiter auth.RateLimiter,
+	quotas service.Quotas,
+	verifier auth.Verifier,
+	h *Health,
+	l *zap.Logger,
+	opts ...grpc.ServerOption,
+) *grpc.Server {
+	guards := []guard{
+		clientCertGuard(),
+		apiKeyGuard(keys),
+		identityGuard(verifier),
+		adminGuard(),
+		trustedSubnetGuard(),
+		rateLimitGuard(limiter),
+	}
+
+	unary := []grpc.UnaryServerInterceptor{loggingInterceptor(l), recoveryInterceptor(l)}
+	stream := []grpc.StreamServerInterceptor{streamLoggingInterceptor(l), streamRecoveryInterceptor(l)}
+	for _, g := range guards {
+		unary = append(unary, unaryGuard(g))
+		stream = append(stream, streamGuard(g))
+	}
+
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	s := grpc.NewServer(opts...)
 	pb.RegisterShortenerServer(
 		s,
 		&server{
-			dbStorage: db, internalService: internal, shortenService: shortService, expandService: expand,
+			dbStorage:       db,
+			internalService: internal,
+			shortenService:  shortService,
+			expandService:   expand,
+			adminService:    admin,
+			deletes:         deletes,
+			workspaces:      workspaces,
+			quotas:          quotas,
+			limiter:         limiter,
+			logger:          l,
 		},
 	)
+	healthpb.RegisterHealthServer(s, h)
+	if config.GRPCReflection() {
+		reflection.Register(s)
+	}
+
 	return s
 }
 
@@ -52,7 +118,7 @@ func (s server) Stats(ctx context.Context, in *pb.EmptyRequest) (*pb.StatsRespon
 	if err != nil {
 		return &pb.StatsResponse{
 			Error: err.Error(),
-		}, nil
+		}, s.fail(err)
 	}
 
 	return &pb.StatsResponse{
@@ -66,17 +132,24 @@ func (s server) Stats(ctx context.Context, in *pb.EmptyRequest) (*pb.StatsRespon
 func (s server) ShortenURL(ctx context.Context, in *pb.ShortenURLRequest) (*pb.ShortenURLResponse, error) {
 	response := pb.ShortenURLResponse{}
 
-	uid, errID := getUserID(in.UserId)
+	uid, errID := getUserID(ctx, in.UserId)
 	if errID != nil {
 		return &pb.ShortenURLResponse{
 			Error: errID.Error(),
-		}, nil
+		}, s.fail(errID)
 	}
 	response.UserId = uid
 
-	shortURL, err := s.shortenService.ShortenURL(in.Url, uid)
+	if errBan := s.checkBan(ctx, uid); errBan != nil {
+		return &pb.ShortenURLResponse{Error: errBan.Error()}, s.fail(errBan)
+	}
+
+	shortURL, err := s.shortenService.ShortenURL(ctx, in.Url, uid)
+	if errors.Is(err, utils.ErrLinksConflict) {
+		return &pb.ShortenURLResponse{Error: err.Error()}, s.fail(err, &errdetails.ResourceInfo{ResourceType: "link", ResourceName: shortURL})
+	}
 	if err != nil {
-		return &pb.ShortenURLResponse{Error: err.Error()}, nil
+		return &pb.ShortenURLResponse{Error: err.Error()}, s.fail(err)
 	}
 	response.Result = shortURL
 
@@ -87,46 +160,80 @@ func (s server) ShortenURL(ctx context.Context, in *pb.ShortenURLRequest) (*pb.S
 func (s server) ExpandURL(ctx context.Context, in *pb.ExpandURLRequest) (*pb.ExpandURLResponse, error) {
 	original, err := s.expandService.ExpandURL(in.ShortUrl)
 	if err != nil {
-		return &pb.ExpandURLResponse{Error: err.Error()}, nil
+		return &pb.ExpandURLResponse{Error: err.Error()}, s.fail(err)
 	}
 
 	return &pb.ExpandURLResponse{OriginalUrl: original}, nil
 }
 
-// GetUserURLs - return a list of records for specific user.
+// GetUserURLs - return a page of records for specific user. Accepts the same filters as /api/user/urls,
+// cursor of the next page is returned in next_cursor. Links of workspace are returned, if workspace is provided
+// and user is its member.
 func (s server) GetUserURLs(ctx context.Context, in *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error) {
-
-	uid, errID := getUserID(in.UserId)
-	if errID != nil {
-		return &pb.GetUserURLsResponse{Error: errID.Error()}, nil
+	q, errQuery := s.linksQuery(ctx, in)
+	if errQuery != nil {
+		return &pb.GetUserURLsResponse{Error: errQuery.Error()}, s.fail(errQuery)
 	}
 
-	result, err := s.expandService.ExpandUserLinks(uid)
+	page, err := s.expandService.FindUserLinks(ctx, q)
 	if err != nil {
-		return &pb.GetUserURLsResponse{Error: err.Error()}, nil
+		return &pb.GetUserURLsResponse{Error: err.Error()}, s.fail(err)
 	}
 
-	records := make([]*pb.GetUserURLsResponse_Record, 0, len(result))
-	for _, rec := range result {
+	records := make([]*pb.GetUserURLsResponse_Record, 0, len(page.Links))
+	for _, rec := range page.Links {
 		records = append(records, &pb.GetUserURLsResponse_Record{
 			ShortUrl:    fmt.Sprintf("%s/%s", config.BaseURL(), rec.ShortURL),
 			OriginalUrl: rec.OriginalURL,
 		})
 	}
 
-	return &pb.GetUserURLsResponse{Records: records}, nil
+	return &pb.GetUserURLsResponse{Records: records, NextCursor: page.NextCursor}, nil
+}
+
+// linksQuery - returns query of links of user or workspace from request of GetUserURLs or ListUserURLs.
+func (s server) linksQuery(ctx context.Context, in *pb.GetUserURLsRequest) (storage.LinksQuery, error) {
+	uid, errID := getUserID(ctx, in.UserId)
+	if errID != nil {
+		return storage.LinksQuery{}, errID
+	}
+
+	if in.Workspace != "" {
+		m, errWorkspace := s.workspaces.Authorize(ctx, in.Workspace, uid, auth.WorkspaceViewer)
+		if errWorkspace != nil {
+			return storage.LinksQuery{}, errWorkspace
+		}
+		uid = m.WorkspaceID
+	}
+
+	params := service.LinksParams{
+		Cursor:       in.Cursor,
+		CreatedAfter: in.CreatedAfter,
+		Domain:       in.Domain,
+		Deleted:      in.Deleted,
+		Search:       in.Search,
+		Sort:         in.Sort,
+	}
+	if in.Limit != 0 {
+		params.Limit = strconv.Itoa(int(in.Limit))
+	}
+
+	return service.ParseLinksQuery(uid, params)
 }
 
 // BatchInsert - shortens a list of URLs.
 func (s server) BatchInsert(ctx context.Context, in *pb.BatchInsertRequest) (*pb.BatchInsertResponse, error) {
-	uid, errID := getUserID(in.UserId)
+	uid, errID := getUserID(ctx, in.UserId)
 	if errID != nil {
-		return &pb.BatchInsertResponse{Error: errID.Error()}, nil
+		return &pb.BatchInsertResponse{Error: errID.Error()}, s.fail(errID)
 	}
 
 	if len(in.Records) == 0 {
 		return &pb.BatchInsertResponse{}, nil
 	}
+	if errBan := s.checkBan(ctx, uid); errBan != nil {
+		return &pb.BatchInsertResponse{Error: errBan.Error()}, s.fail(errBan)
+	}
 	response := pb.BatchInsertResponse{UserId: uid}
 
 	reqRecords := make([]storage.BatchRequest, len(in.Records))
@@ -134,9 +241,14 @@ func (s server) BatchInsert(ctx context.Context, in *pb.BatchInsertRequest) (*pb
 		reqRecords[i].CorrelationID = record.CorrelationId
 		reqRecords[i].OriginalURL = record.Url
 	}
-	res, err := s.dbStorage.BatchInsert(reqRecords, uid)
+	var res []storage.BatchLink
+	err := s.quotas.Insert(ctx, uid, len(in.Records), func(ctx context.Context) error {
+		var err error
+		res, err = s.dbStorage.BatchInsert(ctx, reqRecords, uid)
+		return err
+	})
 	if err != nil {
-		return &pb.BatchInsertResponse{Error: err.Error()}, nil
+		return &pb.BatchInsertResponse{Error: err.Error()}, s.fail(err)
 	}
 
 	responseRecords := make([]*pb.BatchInsertResponse_Records, len(res))
@@ -152,29 +264,127 @@ func (s server) BatchInsert(ctx context.Context, in *pb.BatchInsertRequest) (*pb
 	return &response, nil
 }
 
-// DeleteURLs - soft deletes a list of URLs.
+// DeleteURLs - puts request to soft delete a list of URLs in delete queue, like DELETE /api/user/urls does.
+// Fails with Unavailable if queue can't accept request right now.
 func (s server) DeleteURLs(ctx context.Context, in *pb.DeleteURLsRequest) (*pb.DeleteURLsResponse, error) {
 	if len(in.Keys) == 0 {
 		return &pb.DeleteURLsResponse{}, nil
 	}
 
-	uid, errID := getUserID(in.UserId)
+	uid, errID := getUserID(ctx, in.UserId)
 	if errID != nil {
-		return &pb.DeleteURLsResponse{Error: errID.Error()}, nil
+		return &pb.DeleteURLsResponse{Error: errID.Error()}, s.fail(errID)
 	}
 
-	if err := s.dbStorage.SoftDeleteUserURLs(uid, in.Keys); err != nil {
-		return &pb.DeleteURLsResponse{Error: err.Error()}, nil
+	if err := s.deletes.Enqueue(storage.BatchDelete{UID: uid, Arr: in.Keys}); err != nil {
+		return &pb.DeleteURLsResponse{Error: err.Error()}, s.fail(err)
 	}
 
 	return &pb.DeleteURLsResponse{}, nil
 }
 
-func getUserID(requestUserID string) (string, error) {
-	var uid string
+// SearchLinks - returns a page of links of all users filtered by key, user_id and url. Unlike other methods,
+// user_id is plain ID of user as returned in records. Requires admin, see adminGuard.
+func (s server) SearchLinks(ctx context.Context, in *pb.SearchLinksRequest) (*pb.SearchLinksResponse, error) {
+	params := service.SearchParams{
+		Key:    in.Key,
+		UID:    in.UserId,
+		URL:    in.Url,
+		Cursor: in.Cursor,
+	}
+	if in.Limit != 0 {
+		params.Limit = strconv.Itoa(int(in.Limit))
+	}
+
+	q, errQuery := service.ParseSearchQuery(params)
+	if errQuery != nil {
+		return &pb.SearchLinksResponse{Error: errQuery.Error()}, s.fail(errQuery)
+	}
+
+	page, err := s.adminService.SearchLinks(ctx, q)
+	if err != nil {
+		return &pb.SearchLinksResponse{Error: err.Error()}, s.fail(err)
+	}
+
+	records := make([]*pb.SearchLinksResponse_Record, 0, len(page.Links))
+	for _, l := range page.Links {
+		records = append(records, &pb.SearchLinksResponse_Record{
+			Key:         l.Key,
+			ShortUrl:    fmt.Sprintf("%s/%s", config.BaseURL(), l.Key),
+			OriginalUrl: l.OriginalURL,
+			UserId:      l.UID,
+			CreatedAt:   l.CreatedAt.Format(time.RFC3339),
+			Deleted:     l.IsDeleted,
+			Disabled:    l.IsDisabled,
+		})
+	}
+
+	return &pb.SearchLinksResponse{Records: records, NextCursor: page.NextCursor}, nil
+}
+
+// SetLinksDisabled - disables or enables links of any user. Requires admin, see adminGuard.
+func (s server) SetLinksDisabled(ctx context.Context, in *pb.SetLinksDisabledRequest) (*pb.AdminResponse, error) {
+	n, err := s.adminService.SetLinksDisabled(ctx, in.Keys, in.Disabled)
+	if err != nil {
+		return &pb.AdminResponse{Error: err.Error()}, s.fail(err)
+	}
+
+	return &pb.AdminResponse{Affected: int32(n)}, nil
+}
+
+// ForceDeleteURLs - physically removes links of any user. Requires admin, see adminGuard.
+func (s server) ForceDeleteURLs(ctx context.Context, in *pb.ForceDeleteURLsRequest) (*pb.AdminResponse, error) {
+	n, err := s.adminService.DeleteLinks(ctx, in.Keys)
+	if err != nil {
+		return &pb.AdminResponse{Error: err.Error()}, s.fail(err)
+	}
+
+	return &pb.AdminResponse{Affected: int32(n)}, nil
+}
+
+// BanUser - bans user by plain ID. Requires admin, see adminGuard.
+func (s server) BanUser(ctx context.Context, in *pb.BanUserRequest) (*pb.AdminResponse, error) {
+	if _, err := s.adminService.BanUser(ctx, in.UserId, in.Reason); err != nil {
+		return &pb.AdminResponse{Error: err.Error()}, s.fail(err)
+	}
+
+	return &pb.AdminResponse{Affected: 1}, nil
+}
+
+// UnbanUser - lifts ban of user by plain ID. Requires admin, see adminGuard.
+func (s server) UnbanUser(ctx context.Context, in *pb.UnbanUserRequest) (*pb.AdminResponse, error) {
+	if err := s.adminService.UnbanUser(ctx, in.UserId); err != nil {
+		return &pb.AdminResponse{Error: err.Error()}, s.fail(err)
+	}
+
+	return &pb.AdminResponse{Affected: 1}, nil
+}
+
+// checkBan - returns utils.ErrUserBanned if user is banned.
+func (s server) checkBan(ctx context.Context, uid string) error {
+	banned, err := s.adminService.IsBanned(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return utils.ErrUserBanned
+	}
+
+	return nil
+}
+
+// getUserID - returns owner of API key, bearer token or client certificate the call was authenticated by, ID of user
+// decoded from request, ID of user from x-user-id metadata or ID of a new user.
+func getUserID(ctx context.Context, requestUserID string) (string, error) {
+	switch middleware.AuthMethod(ctx) {
+	case middleware.AuthAPIKey, middleware.AuthBearer, middleware.AuthClientCert:
+		if uid, ok := middleware.UserID(ctx); ok {
+			return uid, nil
+		}
+	}
 
 	if requestUserID != "" {
-		err := utils.Decode(requestUserID, &uid)
+		uid, _, err := middleware.DecodeUserCookie(requestUserID)
 		if err != nil {
 			return "", utils.ErrGRPCWrongUserID
 		}
@@ -182,15 +392,9 @@ func getUserID(requestUserID string) (string, error) {
 		return uid, nil
 	}
 
-	uid = middleware.GetUUID()
-	if uid == "" {
-		uid = uuid.New().String()
-	} else {
-		err := utils.Decode(middleware.GetUUID(), &uid)
-		if err != nil {
-			return "", utils.ErrGRPCWrongUserID
-		}
+	if uid, ok := middleware.UserID(ctx); ok {
+		return uid, nil
 	}
 
-	return uid, nil
+	return uuid.New().String(), nil
 }
@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/sergalkin/go-url-shortener.git/internal/app/grpc/proto"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type deleteQueueMock struct {
+	err      error
+	enqueued []storage.BatchDelete
+}
+
+func (q *deleteQueueMock) Enqueue(r storage.BatchDelete) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.enqueued = append(q.enqueued, r)
+
+	return nil
+}
+
+func TestServer_DeleteURLs(t *testing.T) {
+	uid := uuid.NewString()
+
+	tests := []struct {
+		queueErr     error
+		name         string
+		keys         []string
+		wantEnqueued []storage.BatchDelete
+		wantCode     codes.Code
+	}{
+		{
+			name:         "Keys are put in delete queue",
+			keys:         []string{"a", "b"},
+			wantEnqueued: []storage.BatchDelete{{UID: uid, Arr: []string{"a", "b"}}},
+		},
+		{
+			name: "Empty request is not queued",
+		},
+		{
+			name:     "Full queue is Unavailable",
+			keys:     []string{"a"},
+			queueErr: utils.ErrDeleteQueueFull,
+			wantCode: codes.Unavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &deleteQueueMock{err: tt.queueErr}
+			s := server{deletes: q, logger: zap.NewNop()}
+			ctx := middleware.WithAuthMethod(middleware.WithUserID(context.Background(), uid), middleware.AuthAPIKey)
+
+			_, err := s.DeleteURLs(ctx, &pb.DeleteURLsRequest{Keys: tt.keys})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantEnqueued, q.enqueued)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// errorDomain - domain of ErrorInfo details of errors returned by methods.
+const errorDomain = "shortener"
+
+// unavailableRetryDelay - delay suggested to clients in RetryInfo of Unavailable errors.
+const unavailableRetryDelay = time.Second
+
+// errorStatuses - codes and ErrorInfo reasons of known errors. Unknown errors are returned as Internal
+// without their message, so details of storage are not exposed.
+var errorStatuses = []struct {
+	err    error
+	reason string
+	code   codes.Code
+}{
+	{err: utils.ErrLinksConflict, reason: "LINK_CONFLICT", code: codes.AlreadyExists},
+	{err: utils.ErrLinkNotFound, reason: "LINK_NOT_FOUND", code: codes.NotFound},
+	{err: utils.ErrLinkIsDeleted, reason: "LINK_DELETED", code: codes.FailedPrecondition},
+	{err: utils.ErrGRPCWrongUserID, reason: "WRONG_USER_ID", code: codes.InvalidArgument},
+	{err: utils.ErrWrongUID, reason: "WRONG_USER_ID", code: codes.InvalidArgument},
+	{err: utils.ErrInvalidCursor, reason: "INVALID_CURSOR", code: codes.InvalidArgument},
+	{err: utils.ErrWrongLinksQuery, reason: "WRONG_LINKS_QUERY", code: codes.InvalidArgument},
+	{err: utils.ErrWorkspaceName, reason: "WRONG_WORKSPACE", code: codes.InvalidArgument},
+	{err: utils.ErrForbidden, reason: "FORBIDDEN", code: codes.PermissionDenied},
+	{err: utils.ErrUserBanned, reason: "USER_BANNED", code: codes.PermissionDenied},
+	{err: utils.ErrNotMember, reason: "NOT_WORKSPACE_MEMBER", code: codes.PermissionDenied},
+	{err: utils.ErrDeleteQueueFull, reason: "DELETE_QUEUE_FULL", code: codes.Unavailable},
+	{err: utils.ErrDeleteQueueDone, reason: "SHUTTING_DOWN", code: codes.Unavailable},
+	{err: context.DeadlineExceeded, reason: "DEADLINE_EXCEEDED", code: codes.DeadlineExceeded},
+	{err: context.Canceled, reason: "CANCELED", code: codes.Canceled},
+}
+
+// fail - returns error of call failed with err, see statusError. Returns nil if GRPC_LEGACY_ERRORS is on,
+// so error is reported only in error field of response as before.
+func (s server) fail(err error, details ...protoiface.MessageV1) error {
+	if config.GRPCLegacyErrors() {
+		return nil
+	}
+
+	return s.statusError(err, details...)
+}
+
+// statusError - converts err to status with code of known error and ErrorInfo details, details are appended
+// to them. Quota errors are ResourceExhausted with QuotaFailure details, Unavailable errors carry RetryInfo.
+// Status errors are returned as is.
+func (s server) statusError(err error, details ...protoiface.MessageV1) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code, reason, msg := codes.Internal, "INTERNAL", utils.ErrGRPCInternal.Error()
+	info := &errdetails.ErrorInfo{Domain: errorDomain}
+
+	if qe, ok := service.AsQuotaError(err); ok {
+		code, reason, msg = codes.ResourceExhausted, strings.ToUpper(qe.Code), qe.Error()
+		details = append(details, &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: "user", Description: qe.Error()}},
+		})
+	} else {
+		for _, e := range errorStatuses {
+			if errors.Is(err, e.err) {
+				code, reason, msg = e.code, e.reason, err.Error()
+				break
+			}
+		}
+	}
+
+	if code == codes.Internal {
+		s.logger.Error(err.Error(), zap.Error(err))
+	}
+	if code == codes.Unavailable {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(unavailableRetryDelay)})
+	}
+
+	info.Reason = reason
+	st, errDetails := status.New(code, msg).WithDetails(append([]protoiface.MessageV1{info}, details...)...)
+	if errDetails != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
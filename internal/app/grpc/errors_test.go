@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestServer_statusError(t *testing.T) {
+	tests := []struct {
+		err         error
+		name        string
+		wantMessage string
+		wantReason  string
+		wantCode    codes.Code
+	}{
+		{
+			name:        "Conflict of link is AlreadyExists",
+			err:         utils.ErrLinksConflict,
+			wantCode:    codes.AlreadyExists,
+			wantReason:  "LINK_CONFLICT",
+			wantMessage: utils.ErrLinksConflict.Error(),
+		},
+		{
+			name:        "Deleted link is FailedPrecondition",
+			err:         utils.ErrLinkIsDeleted,
+			wantCode:    codes.FailedPrecondition,
+			wantReason:  "LINK_DELETED",
+			wantMessage: utils.ErrLinkIsDeleted.Error(),
+		},
+		{
+			name:        "Wrapped error is recognized",
+			err:         fmt.Errorf("workspace: %w", utils.ErrNotMember),
+			wantCode:    codes.PermissionDenied,
+			wantReason:  "NOT_WORKSPACE_MEMBER",
+			wantMessage: "workspace: " + utils.ErrNotMember.Error(),
+		},
+		{
+			name:        "Quota error is ResourceExhausted",
+			err:         &service.QuotaError{Err: utils.ErrLinksQuota, Code: service.QuotaLinks, Limit: 2},
+			wantCode:    codes.ResourceExhausted,
+			wantReason:  "LINKS_QUOTA_EXCEEDED",
+			wantMessage: "links quota exceeded: limit is 2",
+		},
+		{
+			name:        "Unknown error is Internal without its message",
+			err:         errors.New("connection refused"),
+			wantCode:    codes.Internal,
+			wantReason:  "INTERNAL",
+			wantMessage: utils.ErrGRPCInternal.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(server{logger: zap.NewNop()}.statusError(tt.err))
+
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantMessage, st.Message())
+			require.NotEmpty(t, st.Details())
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantReason, info.Reason)
+			assert.Equal(t, errorDomain, info.Domain)
+		})
+	}
+}
+
+func TestServer_fail(t *testing.T) {
+	s := server{logger: zap.NewNop()}
+
+	err := s.fail(utils.ErrDeleteQueueFull)
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	require.Len(t, st.Details(), 2)
+	assert.IsType(t, &errdetails.RetryInfo{}, st.Details()[1])
+
+	config.NewConfig(config.WithGRPCLegacyErrors(true))
+	defer config.NewConfig(config.WithGRPCLegacyErrors(false))
+
+	assert.NoError(t, s.fail(utils.ErrDeleteQueueFull), "legacy mode reports errors in response only")
+}
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+// shortenerService - name of Shortener service reported by health service.
+const shortenerService = "grpc.Shortener"
+
+// Health - grpc.health.v1 service reporting the whole server and grpc.Shortener as SERVING while storage is
+// reachable. Storage without database connection is memory or file one and is always reachable.
+type Health struct {
+	*health.Server
+	db     storage.DB
+	logger *zap.Logger
+	status healthpb.HealthCheckResponse_ServingStatus
+}
+
+// NewHealth - creates Health checking db. Services are SERVING until the first check.
+func NewHealth(db storage.DB, l *zap.Logger) *Health {
+	h := &Health{
+		Server: health.NewServer(),
+		db:     db,
+		logger: l,
+		status: healthpb.HealthCheckResponse_SERVING,
+	}
+	h.Server.SetServingStatus(shortenerService, h.status)
+
+	return h
+}
+
+// Run - checks storage every interval until ctx is done. Statuses are not updated after Shutdown.
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		h.probe(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe - pings database and updates statuses of services. Changes of status are logged.
+func (h *Health) probe(ctx context.Context, timeout time.Duration) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if h.db.HasNotNilConn() {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if err := h.db.Ping(pingCtx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			h.logger.Warn("grpc health: storage is not reachable", zap.Error(err))
+		}
+	}
+
+	if status != h.status {
+		h.logger.Info("grpc health: serving status changed", zap.String("status", status.String()))
+		h.status = status
+	}
+
+	h.Server.SetServingStatus("", status)
+	h.Server.SetServingStatus(shortenerService, status)
+}
@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+type pingDBMock struct {
+	storage.DB
+	err     error
+	hasConn bool
+}
+
+func (d *pingDBMock) Ping(ctx context.Context) error {
+	return d.err
+}
+
+func (d *pingDBMock) HasNotNilConn() bool {
+	return d.hasConn
+}
+
+func TestHealth_probe(t *testing.T) {
+	tests := []struct {
+		db   *pingDBMock
+		name string
+		want healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name: "Storage without database is serving",
+			db:   &pingDBMock{err: errors.New("error in connection to db")},
+			want: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "Reachable database is serving",
+			db:   &pingDBMock{hasConn: true},
+			want: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "Unreachable database is not serving",
+			db:   &pingDBMock{hasConn: true, err: errors.New("connection refused")},
+			want: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealth(tt.db, zap.NewNop())
+			h.probe(context.Background(), time.Second)
+
+			for _, svc := range []string{"", shortenerService} {
+				resp, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{Service: svc})
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, resp.Status)
+			}
+		})
+	}
+}
+
+func TestHealth_Shutdown(t *testing.T) {
+	h := NewHealth(&pingDBMock{hasConn: true}, zap.NewNop())
+	h.Shutdown()
+	h.probe(context.Background(), time.Second)
+
+	resp, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{Service: shortenerService})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status, "status is not restored after shutdown")
+}
@@ -0,0 +1,359 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Metadata keys carrying credentials of caller.
+const (
+	apiKeyMetadata        = "x-api-key"
+	authorizationMetadata = "authorization"
+	userIDMetadata        = "x-user-id"
+)
+
+// methodScopes - scopes required from API keys by methods working with links of user. auth.ScopeAdmin of admin
+// methods is checked by adminGuard, as callers from trusted subnet are admins without it.
+var methodScopes = map[string]string{
+	"/grpc.Shortener/ShortenURL":        auth.ScopeShorten,
+	"/grpc.Shortener/BatchInsert":       auth.ScopeShorten,
+	"/grpc.Shortener/BatchInsertStream": auth.ScopeShorten,
+	"/grpc.Shortener/GetUserURLs":       auth.ScopeRead,
+	"/grpc.Shortener/ListUserURLs":      auth.ScopeRead,
+	"/grpc.Shortener/DeleteURLs":        auth.ScopeDelete,
+	"/grpc.Shortener/SearchLinks":       auth.ScopeAdmin,
+	"/grpc.Shortener/SetLinksDisabled":  auth.ScopeAdmin,
+	"/grpc.Shortener/ForceDeleteURLs":   auth.ScopeAdmin,
+	"/grpc.Shortener/BanUser":           auth.ScopeAdmin,
+	"/grpc.Shortener/UnbanUser":         auth.ScopeAdmin,
+}
+
+// methodRateClasses - route classes limiting rate of methods. Methods without class are not limited.
+var methodRateClasses = map[string]string{
+	"/grpc.Shortener/ShortenURL":        auth.RateShorten,
+	"/grpc.Shortener/BatchInsert":       auth.RateBatch,
+	"/grpc.Shortener/BatchInsertStream": auth.RateBatch,
+	"/grpc.Shortener/ExpandURL":         auth.RateRedirect,
+	"/grpc.Shortener/DeleteURLs":        auth.RateDelete,
+}
+
+// internalMethods - methods available only to clients from trusted subnet, like /api/internal routes.
+var internalMethods = map[string]bool{
+	"/grpc.Shortener/Stats": true,
+}
+
+// guard - checks call of method before it reaches handler. Returns context passed to handler or status error
+// the call fails with. Guards are turned into interceptors by unaryGuard and streamGuard, so unary and stream
+// methods are checked the same way.
+type guard func(ctx context.Context, method string) (context.Context, error)
+
+// unaryGuard - returns unary interceptor checking calls with g.
+func unaryGuard(g guard) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := g(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// streamGuard - returns stream interceptor checking calls with g.
+func streamGuard(g guard) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := g(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &guardedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// guardedStream - server stream with context returned by guard.
+type guardedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context - returns context returned by guard.
+func (s *guardedStream) Context() context.Context {
+	return s.ctx
+}
+
+// apiKeyGuard - authenticates calls with x-api-key metadata. Owner and scopes of key are passed to
+// handler in context, so key owner is used instead of user_id of request. Calls with unknown or revoked key
+// fail with Unauthenticated, calls over rate limit fail with ResourceExhausted and retry-after trailer.
+// Calls without key are passed as is.
+func apiKeyGuard(a auth.KeyAuthenticator) guard {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		keys := md.Get(apiKeyMetadata)
+		if len(keys) == 0 {
+			return ctx, nil
+		}
+
+		k, err := a.Authenticate(ctx, keys[0])
+		if errors.Is(err, utils.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, utils.ErrGRPCInternal.Error())
+		}
+
+		if ok, retryAfter := a.Allow(k); !ok {
+			_ = grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+			return nil, status.Error(codes.ResourceExhausted, utils.ErrRateLimited.Error())
+		}
+
+		if scope, ok := methodScopes[method]; ok && scope != auth.ScopeAdmin && !auth.HasScope(k.Scopes, scope) {
+			return nil, status.Error(codes.PermissionDenied, utils.ErrForbidden.Error()+": scope "+scope+" is required")
+		}
+
+		ctx = middleware.WithUserID(ctx, k.UID)
+		ctx = middleware.WithScopes(ctx, k.Scopes)
+		ctx = middleware.WithAuthMethod(ctx, middleware.AuthAPIKey)
+		ctx = middleware.WithAPIKeyID(ctx, k.ID)
+
+		return ctx, nil
+	}
+}
+
+// identityGuard - identifies callers not authenticated by API key or client certificate, like middleware.Bearer
+// and middleware.Cookie do for HTTP. "authorization: Bearer <token>" metadata authenticates account of token,
+// x-user-id metadata carries encoded ID of user, same as user_id field of requests. Calls with invalid token or ID
+// fail with Unauthenticated, calls with token lacking scope of method fail with PermissionDenied, calls without
+// both are passed as is.
+func identityGuard(v auth.Verifier) guard {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		if method := middleware.AuthMethod(ctx); method == middleware.AuthAPIKey || method == middleware.AuthClientCert {
+			return ctx, nil
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(authorizationMetadata); len(values) > 0 {
+			scheme, token, ok := strings.Cut(values[0], " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				return nil, status.Error(codes.Unauthenticated, utils.ErrInvalidToken.Error())
+			}
+
+			claims, err := v.Verify(strings.TrimSpace(token))
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			if scope, ok := methodScopes[method]; ok && scope != auth.ScopeAdmin && !auth.HasScope(claims.Scopes(), scope) {
+				return nil, status.Error(codes.PermissionDenied, utils.ErrForbidden.Error()+": scope "+scope+" is required")
+			}
+
+			ctx = middleware.WithUserID(ctx, claims.UID)
+			ctx = middleware.WithScopes(ctx, claims.Scopes())
+			ctx = middleware.WithAuthMethod(ctx, middleware.AuthBearer)
+
+			return ctx, nil
+		}
+
+		if values := md.Get(userIDMetadata); len(values) > 0 {
+			uid, _, err := middleware.DecodeUserCookie(values[0])
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, utils.ErrGRPCWrongUserID.Error())
+			}
+
+			ctx = middleware.WithUserID(ctx, uid)
+			ctx = middleware.WithScopes(ctx, auth.AllScopes)
+			ctx = middleware.WithAuthMethod(ctx, middleware.AuthCookie)
+		}
+
+		return ctx, nil
+	}
+}
+
+// adminGuard - fails calls of admin methods unless caller was granted auth.ScopeAdmin by API key, bearer token
+// or client certificate, or client IP, resolved by peerClientIP, belongs to TRUSTED_SUBNET, like
+// middleware.RequireAdmin does for HTTP. Anonymous callers fail with Unauthenticated, others with PermissionDenied.
+// Must be chained after authenticating guards.
+func adminGuard() guard {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		if methodScopes[method] != auth.ScopeAdmin || auth.HasScope(middleware.Scopes(ctx), auth.ScopeAdmin) {
+			return ctx, nil
+		}
+
+		trusted, err := middleware.InTrustedSubnet(peerClientIP(ctx))
+		if err != nil {
+			return nil, status.Error(codes.Internal, utils.ErrGRPCInternal.Error())
+		}
+		if trusted {
+			return ctx, nil
+		}
+
+		if middleware.AuthMethod(ctx) == "" {
+			return nil, status.Error(codes.Unauthenticated, utils.ErrForbidden.Error()+": admin is required")
+		}
+
+		return nil, status.Error(codes.PermissionDenied, utils.ErrForbidden.Error()+": admin is required")
+	}
+}
+
+// trustedSubnetGuard - fails calls of internal methods with PermissionDenied unless client IP, resolved by
+// peerClientIP, belongs to TRUSTED_SUBNET, like middleware.TrustedSubnet does for HTTP. Callers with client
+// certificate signed by GRPC_CLIENT_CA_FILE are trusted wherever they are.
+func trustedSubnetGuard() guard {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		if !internalMethods[method] || verifiedClientCert(ctx) != nil {
+			return ctx, nil
+		}
+
+		if len(config.TrustedSubnets()) == 0 {
+			return nil, status.Error(codes.PermissionDenied, utils.ErrForbidden.Error()+": trusted subnet is not defined")
+		}
+
+		trusted, err := middleware.InTrustedSubnet(peerClientIP(ctx))
+		if err != nil {
+			return nil, status.Error(codes.Internal, utils.ErrGRPCInternal.Error())
+		}
+		if !trusted {
+			return nil, status.Error(codes.PermissionDenied, utils.ErrForbidden.Error()+": client is not in trusted subnet")
+		}
+
+		return ctx, nil
+	}
+}
+
+// rateLimitGuard - limits calls of methods by client like middleware.RateLimit. Client IP is resolved
+// by peerClientIP. Calls over limit fail with ResourceExhausted and retry-after trailer. Must be chained after
+// authenticating guards, so calls of known clients are limited by key or account.
+func rateLimitGuard(l auth.RateLimiter) guard {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		class, ok := methodRateClasses[method]
+		if !ok {
+			return ctx, nil
+		}
+
+		if err := allowRate(ctx, l, class); err != nil {
+			return nil, err
+		}
+
+		return ctx, nil
+	}
+}
+
+// allowRate - takes call of client from limit of route class. Returns ResourceExhausted status error and sets
+// retry-after trailer if limit is exceeded.
+func allowRate(ctx context.Context, l auth.RateLimiter, class string) error {
+	client := middleware.RateClient(ctx, peerClientIP(ctx))
+	if allowed, retryAfter := l.Allow(ctx, class, client); !allowed {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+		return status.Error(codes.ResourceExhausted, utils.ErrRateLimited.Error())
+	}
+
+	return nil
+}
+
+// loggingInterceptor - logs unary calls with method, latency and status code.
+func loggingInterceptor(l *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, l, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// streamLoggingInterceptor - logs stream calls with method, latency and status code, when stream is finished.
+func streamLoggingInterceptor(l *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), l, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// logCall - logs finished call. Calls failed with server side codes are logged as errors, successful calls
+// of health service are logged at debug level, as they are made by probes all the time.
+func logCall(ctx context.Context, l *zap.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.Duration("latency", time.Since(start)),
+		zap.String("code", code.String()),
+		zap.String("ip", peerClientIP(ctx)),
+	}
+
+	switch {
+	case code == codes.Internal, code == codes.Unknown, code == codes.DataLoss, code == codes.Unavailable:
+		l.Error("grpc call", append(fields, zap.Error(err))...)
+	case code == codes.OK && strings.HasPrefix(method, "/grpc.health.v1.Health/"):
+		l.Debug("grpc call", fields...)
+	default:
+		l.Info("grpc call", fields...)
+	}
+}
+
+// recoveryInterceptor - turns panic of unary handler into Internal error, so it doesn't crash the process.
+func recoveryInterceptor(l *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(l, info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// streamRecoveryInterceptor - turns panic of stream handler into Internal error.
+func streamRecoveryInterceptor(l *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(l, info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// recovered - logs recovered panic with stack and returns error the call fails with.
+func recovered(l *zap.Logger, method string, r interface{}) error {
+	l.Error("grpc: panic recovered", zap.String("method", method), zap.Any("panic", r), zap.Stack("stack"))
+
+	return status.Error(codes.Internal, utils.ErrGRPCInternal.Error())
+}
+
+// peerClientIP - returns IP of client made call, resolved by middleware.ResolveClientIP from address of peer and
+// x-forwarded-for and x-real-ip metadata.
+func peerClientIP(ctx context.Context) string {
+	var remoteAddr, forwardedFor, realIP string
+	if p, found := peer.FromContext(ctx); found {
+		remoteAddr = p.Addr.String()
+	}
+	if md, found := metadata.FromIncomingContext(ctx); found {
+		forwardedFor = strings.Join(md.Get("x-forwarded-for"), ",")
+		if values := md.Get("x-real-ip"); len(values) > 0 {
+			realIP = values[0]
+		}
+	}
+
+	return middleware.ResolveClientIP(remoteAddr, forwardedFor, realIP)
+}
@@ -0,0 +1,258 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type verifierMock map[string]auth.Claims
+
+func (v verifierMock) Verify(token string) (auth.Claims, error) {
+	c, ok := v[token]
+	if !ok {
+		return auth.Claims{}, utils.ErrInvalidToken
+	}
+
+	return c, nil
+}
+
+func peerContext(addr string, md metadata.MD) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 4321}})
+
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func TestIdentityGuard(t *testing.T) {
+	encoded, err := utils.Encode("user-from-metadata|" + strconv.FormatInt(time.Now().Unix(), 10))
+	require.NoError(t, err)
+
+	g := identityGuard(verifierMock{
+		"full":   {UID: "account", Scope: auth.ScopeShorten + " " + auth.ScopeRead},
+		"reader": {UID: "reader", Scope: auth.ScopeRead},
+	})
+
+	tests := []struct {
+		md         metadata.MD
+		name       string
+		method     string
+		wantUID    string
+		wantMethod string
+		wantCode   codes.Code
+	}{
+		{
+			name:       "Bearer token authenticates account",
+			md:         metadata.Pairs(authorizationMetadata, "Bearer full"),
+			method:     "/grpc.Shortener/ShortenURL",
+			wantUID:    "account",
+			wantMethod: middleware.AuthBearer,
+		},
+		{
+			name:     "Invalid bearer token is rejected",
+			md:       metadata.Pairs(authorizationMetadata, "Bearer wrong"),
+			method:   "/grpc.Shortener/ShortenURL",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Token without scope of method is rejected",
+			md:       metadata.Pairs(authorizationMetadata, "Bearer reader"),
+			method:   "/grpc.Shortener/DeleteURLs",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:       "User ID is decoded from metadata",
+			md:         metadata.Pairs(userIDMetadata, encoded),
+			method:     "/grpc.Shortener/ShortenURL",
+			wantUID:    "user-from-metadata",
+			wantMethod: middleware.AuthCookie,
+		},
+		{
+			name:     "Tampered user ID is rejected",
+			md:       metadata.Pairs(userIDMetadata, "tampered"),
+			method:   "/grpc.Shortener/ShortenURL",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:   "Anonymous call is passed as is",
+			md:     metadata.MD{},
+			method: "/grpc.Shortener/ShortenURL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := g(peerContext("192.0.2.1", tt.md), tt.method)
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			uid, _ := middleware.UserID(ctx)
+			assert.Equal(t, tt.wantUID, uid)
+			assert.Equal(t, tt.wantMethod, middleware.AuthMethod(ctx))
+		})
+	}
+}
+
+func TestAdminGuard(t *testing.T) {
+	config.NewConfig(config.WithTrustedSubnet("10.0.0.0/8"))
+	defer config.NewConfig(config.WithTrustedSubnet(""))
+
+	guards := []guard{
+		identityGuard(verifierMock{
+			"admin": {UID: "admin", Scope: auth.ScopeRead + " " + auth.ScopeAdmin},
+			"user":  {UID: "user", Scope: auth.ScopeRead},
+		}),
+		adminGuard(),
+	}
+
+	tests := []struct {
+		md       metadata.MD
+		name     string
+		method   string
+		addr     string
+		wantCode codes.Code
+	}{
+		{
+			name:   "Bearer token with scope admin calls admin methods",
+			md:     metadata.Pairs(authorizationMetadata, "Bearer admin"),
+			method: "/grpc.Shortener/BanUser",
+			addr:   "192.0.2.1",
+		},
+		{
+			name:     "Bearer token without scope admin is rejected",
+			md:       metadata.Pairs(authorizationMetadata, "Bearer user"),
+			method:   "/grpc.Shortener/SearchLinks",
+			addr:     "192.0.2.1",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "Anonymous call is rejected",
+			md:       metadata.MD{},
+			method:   "/grpc.Shortener/ForceDeleteURLs",
+			addr:     "192.0.2.1",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:   "Anonymous call from trusted subnet is admin",
+			md:     metadata.MD{},
+			method: "/grpc.Shortener/UnbanUser",
+			addr:   "10.1.2.3",
+		},
+		{
+			name:   "Bearer token without scope admin from trusted subnet is admin",
+			md:     metadata.Pairs(authorizationMetadata, "Bearer user"),
+			method: "/grpc.Shortener/SetLinksDisabled",
+			addr:   "10.1.2.3",
+		},
+		{
+			name:   "Not admin method is passed",
+			md:     metadata.MD{},
+			method: "/grpc.Shortener/ShortenURL",
+			addr:   "192.0.2.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peerContext(tt.addr, tt.md)
+			var err error
+			for _, g := range guards {
+				if ctx, err = g(ctx, tt.method); err != nil {
+					break
+				}
+			}
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestTrustedSubnetGuard(t *testing.T) {
+	defer config.NewConfig(config.WithTrustedSubnet(""), config.WithTrustedProxies(""))
+
+	tests := []struct {
+		name     string
+		subnet   string
+		method   string
+		addr     string
+		realIP   string
+		wantCode codes.Code
+	}{
+		{
+			name:   "Not internal method is passed",
+			method: "/grpc.Shortener/ShortenURL",
+			addr:   "192.0.2.1",
+		},
+		{
+			name:     "Internal method is rejected if trusted subnet is not defined",
+			method:   "/grpc.Shortener/Stats",
+			addr:     "10.1.2.3",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:   "Internal method is passed for peer from trusted subnet",
+			subnet: "10.0.0.0/8",
+			method: "/grpc.Shortener/Stats",
+			addr:   "10.1.2.3",
+		},
+		{
+			name:     "Spoofed x-real-ip of untrusted peer is rejected",
+			subnet:   "10.0.0.0/8",
+			method:   "/grpc.Shortener/Stats",
+			addr:     "192.0.2.1",
+			realIP:   "10.1.2.3",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:   "Client forwarded by trusted proxy is passed",
+			subnet: "10.0.0.0/8",
+			method: "/grpc.Shortener/Stats",
+			addr:   "172.16.0.1",
+			realIP: "10.1.2.3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(config.WithTrustedSubnet(tt.subnet), config.WithTrustedProxies("172.16.0.0/12"))
+
+			md := metadata.MD{}
+			if tt.realIP != "" {
+				md = metadata.Pairs("x-real-ip", tt.realIP)
+			}
+			_, err := trustedSubnetGuard()(peerContext(tt.addr, md), tt.method)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.Shortener/Stats"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}
+
+	var resp interface{}
+	var err error
+	require.NotPanics(t, func() {
+		resp, err = recoveryInterceptor(zap.NewNop())(context.Background(), nil, info, handler)
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
@@ -26,9 +26,16 @@ type ShortenerClient interface {
 	ExpandURL(ctx context.Context, in *ExpandURLRequest, opts ...grpc.CallOption) (*ExpandURLResponse, error)
 	GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error)
 	BatchInsert(ctx context.Context, in *BatchInsertRequest, opts ...grpc.CallOption) (*BatchInsertResponse, error)
+	BatchInsertStream(ctx context.Context, opts ...grpc.CallOption) (Shortener_BatchInsertStreamClient, error)
+	ListUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (Shortener_ListUserURLsClient, error)
 	DeleteURLs(ctx context.Context, in *DeleteURLsRequest, opts ...grpc.CallOption) (*DeleteURLsResponse, error)
 	Ping(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*PingResponse, error)
 	Stats(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	SearchLinks(ctx context.Context, in *SearchLinksRequest, opts ...grpc.CallOption) (*SearchLinksResponse, error)
+	SetLinksDisabled(ctx context.Context, in *SetLinksDisabledRequest, opts ...grpc.CallOption) (*AdminResponse, error)
+	ForceDeleteURLs(ctx context.Context, in *ForceDeleteURLsRequest, opts ...grpc.CallOption) (*AdminResponse, error)
+	BanUser(ctx context.Context, in *BanUserRequest, opts ...grpc.CallOption) (*AdminResponse, error)
+	UnbanUser(ctx context.Context, in *UnbanUserRequest, opts ...grpc.CallOption) (*AdminResponse, error)
 }
 
 type shortenerClient struct {
@@ -75,6 +82,69 @@ func (c *shortenerClient) BatchInsert(ctx context.Context, in *BatchInsertReques
 	return out, nil
 }
 
+func (c *shortenerClient) BatchInsertStream(ctx context.Context, opts ...grpc.CallOption) (Shortener_BatchInsertStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[0], "/grpc.Shortener/BatchInsertStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerBatchInsertStreamClient{stream}
+	return x, nil
+}
+
+type Shortener_BatchInsertStreamClient interface {
+	Send(*BatchInsertStreamRequest) error
+	Recv() (*BatchInsertStreamResponse, error)
+	grpc.ClientStream
+}
+
+type shortenerBatchInsertStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerBatchInsertStreamClient) Send(m *BatchInsertStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *shortenerBatchInsertStreamClient) Recv() (*BatchInsertStreamResponse, error) {
+	m := new(BatchInsertStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shortenerClient) ListUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (Shortener_ListUserURLsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[1], "/grpc.Shortener/ListUserURLs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerListUserURLsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Shortener_ListUserURLsClient interface {
+	Recv() (*ListUserURLsResponse, error)
+	grpc.ClientStream
+}
+
+type shortenerListUserURLsClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerListUserURLsClient) Recv() (*ListUserURLsResponse, error) {
+	m := new(ListUserURLsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *shortenerClient) DeleteURLs(ctx context.Context, in *DeleteURLsRequest, opts ...grpc.CallOption) (*DeleteURLsResponse, error) {
 	out := new(DeleteURLsResponse)
 	err := c.cc.Invoke(ctx, "/grpc.Shortener/DeleteURLs", in, out, opts...)
@@ -102,6 +172,51 @@ func (c *shortenerClient) Stats(ctx context.Context, in *EmptyRequest, opts ...g
 	return out, nil
 }
 
+func (c *shortenerClient) SearchLinks(ctx context.Context, in *SearchLinksRequest, opts ...grpc.CallOption) (*SearchLinksResponse, error) {
+	out := new(SearchLinksResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/SearchLinks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) SetLinksDisabled(ctx context.Context, in *SetLinksDisabledRequest, opts ...grpc.CallOption) (*AdminResponse, error) {
+	out := new(AdminResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/SetLinksDisabled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) ForceDeleteURLs(ctx context.Context, in *ForceDeleteURLsRequest, opts ...grpc.CallOption) (*AdminResponse, error) {
+	out := new(AdminResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/ForceDeleteURLs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) BanUser(ctx context.Context, in *BanUserRequest, opts ...grpc.CallOption) (*AdminResponse, error) {
+	out := new(AdminResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/BanUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) UnbanUser(ctx context.Context, in *UnbanUserRequest, opts ...grpc.CallOption) (*AdminResponse, error) {
+	out := new(AdminResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/UnbanUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShortenerServer is the server API for Shortener service.
 // All implementations must embed UnimplementedShortenerServer
 // for forward compatibility
@@ -110,9 +225,16 @@ type ShortenerServer interface {
 	ExpandURL(context.Context, *ExpandURLRequest) (*ExpandURLResponse, error)
 	GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error)
 	BatchInsert(context.Context, *BatchInsertRequest) (*BatchInsertResponse, error)
+	BatchInsertStream(Shortener_BatchInsertStreamServer) error
+	ListUserURLs(*GetUserURLsRequest, Shortener_ListUserURLsServer) error
 	DeleteURLs(context.Context, *DeleteURLsRequest) (*DeleteURLsResponse, error)
 	Ping(context.Context, *EmptyRequest) (*PingResponse, error)
 	Stats(context.Context, *EmptyRequest) (*StatsResponse, error)
+	SearchLinks(context.Context, *SearchLinksRequest) (*SearchLinksResponse, error)
+	SetLinksDisabled(context.Context, *SetLinksDisabledRequest) (*AdminResponse, error)
+	ForceDeleteURLs(context.Context, *ForceDeleteURLsRequest) (*AdminResponse, error)
+	BanUser(context.Context, *BanUserRequest) (*AdminResponse, error)
+	UnbanUser(context.Context, *UnbanUserRequest) (*AdminResponse, error)
 	mustEmbedUnimplementedShortenerServer()
 }
 
@@ -132,6 +254,12 @@ func (UnimplementedShortenerServer) GetUserURLs(context.Context, *GetUserURLsReq
 func (UnimplementedShortenerServer) BatchInsert(context.Context, *BatchInsertRequest) (*BatchInsertResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchInsert not implemented")
 }
+func (UnimplementedShortenerServer) BatchInsertStream(Shortener_BatchInsertStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method BatchInsertStream not implemented")
+}
+func (UnimplementedShortenerServer) ListUserURLs(*GetUserURLsRequest, Shortener_ListUserURLsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListUserURLs not implemented")
+}
 func (UnimplementedShortenerServer) DeleteURLs(context.Context, *DeleteURLsRequest) (*DeleteURLsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteURLs not implemented")
 }
@@ -141,6 +269,21 @@ func (UnimplementedShortenerServer) Ping(context.Context, *EmptyRequest) (*PingR
 func (UnimplementedShortenerServer) Stats(context.Context, *EmptyRequest) (*StatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
 }
+func (UnimplementedShortenerServer) SearchLinks(context.Context, *SearchLinksRequest) (*SearchLinksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchLinks not implemented")
+}
+func (UnimplementedShortenerServer) SetLinksDisabled(context.Context, *SetLinksDisabledRequest) (*AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinksDisabled not implemented")
+}
+func (UnimplementedShortenerServer) ForceDeleteURLs(context.Context, *ForceDeleteURLsRequest) (*AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceDeleteURLs not implemented")
+}
+func (UnimplementedShortenerServer) BanUser(context.Context, *BanUserRequest) (*AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BanUser not implemented")
+}
+func (UnimplementedShortenerServer) UnbanUser(context.Context, *UnbanUserRequest) (*AdminResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnbanUser not implemented")
+}
 func (UnimplementedShortenerServer) mustEmbedUnimplementedShortenerServer() {}
 
 // UnsafeShortenerServer may be embedded to opt out of forward compatibility for this service.
@@ -226,6 +369,53 @@ func _Shortener_BatchInsert_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_BatchInsertStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ShortenerServer).BatchInsertStream(&shortenerBatchInsertStreamServer{stream})
+}
+
+type Shortener_BatchInsertStreamServer interface {
+	Send(*BatchInsertStreamResponse) error
+	Recv() (*BatchInsertStreamRequest, error)
+	grpc.ServerStream
+}
+
+type shortenerBatchInsertStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerBatchInsertStreamServer) Send(m *BatchInsertStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *shortenerBatchInsertStreamServer) Recv() (*BatchInsertStreamRequest, error) {
+	m := new(BatchInsertStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Shortener_ListUserURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetUserURLsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShortenerServer).ListUserURLs(m, &shortenerListUserURLsServer{stream})
+}
+
+type Shortener_ListUserURLsServer interface {
+	Send(*ListUserURLsResponse) error
+	grpc.ServerStream
+}
+
+type shortenerListUserURLsServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerListUserURLsServer) Send(m *ListUserURLsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Shortener_DeleteURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteURLsRequest)
 	if err := dec(in); err != nil {
@@ -280,6 +470,96 @@ func _Shortener_Stats_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_SearchLinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchLinksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).SearchLinks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/SearchLinks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).SearchLinks(ctx, req.(*SearchLinksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_SetLinksDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinksDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).SetLinksDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/SetLinksDisabled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).SetLinksDisabled(ctx, req.(*SetLinksDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_ForceDeleteURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceDeleteURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).ForceDeleteURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/ForceDeleteURLs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).ForceDeleteURLs(ctx, req.(*ForceDeleteURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_BanUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).BanUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/BanUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).BanUser(ctx, req.(*BanUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_UnbanUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).UnbanUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/UnbanUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).UnbanUser(ctx, req.(*UnbanUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Shortener_ServiceDesc is the grpc.ServiceDesc for Shortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -315,7 +595,39 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stats",
 			Handler:    _Shortener_Stats_Handler,
 		},
+		{
+			MethodName: "SearchLinks",
+			Handler:    _Shortener_SearchLinks_Handler,
+		},
+		{
+			MethodName: "SetLinksDisabled",
+			Handler:    _Shortener_SetLinksDisabled_Handler,
+		},
+		{
+			MethodName: "ForceDeleteURLs",
+			Handler:    _Shortener_ForceDeleteURLs_Handler,
+		},
+		{
+			MethodName: "BanUser",
+			Handler:    _Shortener_BanUser_Handler,
+		},
+		{
+			MethodName: "UnbanUser",
+			Handler:    _Shortener_UnbanUser_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchInsertStream",
+			Handler:       _Shortener_BatchInsertStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListUserURLs",
+			Handler:       _Shortener_ListUserURLs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/app/grpc/proto/api.proto",
 }
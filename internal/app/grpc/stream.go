@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	pb "github.com/sergalkin/go-url-shortener.git/internal/app/grpc/proto"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// BatchInsertStream - shortens URLs received one by one and acknowledges each stored record with its short URL,
+// so batches are not limited by size of message. Next record is received only after acknowledgement of previous
+// one is sent, so slow clients are not flooded. Records are stored by shortenService one by one, which checks quota
+// of user for each of them, and count against batch quota like links of one batch. Each record is limited by limiter
+// like single shortening, while stream itself is limited like batch call. Record with URL shortened before
+// is acknowledged with its existing short URL. Stream fails with status of the first failed record, acknowledged
+// records stay stored.
+func (s server) BatchInsertStream(stream pb.Shortener_BatchInsertStreamServer) error {
+	ctx := stream.Context()
+
+	var uid string
+	var quota service.QuotaUsage
+	for n := 0; ; n++ {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			if uid, err = getUserID(ctx, in.UserId); err != nil {
+				return s.statusError(err)
+			}
+			if err = s.checkBan(ctx, uid); err != nil {
+				return s.statusError(err)
+			}
+			if quota, err = s.quotas.Usage(ctx, uid); err != nil {
+				return s.statusError(err)
+			}
+		}
+
+		if quota.MaxBatch > 0 && n >= quota.MaxBatch {
+			return s.statusError(&service.QuotaError{Err: utils.ErrBatchQuota, Code: service.QuotaBatch, Limit: quota.MaxBatch})
+		}
+		if err = allowRate(ctx, s.limiter, auth.RateShorten); err != nil {
+			return err
+		}
+		key, err := s.shortenService.ShortenURL(ctx, in.Url, uid)
+		if err != nil && !errors.Is(err, utils.ErrLinksConflict) {
+			return s.statusError(err)
+		}
+
+		shortURL := fmt.Sprintf("%s/%s", config.BaseURL(), key)
+		if err = stream.Send(&pb.BatchInsertStreamResponse{CorrelationId: in.CorrelationId, ShortUrl: shortURL, UserId: uid}); err != nil {
+			return err
+		}
+	}
+}
+
+// ListUserURLs - streams links of user or workspace matching the same filters as GetUserURLs. Links are read from
+// storage page by page with cursor, limit is size of page, so heavy users are not loaded into memory at once.
+// Next page is read only after records of previous one are sent.
+func (s server) ListUserURLs(in *pb.GetUserURLsRequest, stream pb.Shortener_ListUserURLsServer) error {
+	ctx := stream.Context()
+
+	q, err := s.linksQuery(ctx, in)
+	if err != nil {
+		return s.statusError(err)
+	}
+
+	for {
+		page, errFind := s.expandService.FindUserLinks(ctx, q)
+		if errFind != nil {
+			return s.statusError(errFind)
+		}
+
+		for i, rec := range page.Links {
+			resp := &pb.ListUserURLsResponse{
+				ShortUrl:    fmt.Sprintf("%s/%s", config.BaseURL(), rec.ShortURL),
+				OriginalUrl: rec.OriginalURL,
+			}
+			if i == len(page.Links)-1 {
+				resp.Cursor = page.NextCursor
+			}
+			if errSend := stream.Send(resp); errSend != nil {
+				return errSend
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	pb "github.com/sergalkin/go-url-shortener.git/internal/app/grpc/proto"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
+)
+
+type adminMock struct {
+	service.Admin
+}
+
+func (a adminMock) IsBanned(ctx context.Context, uid string) (bool, error) {
+	return false, nil
+}
+
+type batchStreamMock struct {
+	grpc.ServerStream
+	ctx  context.Context
+	in   []*pb.BatchInsertStreamRequest
+	sent []*pb.BatchInsertStreamResponse
+}
+
+func (s *batchStreamMock) Context() context.Context {
+	return s.ctx
+}
+
+func (s *batchStreamMock) Recv() (*pb.BatchInsertStreamRequest, error) {
+	if len(s.in) == 0 {
+		return nil, io.EOF
+	}
+	in := s.in[0]
+	s.in = s.in[1:]
+
+	return in, nil
+}
+
+func (s *batchStreamMock) Send(r *pb.BatchInsertStreamResponse) error {
+	s.sent = append(s.sent, r)
+	return nil
+}
+
+type listStreamMock struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.ListUserURLsResponse
+}
+
+func (s *listStreamMock) Context() context.Context {
+	return s.ctx
+}
+
+func (s *listStreamMock) Send(r *pb.ListUserURLsResponse) error {
+	s.sent = append(s.sent, r)
+	return nil
+}
+
+func TestServer_BatchInsertStream(t *testing.T) {
+	records := []*pb.BatchInsertStreamRequest{
+		{CorrelationId: "1", Url: "https://one.example.com"},
+		{CorrelationId: "2", Url: "https://two.example.com"},
+		{CorrelationId: "3", Url: "https://three.example.com"},
+	}
+
+	tests := []struct {
+		name      string
+		quota     storage.Quota
+		rate      int
+		wantAcked []string
+		wantCode  codes.Code
+	}{
+		{
+			name:      "Each record is acknowledged",
+			wantAcked: []string{"1", "2", "3"},
+		},
+		{
+			name:      "Stream fails after batch quota is exhausted",
+			quota:     storage.Quota{MaxBatch: 2},
+			wantAcked: []string{"1", "2"},
+			wantCode:  codes.ResourceExhausted,
+		},
+		{
+			name:      "Stream fails after quota of active links is exhausted",
+			quota:     storage.Quota{MaxLinks: 1},
+			wantAcked: []string{"1"},
+			wantCode:  codes.ResourceExhausted,
+		},
+		{
+			name:      "Stream fails after rate limit of shortening is exceeded by records",
+			rate:      2,
+			wantAcked: []string{"1", "2"},
+			wantCode:  codes.ResourceExhausted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := storage.NewMemory(zap.NewNop())
+			quotas := service.NewQuotaService(links, storage.NewMemoryQuotas(), storage.NewMemoryQuotaLocker(), tt.quota, zap.NewNop())
+			s := server{
+				shortenService: service.NewQuotaShortener(service.NewURLShortenerService(links, sequence.NewSequence(), zap.NewNop()), quotas),
+				adminService:   adminMock{},
+				quotas:         quotas,
+				limiter:        auth.NewRateLimits(auth.NewMemoryRates(), map[string]int{auth.RateShorten: tt.rate}, zap.NewNop()),
+				logger:         zap.NewNop(),
+			}
+			uid := uuid.NewString()
+			stream := &batchStreamMock{ctx: middleware.WithUserID(context.Background(), uid), in: records}
+
+			err := s.BatchInsertStream(stream)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			stored, _ := links.LinksByUUID(uid)
+			require.Len(t, stored, len(tt.wantAcked))
+			acked := make([]string, 0, len(stream.sent))
+			for i, r := range stream.sent {
+				acked = append(acked, r.CorrelationId)
+				assert.Equal(t, uid, r.UserId)
+				assert.Equal(t, config.BaseURL()+"/"+stored[i].ShortURL, r.ShortUrl)
+				assert.Equal(t, records[i].Url, stored[i].OriginalURL)
+			}
+			assert.Equal(t, tt.wantAcked, acked)
+		})
+	}
+}
+
+func TestServer_ListUserURLs(t *testing.T) {
+	links := storage.NewMemory(zap.NewNop())
+	uid := uuid.NewString()
+	for i := 0; i < 5; i++ {
+		key := uuid.NewString()
+		links.Store(&key, "https://example.com/"+key, uid)
+	}
+	s := server{expandService: service.NewURLExpandService(links, zap.NewNop()), logger: zap.NewNop()}
+
+	stream := &listStreamMock{ctx: middleware.WithUserID(context.Background(), uid)}
+	require.NoError(t, s.ListUserURLs(&pb.GetUserURLsRequest{Limit: 2}, stream))
+
+	require.Len(t, stream.sent, 5)
+	cursors := make([]bool, 0, len(stream.sent))
+	for _, r := range stream.sent {
+		cursors = append(cursors, r.Cursor != "")
+	}
+	assert.Equal(t, []bool{false, true, false, true, false}, cursors, "cursor is set on last record of each page but the last")
+
+	err := s.ListUserURLs(&pb.GetUserURLsRequest{Sort: "wrong"}, &listStreamMock{ctx: context.Background()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,141 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+)
+
+// uuidURNPrefix - prefix of URI SAN carrying user ID in client certificate.
+const uuidURNPrefix = "urn:uuid:"
+
+// errNoClientCA - error of client certificates required without CA verifying them.
+var errNoClientCA = errors.New("grpc: client certificates can't be required without client CA")
+
+// TLSConfig - returns TLS config of gRPC listener with certificate from certFile and keyFile. If clientCAFile
+// is set, client certificates signed by that CA are verified, so call can be authenticated by certificate.
+// Connections without certificate are accepted unless requireClientCert is set.
+func TLSConfig(certFile string, keyFile string, clientCAFile string, requireClientCert bool) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile == "" {
+		if requireClientCert {
+			return nil, errNoClientCA
+		}
+		return cfg, nil
+	}
+
+	pem, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientCAs = x509.NewCertPool()
+	if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("grpc: no certificates found in " + clientCAFile)
+	}
+
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	if requireClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// clientCertGuard - identifies callers by client certificate verified against GRPC_CLIENT_CA_FILE. User ID
+// is taken from common name or "urn:uuid:" URI SAN which is UUID, certificates with common name or SAN listed
+// in GRPC_ADMIN_CERT_NAMES are granted admin role. Certificates mapped to neither are only trusted by
+// trustedSubnetGuard. Calls without verified certificate are passed as is.
+func clientCertGuard() guard {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		cert := verifiedClientCert(ctx)
+		if cert == nil {
+			return ctx, nil
+		}
+
+		uid, isAdmin := certIdentity(cert)
+		if uid == "" && !isAdmin {
+			return ctx, nil
+		}
+
+		var roles []string
+		if isAdmin {
+			roles = []string{auth.RoleAdmin}
+		}
+		if uid != "" {
+			ctx = middleware.WithUserID(ctx, uid)
+		}
+		ctx = middleware.WithScopes(ctx, auth.RoleScopes(roles))
+		ctx = middleware.WithRoles(ctx, roles)
+		ctx = middleware.WithAuthMethod(ctx, middleware.AuthClientCert)
+
+		return ctx, nil
+	}
+}
+
+// verifiedClientCert - returns client certificate of peer verified against client CA, nil if peer has not
+// presented certificate or connection is not TLS.
+func verifiedClientCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return info.State.VerifiedChains[0][0]
+}
+
+// certIdentity - returns user ID of certificate, empty if there is none, and whether admin role is granted to it.
+func certIdentity(cert *x509.Certificate) (string, bool) {
+	ids := []string{cert.Subject.CommonName}
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		if strings.HasPrefix(u.String(), uuidURNPrefix) {
+			ids = append(ids, strings.TrimPrefix(u.String(), uuidURNPrefix))
+		}
+		names = append(names, u.String())
+	}
+
+	var uid string
+	for _, id := range ids {
+		if parsed, err := uuid.Parse(id); err == nil && len(id) == len(parsed.String()) {
+			uid = parsed.String()
+			break
+		}
+	}
+
+	isAdmin := false
+	for _, name := range names {
+		if name != "" && auth.HasScope(config.GRPCAdminCertNames(), name) {
+			isAdmin = true
+			break
+		}
+	}
+
+	return uid, isAdmin
+}
@@ -0,0 +1,288 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+)
+
+// newCert - returns certificate created from template, signed by parent, or self-signed if parent is nil,
+// with its key.
+func newCert(
+	t *testing.T,
+	template *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+// newCA - returns self-signed CA certificate with its key.
+func newCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return newCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+}
+
+// certContext - returns context of call from peer with client certificate, verified if verified is set.
+func certContext(cert *x509.Certificate, verified bool, md metadata.MD) context.Context {
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if verified {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4321},
+		AuthInfo: credentials.TLSInfo{State: state},
+	})
+
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func TestCertIdentity(t *testing.T) {
+	config.NewConfig(config.WithGRPCAdminCertNames("ops.example.com, spiffe://example.com/ops"))
+	defer config.NewConfig(config.WithGRPCAdminCertNames(""))
+
+	uid := uuid.NewString()
+	spiffe, _ := url.Parse("spiffe://example.com/ops")
+	urn, _ := url.Parse("urn:uuid:" + uid)
+
+	tests := []struct {
+		template  *x509.Certificate
+		name      string
+		wantUID   string
+		wantAdmin bool
+	}{
+		{
+			name:     "User ID is taken from common name",
+			template: &x509.Certificate{Subject: pkix.Name{CommonName: uid}},
+			wantUID:  uid,
+		},
+		{
+			name:     "User ID is taken from urn:uuid URI SAN",
+			template: &x509.Certificate{Subject: pkix.Name{CommonName: "client"}, URIs: []*url.URL{urn}},
+			wantUID:  uid,
+		},
+		{
+			name:     "Common name which is not UUID is not user ID",
+			template: &x509.Certificate{Subject: pkix.Name{CommonName: "{" + uid + "}"}},
+		},
+		{
+			name:      "Admin is granted by DNS SAN",
+			template:  &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}, DNSNames: []string{"ops.example.com"}},
+			wantAdmin: true,
+		},
+		{
+			name:      "Admin with user ID is granted by URI SAN",
+			template:  &x509.Certificate{Subject: pkix.Name{CommonName: uid}, URIs: []*url.URL{spiffe}},
+			wantUID:   uid,
+			wantAdmin: true,
+		},
+		{
+			name:     "Certificate of unknown name is mapped to nothing",
+			template: &x509.Certificate{Subject: pkix.Name{CommonName: "unknown.example.com"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, _ := newCert(t, tt.template, nil, nil)
+
+			gotUID, gotAdmin := certIdentity(cert)
+
+			assert.Equal(t, tt.wantUID, gotUID)
+			assert.Equal(t, tt.wantAdmin, gotAdmin)
+		})
+	}
+}
+
+func TestClientCertGuard(t *testing.T) {
+	config.NewConfig(config.WithGRPCAdminCertNames("ops"), config.WithTrustedSubnet(""))
+	defer config.NewConfig(config.WithGRPCAdminCertNames(""))
+
+	ca, caKey := newCA(t)
+	uid := uuid.NewString()
+	userCert, _ := newCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: uid}}, ca, caKey)
+	adminCert, _ := newCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}}, ca, caKey)
+
+	tests := []struct {
+		ctx            context.Context
+		name           string
+		method         string
+		wantUID        string
+		wantAuthMethod string
+		wantScopes     []string
+		wantCode       codes.Code
+	}{
+		{
+			name:           "User certificate identifies user",
+			ctx:            certContext(userCert, true, nil),
+			method:         "/grpc.Shortener/ShortenURL",
+			wantUID:        uid,
+			wantAuthMethod: middleware.AuthClientCert,
+			wantScopes:     auth.AllScopes,
+		},
+		{
+			name:     "User certificate can't call admin methods",
+			ctx:      certContext(userCert, true, nil),
+			method:   "/grpc.Shortener/BanUser",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:           "Admin certificate calls admin methods",
+			ctx:            certContext(adminCert, true, nil),
+			method:         "/grpc.Shortener/BanUser",
+			wantAuthMethod: middleware.AuthClientCert,
+			wantScopes:     append(append([]string{}, auth.AllScopes...), auth.ScopeAdmin),
+		},
+		{
+			name:     "Not verified certificate is ignored",
+			ctx:      certContext(adminCert, false, nil),
+			method:   "/grpc.Shortener/BanUser",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:           "Verified certificate calls internal methods from any subnet",
+			ctx:            certContext(userCert, true, nil),
+			method:         "/grpc.Shortener/Stats",
+			wantUID:        uid,
+			wantAuthMethod: middleware.AuthClientCert,
+			wantScopes:     auth.AllScopes,
+		},
+		{
+			name:     "Not verified certificate calls internal methods from trusted subnet only",
+			ctx:      certContext(userCert, false, nil),
+			method:   "/grpc.Shortener/Stats",
+			wantCode: codes.PermissionDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := clientCertGuard()(tt.ctx, tt.method)
+			require.NoError(t, err)
+			for _, g := range []guard{apiKeyGuard(nil), identityGuard(verifierMock{}), adminGuard(), trustedSubnetGuard()} {
+				if ctx, err = g(ctx, tt.method); err != nil {
+					break
+				}
+			}
+
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if err != nil {
+				return
+			}
+			gotUID, _ := middleware.UserID(ctx)
+			assert.Equal(t, tt.wantUID, gotUID)
+			assert.Equal(t, tt.wantAuthMethod, middleware.AuthMethod(ctx))
+			assert.ElementsMatch(t, tt.wantScopes, middleware.Scopes(ctx))
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	writePEM := func(name string, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+		return path
+	}
+
+	ca, caKey := newCA(t)
+	serverCert, serverKey := newCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "localhost"}}, ca, caKey)
+	keyDER, err := x509.MarshalECPrivateKey(serverKey)
+	require.NoError(t, err)
+
+	certFile := writePEM("server.crt", "CERTIFICATE", serverCert.Raw)
+	keyFile := writePEM("server.key", "EC PRIVATE KEY", keyDER)
+	caFile := writePEM("ca.crt", "CERTIFICATE", ca.Raw)
+	emptyFile := writePEM("empty.crt", "EMPTY", nil)
+
+	tests := []struct {
+		name              string
+		clientCAFile      string
+		requireClientCert bool
+		wantClientAuth    tls.ClientAuthType
+		wantErr           bool
+	}{
+		{
+			name:           "TLS without client certificates",
+			wantClientAuth: tls.NoClientCert,
+		},
+		{
+			name:           "Client certificates are verified if given",
+			clientCAFile:   caFile,
+			wantClientAuth: tls.VerifyClientCertIfGiven,
+		},
+		{
+			name:              "Client certificates are required",
+			clientCAFile:      caFile,
+			requireClientCert: true,
+			wantClientAuth:    tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:              "Client certificates can't be required without CA",
+			requireClientCert: true,
+			wantErr:           true,
+		},
+		{
+			name:         "CA file without certificates is rejected",
+			clientCAFile: emptyFile,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := TLSConfig(certFile, keyFile, tt.clientCAFile, tt.requireClientCert)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantClientAuth, cfg.ClientAuth)
+			assert.Len(t, cfg.Certificates, 1)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type AccountHandler struct {
+	accounts auth.AccountManager
+}
+
+// Credentials - a representation of signup and login request.
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+func NewAccountHandler(accounts auth.AccountManager) *AccountHandler {
+	return &AccountHandler{
+		accounts: accounts,
+	}
+}
+
+// Register - creates account and logs it in. Links of anonymous user identified by cookie are merged into account.
+func (h *AccountHandler) Register(w http.ResponseWriter, req *http.Request) {
+	var c Credentials
+	if err := json.NewDecoder(req.Body).Decode(&c); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	acc, err := h.accounts.Register(req.Context(), c.Login, c.Password, anonymousUserID(req))
+	switch {
+	case errors.Is(err, utils.ErrWrongLogin), errors.Is(err, utils.ErrWeakPassword):
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, utils.ErrLoginTaken):
+		utils.JSONError(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, s, err := h.accounts.Login(req.Context(), c.Login, c.Password, "")
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(w, token, s)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+
+	if errEnc := json.NewEncoder(w).Encode(acc); errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Login - checks credentials and sets session cookie. Links of anonymous user identified by cookie are merged
+// into account.
+func (h *AccountHandler) Login(w http.ResponseWriter, req *http.Request) {
+	var c Credentials
+	if err := json.NewDecoder(req.Body).Decode(&c); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, s, err := h.accounts.Login(req.Context(), c.Login, c.Password, anonymousUserID(req))
+	if errors.Is(err, utils.ErrBadCredentials) {
+		utils.JSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(w, token, s)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Logout - ends session from session cookie and removes cookie.
+func (h *AccountHandler) Logout(w http.ResponseWriter, req *http.Request) {
+	if c, err := req.Cookie(middleware.SessionCookieName); err == nil {
+		if errLogout := h.accounts.Logout(req.Context(), c.Value); errLogout != nil {
+			utils.JSONError(w, errLogout.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.SetCookie(w, middleware.SessionCookie("", -1))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// anonymousUserID - returns ID of user identified by uid cookie, or empty string if request is identified otherwise.
+func anonymousUserID(req *http.Request) string {
+	if middleware.AuthMethod(req.Context()) != middleware.AuthCookie {
+		return ""
+	}
+
+	uid, _ := middleware.UserID(req.Context())
+
+	return uid
+}
+
+// setSessionCookie - sets cookie with session token which expires with session.
+func setSessionCookie(w http.ResponseWriter, token string, s auth.Session) {
+	c := middleware.SessionCookie(token, 0)
+	c.Expires = s.ExpiresAt
+	http.SetCookie(w, c)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+func TestAccountHandler(t *testing.T) {
+	links := storage.NewMemory(zap.NewNop())
+	accounts := auth.NewAccounts(auth.NewMemoryAccounts(), links, time.Hour, zap.NewNop())
+	h := NewAccountHandler(accounts)
+
+	var uid, method string
+	r := chi.NewRouter()
+	r.Use(middleware.Session(accounts), middleware.Cookie(zap.NewNop()))
+	r.Post("/api/user/register", h.Register)
+	r.Post("/api/user/login", h.Login)
+	r.Post("/api/user/logout", h.Logout)
+	r.Get("/whoami", func(w http.ResponseWriter, req *http.Request) {
+		uid, _ = middleware.UserID(req.Context())
+		method = middleware.AuthMethod(req.Context())
+	})
+
+	do := func(method, path, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	cookie := func(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		return nil
+	}
+
+	anon := do(http.MethodGet, "/whoami", "")
+	anonCookie := cookie(anon, "uid")
+	require.NotNil(t, anonCookie)
+	anonUID := uid
+	key := "anon"
+	links.Store(&key, "https://anon.ru", anonUID)
+
+	rec := do(http.MethodPost, "/api/user/register", `{"login":"user","password":"short"}`, anonCookie)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPost, "/api/user/register", `{"login":"user","password":"password"}`, anonCookie)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	session := cookie(rec, middleware.SessionCookieName)
+	require.NotNil(t, session)
+	assert.True(t, session.HttpOnly)
+
+	rec = do(http.MethodPost, "/api/user/register", `{"login":"user","password":"password"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	do(http.MethodGet, "/whoami", "", anonCookie, session)
+	assert.Equal(t, middleware.AuthSession, method)
+	assert.NotEqual(t, anonUID, uid)
+	accountUID := uid
+
+	merged, ok := links.LinksByUUID(accountUID)
+	require.True(t, ok, "links of anonymous user must be merged into account")
+	assert.Len(t, merged, 1)
+
+	rec = do(http.MethodPost, "/api/user/login", `{"login":"user","password":"wrong password"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(http.MethodPost, "/api/user/login", `{"login":"user","password":"password"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	second := cookie(rec, middleware.SessionCookieName)
+	require.NotNil(t, second)
+
+	rec = do(http.MethodPost, "/api/user/logout", "", second)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	do(http.MethodGet, "/whoami", "", anonCookie, second)
+	assert.Equal(t, middleware.AuthCookie, method)
+	assert.Equal(t, anonUID, uid, "after logout user must be anonymous again")
+
+	_, err := accounts.Session(context.Background(), session.Value)
+	assert.NoError(t, err, "logout must end only its own session")
+}
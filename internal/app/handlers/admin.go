@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type AdminHandler struct {
+	service service.Admin
+}
+
+// BanRequest - a representation of request to ban user. Reason is optional.
+type BanRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AdminActionResponse - a representation of result of admin action over links.
+type AdminActionResponse struct {
+	Affected int `json:"affected"`
+}
+
+func NewAdminHandler(s service.Admin) *AdminHandler {
+	return &AdminHandler{
+		service: s,
+	}
+}
+
+// SearchLinks - returns page of links of all users filtered by key, uid and url query params.
+func (h *AdminHandler) SearchLinks(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	h.search(w, req, service.SearchParams{
+		Key:    params.Get("key"),
+		UID:    params.Get("uid"),
+		URL:    params.Get("url"),
+		Limit:  params.Get("limit"),
+		Cursor: params.Get("cursor"),
+	})
+}
+
+// UserLinks - returns page of links of user from path, including deleted and disabled ones.
+func (h *AdminHandler) UserLinks(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	h.search(w, req, service.SearchParams{
+		UID:    chi.URLParam(req, "uid"),
+		Limit:  params.Get("limit"),
+		Cursor: params.Get("cursor"),
+	})
+}
+
+// DisableLinks - disables links with keys from JSON array of request body, so they are not redirected.
+func (h *AdminHandler) DisableLinks(w http.ResponseWriter, req *http.Request) {
+	h.setDisabled(w, req, true)
+}
+
+// EnableLinks - enables links with keys from JSON array of request body.
+func (h *AdminHandler) EnableLinks(w http.ResponseWriter, req *http.Request) {
+	h.setDisabled(w, req, false)
+}
+
+// DeleteLinks - physically removes links with keys from JSON array of request body regardless of their owner.
+func (h *AdminHandler) DeleteLinks(w http.ResponseWriter, req *http.Request) {
+	var keys []string
+	if err := json.NewDecoder(req.Body).Decode(&keys); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.service.DeleteLinks(req.Context(), keys)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminActionResponse{Affected: n})
+}
+
+// BanUser - bans user from path. Banned user can't shorten links and links of banned user are not redirected.
+func (h *AdminHandler) BanUser(w http.ResponseWriter, req *http.Request) {
+	var br BanRequest
+	if err := json.NewDecoder(req.Body).Decode(&br); err != nil && !errors.Is(err, io.EOF) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := h.service.BanUser(req.Context(), chi.URLParam(req, "uid"), br.Reason)
+	if errors.Is(err, utils.ErrWrongUID) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b)
+}
+
+// UnbanUser - lifts ban of user from path.
+func (h *AdminHandler) UnbanUser(w http.ResponseWriter, req *http.Request) {
+	err := h.service.UnbanUser(req.Context(), chi.URLParam(req, "uid"))
+	if errors.Is(err, utils.ErrWrongUID) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Bans - returns all bans ordered by time.
+func (h *AdminHandler) Bans(w http.ResponseWriter, req *http.Request) {
+	bans, err := h.service.Bans(req.Context())
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bans)
+}
+
+// search - responds with page of links matching params.
+func (h *AdminHandler) search(w http.ResponseWriter, req *http.Request, p service.SearchParams) {
+	q, err := service.ParseSearchQuery(p)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.SearchLinks(req.Context(), q)
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// setDisabled - disables or enables links with keys from request body.
+func (h *AdminHandler) setDisabled(w http.ResponseWriter, req *http.Request, disabled bool) {
+	var keys []string
+	if err := json.NewDecoder(req.Body).Decode(&keys); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.service.SetLinksDisabled(req.Context(), keys, disabled)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminActionResponse{Affected: n})
+}
+
+// writeJSON - responds with provided status and v encoded as json.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if errEnc := json.NewEncoder(w).Encode(v); errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
+)
+
+func TestAdminHandler(t *testing.T) {
+	links := storage.NewMemory(zap.NewNop())
+	admin := service.NewAdminService(links, zap.NewNop())
+	h := NewAdminHandler(admin)
+	tokens := auth.NewTokens(auth.NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Bearer(tokens), middleware.Cookie(zap.NewNop()))
+	r.With(middleware.RejectBanned(admin)).
+		Post("/", NewURLShortenerHandler(service.NewURLShortenerService(links, sequence.NewSequence(), zap.NewNop())).ShortenURL)
+	r.Get("/{id}", NewURLExpandHandler(service.NewURLExpandService(links, zap.NewNop())).ExpandURL)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Get("/api/admin/links", h.SearchLinks)
+		r.Delete("/api/admin/links", h.DeleteLinks)
+		r.Post("/api/admin/links/disable", h.DisableLinks)
+		r.Post("/api/admin/links/enable", h.EnableLinks)
+		r.Get("/api/admin/users/{uid}/links", h.UserLinks)
+		r.Put("/api/admin/users/{uid}/ban", h.BanUser)
+		r.Delete("/api/admin/users/{uid}/ban", h.UnbanUser)
+		r.Get("/api/admin/bans", h.Bans)
+	})
+
+	adminToken, _, err := tokens.Issue(uuid.NewString(), []string{auth.ScopeAdmin}, 0)
+	require.NoError(t, err)
+	userToken, _, err := tokens.Issue(uuid.NewString(), auth.AllScopes, 0)
+	require.NoError(t, err)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	spammer := uuid.NewString()
+	for _, key := range []string{"spam1", "spam2"} {
+		key := key
+		links.Store(&key, "https://spam.example.com/"+key, spammer)
+	}
+
+	rec := do(http.MethodGet, "/api/admin/links", "", userToken)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "user without admin scope must be rejected")
+	rec = do(http.MethodGet, "/api/admin/links", "", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "anonymous user must be rejected")
+
+	rec = do(http.MethodGet, "/api/admin/links?url=spam&limit=1", "", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page storage.SearchPage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Links, 1)
+	assert.Equal(t, spammer, page.Links[0].UID)
+	assert.NotEmpty(t, page.NextCursor)
+
+	rec = do(http.MethodGet, "/api/admin/links?uid=not-uuid", "", adminToken)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPost, "/api/admin/links/disable", `["spam1"]`, adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"affected":1}`, rec.Body.String())
+	assert.Equal(t, http.StatusGone, do(http.MethodGet, "/spam1", "", "").Code, "disabled link must not be redirected")
+
+	rec = do(http.MethodPost, "/api/admin/links/enable", `["spam1"]`, adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusTemporaryRedirect, do(http.MethodGet, "/spam1", "", "").Code)
+
+	rec = do(http.MethodPut, "/api/admin/users/"+spammer+"/ban", `{"reason":"spam"}`, adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusGone, do(http.MethodGet, "/spam2", "", "").Code, "links of banned user must not be redirected")
+
+	spammerToken, _, err := tokens.Issue(spammer, auth.AllScopes, 0)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/", "https://new.example.com", spammerToken).Code,
+		"banned user can't shorten links")
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/", "https://other.example.com", userToken).Code)
+
+	rec = do(http.MethodGet, "/api/admin/bans", "", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"reason":"spam"`)
+
+	rec = do(http.MethodPut, "/api/admin/users/not-uuid/ban", "", adminToken)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodDelete, "/api/admin/users/"+spammer+"/ban", "", adminToken)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, http.StatusTemporaryRedirect, do(http.MethodGet, "/spam2", "", "").Code)
+
+	rec = do(http.MethodDelete, "/api/admin/links", `["spam1","spam2"]`, adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"affected":2}`, rec.Body.String())
+
+	rec = do(http.MethodGet, "/api/admin/users/"+spammer+"/links", "", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"links":[]}`, rec.Body.String())
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type APIKeyHandler struct {
+	keys auth.KeyManager
+}
+
+// APIKeyRequest - a representation of request to create API key. All fields are optional,
+// key of a new user with all scopes and default rate limit is created by empty request.
+type APIKeyRequest struct {
+	UID       string   `json:"uid"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rate_limit"`
+}
+
+// APIKeyResponse - a representation of created API key. Key itself is returned only once.
+type APIKeyResponse struct {
+	Key string `json:"key"`
+	auth.APIKey
+}
+
+func NewAPIKeyHandler(keys auth.KeyManager) *APIKeyHandler {
+	return &APIKeyHandler{
+		keys: keys,
+	}
+}
+
+// Create - creates API key. Works only via trusted subnet.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, req *http.Request) {
+	var kr APIKeyRequest
+	if err := json.NewDecoder(req.Body).Decode(&kr); err != nil && !errors.Is(err, io.EOF) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, k, err := h.keys.Create(req.Context(), kr.UID, kr.Name, kr.Scopes, kr.RateLimit)
+	if errors.Is(err, utils.ErrUnknownScope) || errors.Is(err, utils.ErrWrongRateLimit) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+
+	errEnc := json.NewEncoder(w).Encode(APIKeyResponse{Key: key, APIKey: k})
+	if errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// List - will return API keys of user from uid query param or keys of all users. Works only via trusted subnet.
+func (h *APIKeyHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	keys, err := h.keys.List(req.Context(), req.URL.Query().Get("uid"))
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	errEnc := json.NewEncoder(w).Encode(keys)
+	if errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Revoke - revokes API key by its ID. Works only via trusted subnet.
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, req *http.Request) {
+	err := h.keys.Revoke(req.Context(), chi.URLParam(req, "id"))
+	if errors.Is(err, utils.ErrAPIKeyNotFound) {
+		utils.JSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
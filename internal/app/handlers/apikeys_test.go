@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+)
+
+func TestAPIKeyHandler(t *testing.T) {
+	keys := auth.NewAPIKeys(auth.NewMemoryKeys(), 60, zap.NewNop())
+	_, existing, err := keys.Create(context.Background(), "user", "existing", nil, 0)
+	require.NoError(t, err)
+
+	h := NewAPIKeyHandler(keys)
+	r := chi.NewRouter()
+	r.Post("/api/internal/keys", h.Create)
+	r.Get("/api/internal/keys", h.List)
+	r.Delete("/api/internal/keys/{id}", h.Revoke)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantBody string
+		wantCode int
+	}{
+		{
+			name:     "Key can be created",
+			method:   http.MethodPost,
+			path:     "/api/internal/keys",
+			body:     `{"uid":"user","name":"ci","scopes":["read"],"rate_limit":10}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"key":"usk_`,
+		},
+		{
+			name:     "Key with unknown scope can't be created",
+			method:   http.MethodPost,
+			path:     "/api/internal/keys",
+			body:     `{"scopes":["superuser"]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Keys of user can be listed without hashes",
+			method:   http.MethodGet,
+			path:     "/api/internal/keys?uid=user",
+			wantCode: http.StatusOK,
+			wantBody: `"name":"existing"`,
+		},
+		{
+			name:     "Key can be revoked",
+			method:   http.MethodDelete,
+			path:     "/api/internal/keys/" + existing.ID,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Unknown key can't be revoked",
+			method:   http.MethodDelete,
+			path:     "/api/internal/keys/unknown",
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+			assert.NotContains(t, rec.Body.String(), existing.Hash)
+		})
+	}
+
+	var listed []auth.APIKey
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/keys?uid=user", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 2)
+	assert.NotNil(t, listed[0].RevokedAt)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type AuthHandler struct {
+	issuer auth.Issuer
+}
+
+// TokenRequest - a representation of request to issue bearer token. All fields are optional.
+type TokenRequest struct {
+	TTL    string   `json:"ttl"`
+	Scopes []string `json:"scopes"`
+}
+
+// TokenResponse - a representation of issued bearer token.
+type TokenResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	Scopes    []string  `json:"scopes"`
+}
+
+// NewAuthHandler - creates AuthHandler.
+func NewAuthHandler(issuer auth.Issuer) *AuthHandler {
+	return &AuthHandler{
+		issuer: issuer,
+	}
+}
+
+// IssueToken - issues bearer token for user identified by cookie or session. Token can't be issued with another token
+// or API key, so their scopes can't be widened. Admin scope can be requested only by session of admin.
+func (h *AuthHandler) IssueToken(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if method := middleware.AuthMethod(req.Context()); method != middleware.AuthCookie && method != middleware.AuthSession {
+		utils.JSONError(w, utils.ErrForbidden.Error()+": token can be issued only for cookie or session identity", http.StatusForbidden)
+		return
+	}
+
+	var tr TokenRequest
+	if err := json.NewDecoder(req.Body).Decode(&tr); err != nil && !errors.Is(err, io.EOF) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if auth.HasScope(tr.Scopes, auth.ScopeAdmin) && !auth.HasScope(middleware.Scopes(req.Context()), auth.ScopeAdmin) {
+		utils.JSONError(w, utils.ErrForbidden.Error()+": scope admin is granted only to admins", http.StatusForbidden)
+		return
+	}
+
+	var ttl time.Duration
+	if tr.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(tr.TTL); err != nil {
+			utils.JSONError(w, utils.ErrWrongTokenTTL.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	token, claims, err := h.issuer.Issue(uid, tr.Scopes, ttl)
+	if errors.Is(err, utils.ErrUnknownScope) || errors.Is(err, utils.ErrWrongTokenTTL) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+
+	errEnc := json.NewEncoder(w).Encode(TokenResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: claims.Expiry(),
+		Scopes:    claims.Scopes(),
+	})
+	if errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
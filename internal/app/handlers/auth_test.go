@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+)
+
+func TestAuthHandler_IssueToken(t *testing.T) {
+	tokens := auth.NewTokens(auth.NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+	bearer, _, err := tokens.Issue("token-user", []string{auth.ScopeRead}, 0)
+	require.NoError(t, err)
+
+	type want struct {
+		scopes []string
+		code   int
+	}
+	tests := []struct {
+		name   string
+		body   string
+		bearer string
+		want   want
+	}{
+		{
+			name: "Token with all scopes is issued for cookie identity",
+			want: want{code: http.StatusCreated, scopes: []string{auth.ScopeDelete, auth.ScopeRead, auth.ScopeShorten}},
+		},
+		{
+			name: "Token with requested scopes is issued",
+			body: `{"scopes":["read"],"ttl":"10m"}`,
+			want: want{code: http.StatusCreated, scopes: []string{auth.ScopeRead}},
+		},
+		{
+			name: "Unknown scope can't be requested",
+			body: `{"scopes":["superuser"]}`,
+			want: want{code: http.StatusBadRequest},
+		},
+		{
+			name: "Admin scope can't be requested by user",
+			body: `{"scopes":["admin"]}`,
+			want: want{code: http.StatusForbidden},
+		},
+		{
+			name: "Too long ttl can't be requested",
+			body: `{"ttl":"100h"}`,
+			want: want{code: http.StatusBadRequest},
+		},
+		{
+			name:   "Token can't be issued with token",
+			bearer: bearer,
+			want:   want{code: http.StatusForbidden},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := middleware.Bearer(tokens)(middleware.Cookie(zap.NewNop())(http.HandlerFunc(NewAuthHandler(tokens).IssueToken)))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/token", strings.NewReader(tt.body))
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.want.code, rec.Code)
+			if tt.want.code != http.StatusCreated {
+				return
+			}
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err)
+
+			var resp TokenResponse
+			require.NoError(t, json.Unmarshal(body, &resp))
+			assert.Equal(t, "Bearer", resp.TokenType)
+			assert.Equal(t, tt.want.scopes, resp.Scopes)
+
+			claims, err := tokens.Verify(resp.Token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.scopes, claims.Scopes())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -8,30 +9,31 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 type BatchHandler struct {
 	storage storage.DB
+	quotas  service.Quotas
 	logger  *zap.Logger
 }
 
-// NewBatchHandler - creates BatchHandler.
-func NewBatchHandler(storage storage.DB, l *zap.Logger) *BatchHandler {
+// NewBatchHandler - creates BatchHandler. Batches exceeding quota of user are rejected with 403.
+func NewBatchHandler(storage storage.DB, quotas service.Quotas, l *zap.Logger) *BatchHandler {
 	return &BatchHandler{
 		storage: storage,
+		quotas:  quotas,
 		logger:  l,
 	}
 }
 
 // BatchInsert - mass insert of provided URLs in storage.
 func (h *BatchHandler) BatchInsert(w http.ResponseWriter, req *http.Request) {
-	var uid string
-	err := utils.Decode(middleware.GetUUID(), &uid)
-	if err != nil {
-		h.logger.Error(err.Error(), zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		http.Error(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
 		return
 	}
 
@@ -48,10 +50,18 @@ func (h *BatchHandler) BatchInsert(w http.ResponseWriter, req *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 	}
 
-	batchLinks, err := h.storage.BatchInsert(requestData, uid)
+	var batchLinks []storage.BatchLink
+	err = h.quotas.Insert(req.Context(), storage.Creator(req.Context(), uid), len(requestData), func(ctx context.Context) error {
+		var err error
+		if batchLinks, err = h.storage.BatchInsert(ctx, requestData, uid); err != nil {
+			h.logger.Error(err.Error(), zap.Error(err))
+		}
+		return err
+	})
 	if err != nil {
-		h.logger.Error(err.Error(), zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if !writeQuotaError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -14,6 +14,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
 )
 
@@ -21,8 +22,11 @@ type BatchHandlerMock struct {
 }
 
 func TestNewBatchHandler(t *testing.T) {
+	quotas := service.NewQuotaService(storage.NewMemory(zap.NewNop()), storage.NewMemoryQuotas(), storage.NewMemoryQuotaLocker(), storage.Quota{}, zap.NewNop())
+
 	type args struct {
 		storage storage.DB
+		quotas  service.Quotas
 		l       *zap.Logger
 	}
 	tests := []struct {
@@ -34,17 +38,19 @@ func TestNewBatchHandler(t *testing.T) {
 			name: "DBHandler can be created",
 			args: args{
 				storage: &DBMock{},
+				quotas:  quotas,
 				l:       zap.NewNop(),
 			},
 			want: &BatchHandler{
 				storage: &DBMock{},
+				quotas:  quotas,
 				logger:  zap.NewNop(),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, NewBatchHandler(tt.args.storage, tt.args.l), "NewBatchHandler(%v, %v)", tt.args.storage, tt.args.l)
+			assert.Equalf(t, tt.want, NewBatchHandler(tt.args.storage, tt.args.quotas, tt.args.l), "NewBatchHandler(%v, %v, %v)", tt.args.storage, tt.args.quotas, tt.args.l)
 		})
 	}
 }
@@ -74,7 +80,7 @@ func TestBatchHandler_BatchInsert_ThrowErr(t *testing.T) {
 			)
 			defer resp.Body.Close()
 
-			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
 		})
 	}
 }
@@ -88,6 +94,7 @@ func TestBatchHandler_BatchInsert(t *testing.T) {
 			name: "can batch insert",
 			handler: BatchHandler{
 				storage: &DBMock{},
+				quotas:  service.NewQuotaService(storage.NewMemory(zap.NewNop()), storage.NewMemoryQuotas(), storage.NewMemoryQuotaLocker(), storage.Quota{}, zap.NewNop()),
 				logger:  zap.NewNop(),
 			},
 		},
@@ -95,7 +102,7 @@ func TestBatchHandler_BatchInsert(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := chi.NewRouter()
-			r.Use(middleware.Cookie)
+			r.Use(middleware.Cookie(zap.NewNop()))
 			r.Post("/api/shorten/batch", tt.handler.BatchInsert)
 
 			ts := httptest.NewServer(r)
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type CSRFHandler struct{}
+
+// CSRFResponse - a representation of issued CSRF token.
+type CSRFResponse struct {
+	Token string `json:"token"`
+}
+
+func NewCSRFHandler() *CSRFHandler {
+	return &CSRFHandler{}
+}
+
+// Token - issues CSRF token of user in csrf_token cookie and response body. Token must be sent back
+// in X-CSRF-Token header of state-changing requests to routes protected in token or strict mode.
+func (h *CSRFHandler) Token(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	token, err := middleware.IssueCSRFToken(w, uid)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, CSRFResponse{Token: token})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+)
+
+func TestCSRFHandler_Token(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.Cookie(zap.NewNop()), middleware.CSRF(middleware.CSRFStrict))
+	r.Get("/api/csrf", NewCSRFHandler().Token)
+	r.Post("/api/shorten", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/csrf", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var resp CSRFResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotEmpty(t, resp.Token)
+
+	var uid, csrf *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		switch c.Name {
+		case "uid":
+			uid = c
+		case middleware.CSRFCookieName:
+			csrf = c
+		}
+	}
+	require.NotNil(t, uid)
+	require.NotNil(t, csrf)
+	assert.Equal(t, resp.Token, csrf.Value)
+	assert.False(t, csrf.HttpOnly, "token must be readable by scripts of site")
+
+	post := func(token string, cookies ...*http.Cookie) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		req.Header.Set("Origin", "http://example.com")
+		if token != "" {
+			req.Header.Set(middleware.CSRFHeader, token)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, post(resp.Token, uid, csrf))
+	assert.Equal(t, http.StatusForbidden, post("", uid, csrf), "request without header must be rejected")
+	assert.Equal(t, http.StatusForbidden, post(resp.Token, csrf), "token of another user must be rejected")
+}
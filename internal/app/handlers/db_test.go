@@ -39,22 +39,25 @@ func (d *DBMock) Close(ctx context.Context) error {
 
 func (d *DBMock) Store(key *string, url string, uid string) {
 }
+func (d *DBMock) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	return nil
+}
 func (d *DBMock) Get(key string) (string, bool, bool) {
 	return "", true, true
 }
 func (d *DBMock) LinksByUUID(uuid string) ([]storage.UserURLs, bool) {
 	return nil, false
 }
-func (d *DBMock) BatchInsert([]storage.BatchRequest, string) ([]storage.BatchLink, error) {
+func (d *DBMock) FindLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error) {
+	return storage.LinksPage{}, nil
+}
+func (d *DBMock) BatchInsert(context.Context, []storage.BatchRequest, string) ([]storage.BatchLink, error) {
 	return nil, nil
 }
 func (d *DBMock) SoftDeleteUserURLs(uuid string, ids []string) error {
 	return nil
 }
 
-func (d *DBMock) DeleteThroughCh(channels ...chan storage.BatchDelete) {
-}
-
 func (d *DBMock) HasNotNilConn() bool {
 	return d.isConnNil
 }
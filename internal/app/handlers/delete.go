@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 type URLDeleteHandler struct {
@@ -19,7 +22,19 @@ func NewURLDeleteHandler(service service.URLDelete) *URLDeleteHandler {
 
 // Delete - soft delete provided URL.
 func (h *URLDeleteHandler) Delete(w http.ResponseWriter, req *http.Request) {
-	err := h.service.Delete(req)
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		http.Error(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err := h.service.Delete(uid, req)
+	if errors.Is(err, utils.ErrDeleteQueueFull) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -9,15 +9,23 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 type URLDeleteHandlerMock struct {
-	hasError bool
+	hasError    bool
+	isQueueFull bool
 }
 
-func (h *URLDeleteHandlerMock) Delete(r *http.Request) error {
+func (h *URLDeleteHandlerMock) Delete(uid string, r *http.Request) error {
+	if h.isQueueFull {
+		return utils.ErrDeleteQueueFull
+	}
+
 	if h.hasError {
 		return errors.New("error")
 	}
@@ -31,10 +39,11 @@ func TestURLDeleteHandler_Delete(t *testing.T) {
 	}
 
 	tests := []struct {
-		urlHandler *URLDeleteHandlerMock
-		name       string
-		body       string
-		want       want
+		urlHandler  *URLDeleteHandlerMock
+		name        string
+		body        string
+		want        want
+		isAnonymous bool
 	}{
 		{
 			name: "Can return 202 status",
@@ -50,10 +59,26 @@ func TestURLDeleteHandler_Delete(t *testing.T) {
 				hasError: true,
 			},
 		},
+		{
+			name: "Can return 503 status if delete queue is full",
+			want: want{code: http.StatusServiceUnavailable},
+			urlHandler: &URLDeleteHandlerMock{
+				isQueueFull: true,
+			},
+		},
+		{
+			name:        "Can return 401 status if user is not identified",
+			want:        want{code: http.StatusUnauthorized},
+			urlHandler:  &URLDeleteHandlerMock{},
+			isAnonymous: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := chi.NewRouter()
+			if !tt.isAnonymous {
+				r.Use(middleware.Cookie(zap.NewNop()))
+			}
 			r.Delete("/api/user/urls", NewURLDeleteHandler(tt.urlHandler).Delete)
 
 			ts := httptest.NewServer(r)
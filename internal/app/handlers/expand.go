@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -46,28 +47,59 @@ func (h *URLExpandHandler) ExpandURL(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusTemporaryRedirect)
 }
 
-// UserURLs - get list of userURLs from storage.
+// UserURLs - get page of userURLs from storage. Supports query params limit, cursor, created_after, domain,
+// deleted, q (search over original URL) and sort. URL of the next page is returned in Link header.
+// Links of workspace are listed, if workspace query param is resolved by middleware.Workspace.
 func (h *URLExpandHandler) UserURLs(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-	var uuid string
-	err := utils.Decode(middleware.GetUUID(), &uuid)
-	if err != nil {
-		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+	uuid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	params := req.URL.Query()
+	q, errQuery := service.ParseLinksQuery(uuid, service.LinksParams{
+		Limit:        params.Get("limit"),
+		Cursor:       params.Get("cursor"),
+		CreatedAfter: params.Get("created_after"),
+		Domain:       params.Get("domain"),
+		Deleted:      params.Get("deleted"),
+		Search:       params.Get("q"),
+		Sort:         params.Get("sort"),
+	})
+	if errQuery != nil {
+		utils.JSONError(w, errQuery.Error(), http.StatusBadRequest)
 		return
 	}
 
-	links, errExpand := h.service.ExpandUserLinks(uuid)
-	if errExpand != nil {
+	page, errFind := h.service.FindUserLinks(req.Context(), q)
+	if errors.Is(errFind, utils.ErrInvalidCursor) {
+		utils.JSONError(w, errFind.Error(), http.StatusBadRequest)
+		return
+	}
+	if errFind != nil {
+		utils.JSONError(w, errFind.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(page.Links) == 0 {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
+	links := page.Links
 	for k, v := range links {
 		ss := strings.Split(v.ShortURL, "/")
 		links[k].ShortURL = config.BaseURL() + "/" + ss[len(ss)-1]
 	}
 
+	if page.NextCursor != "" {
+		params.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?%s>; rel="next"`, config.BaseURL(), req.URL.Path, params.Encode()))
+	}
+
 	w.WriteHeader(http.StatusOK)
 	errEnc := json.NewEncoder(w).Encode(links)
 	if errEnc != nil {
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -10,13 +11,16 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 type URLExpandHandlerMock struct {
+	links                  []storage.UserURLs
 	hasErrorInExpandingURL bool
 }
 
@@ -24,6 +28,19 @@ func (u *URLExpandHandlerMock) ExpandUserLinks(uuid string) ([]storage.UserURLs,
 	return nil, nil
 }
 
+func (u *URLExpandHandlerMock) FindUserLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error) {
+	if q.Cursor == "broken" {
+		return storage.LinksPage{}, utils.ErrInvalidCursor
+	}
+
+	page := storage.LinksPage{Links: u.links}
+	if len(u.links) > q.Limit {
+		page.Links, page.NextCursor = u.links[:q.Limit], "next"
+	}
+
+	return page, nil
+}
+
 func (u *URLExpandHandlerMock) ExpandURL(key string) (string, error) {
 	if u.hasErrorInExpandingURL {
 		return "", errors.New("error")
@@ -117,29 +134,75 @@ func TestURLExpandHandler_ExpandURL(t *testing.T) {
 }
 
 func TestURLExpandHandler_UserURLs(t *testing.T) {
-	type fields struct {
-		service service.URLExpand
+	links := []storage.UserURLs{
+		{ShortURL: "a", OriginalURL: "https://github.com/"},
+		{ShortURL: "b", OriginalURL: "https://yandex.ru/"},
+	}
+
+	type want struct {
+		link string
+		code int
 	}
 	tests := []struct {
-		name   string
-		fields fields
+		name    string
+		query   string
+		service service.URLExpand
+		want    want
 	}{
 		{
-			name:   "can retrieve list of URLs",
-			fields: fields{service: &URLExpandHandlerMock{}},
+			name:    "can retrieve list of URLs",
+			service: &URLExpandHandlerMock{links: links},
+			want:    want{code: http.StatusOK},
+		},
+		{
+			name:    "Link header is set when there is next page",
+			query:   "?limit=1&sort=-created_at",
+			service: &URLExpandHandlerMock{links: links},
+			want: want{
+				code: http.StatusOK,
+				link: `<http://localhost:8080/user/urls?cursor=next&limit=1&sort=-created_at>; rel="next"`,
+			},
+		},
+		{
+			name:    "204 is returned when user has no links",
+			service: &URLExpandHandlerMock{},
+			want:    want{code: http.StatusNoContent},
+		},
+		{
+			name:    "400 is returned on malformed limit",
+			query:   "?limit=-1",
+			service: &URLExpandHandlerMock{links: links},
+			want:    want{code: http.StatusBadRequest},
+		},
+		{
+			name:    "400 is returned on unknown sort",
+			query:   "?sort=url",
+			service: &URLExpandHandlerMock{links: links},
+			want:    want{code: http.StatusBadRequest},
+		},
+		{
+			name:    "400 is returned on malformed cursor",
+			query:   "?cursor=broken",
+			service: &URLExpandHandlerMock{links: links},
+			want:    want{code: http.StatusBadRequest},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := chi.NewRouter()
-			r.Use(middleware.Cookie)
-			r.Get("/user/urls", NewURLExpandHandler(tt.fields.service).UserURLs)
+			r.Use(middleware.Cookie(zap.NewNop()))
+			r.Get("/user/urls", NewURLExpandHandler(tt.service).UserURLs)
 
 			ts := httptest.NewServer(r)
-			resp, body := expandTestRequest(t, ts, http.MethodGet, "/user/urls")
+			defer ts.Close()
+
+			resp, body := expandTestRequest(t, ts, http.MethodGet, "/user/urls"+tt.query)
 			defer resp.Body.Close()
-			assert.Equal(t, http.StatusOK, resp.StatusCode)
-			assert.NotEmpty(t, body)
+			assert.Equal(t, tt.want.code, resp.StatusCode)
+			assert.Equal(t, tt.want.link, resp.Header.Get("Link"))
+			if tt.want.code != http.StatusNoContent {
+				assert.NotEmpty(t, body)
+			}
 		})
 	}
 }
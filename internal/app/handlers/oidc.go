@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+const (
+	// oidcFlowCookie - name of cookie with encrypted state, nonce and PKCE verifier of started login.
+	oidcFlowCookie = "oidc_flow"
+	// oidcFlowPath - path of OIDC routes, flow cookie is sent only to them.
+	oidcFlowPath = "/api/auth/oidc"
+	// oidcFlowTTL - time given to user to log in at identity provider.
+	oidcFlowTTL = 10 * time.Minute
+)
+
+type OIDCHandler struct {
+	provider auth.OIDCProvider
+	accounts auth.ExternalLogin
+}
+
+// oidcFlow - a representation of login started at identity provider.
+type oidcFlow struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// OIDCLoginResponse - a representation of user logged in with identity provider.
+type OIDCLoginResponse struct {
+	UID   string   `json:"uid"`
+	Login string   `json:"login"`
+	Roles []string `json:"roles"`
+}
+
+func NewOIDCHandler(p auth.OIDCProvider, accounts auth.ExternalLogin) *OIDCHandler {
+	return &OIDCHandler{
+		provider: p,
+		accounts: accounts,
+	}
+}
+
+// Login - starts authorization code flow with PKCE and redirects user to identity provider.
+func (h *OIDCHandler) Login(w http.ResponseWriter, req *http.Request) {
+	var (
+		flow oidcFlow
+		err  error
+	)
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *v, err = auth.NewPKCEVerifier(); err != nil {
+			utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	flow.ExpiresAt = time.Now().Add(oidcFlowTTL).Unix()
+
+	redirect, err := h.provider.AuthCodeURL(req.Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	b, err := json.Marshal(flow)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	encoded, err := utils.Encode(string(b))
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    encoded,
+		Path:     oidcFlowPath,
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   config.EnableHTTPS(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, req, redirect, http.StatusFound)
+}
+
+// Callback - completes login started by Login: checks state, exchanges code for ID token and starts session
+// of account mapped from its claims. Links of anonymous user identified by cookie are merged into account.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	if e := q.Get("error"); e != "" {
+		utils.JSONError(w, e+": "+q.Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+
+	flow, err := readOIDCFlow(req)
+	if err != nil || subtle.ConstantTimeCompare([]byte(flow.State), []byte(q.Get("state"))) != 1 {
+		utils.JSONError(w, utils.ErrOIDCState.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     oidcFlowPath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   config.EnableHTTPS(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	id, err := h.provider.Exchange(req.Context(), q.Get("code"), flow.Verifier, flow.Nonce)
+	switch {
+	case errors.Is(err, utils.ErrInvalidIDToken), errors.Is(err, utils.ErrMissingClaim):
+		utils.JSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, utils.ErrProviderFailed):
+		utils.JSONError(w, err.Error(), http.StatusBadGateway)
+		return
+	case err != nil:
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, s, err := h.accounts.LoginExternal(req.Context(), id, anonymousUserID(req))
+	if errors.Is(err, utils.ErrLoginTaken) {
+		utils.JSONError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(w, token, s)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	errEnc := json.NewEncoder(w).Encode(OIDCLoginResponse{UID: s.UID, Login: id.Login, Roles: s.Roles})
+	if errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// readOIDCFlow - decrypts flow cookie and checks that it's not expired.
+func readOIDCFlow(req *http.Request) (oidcFlow, error) {
+	var flow oidcFlow
+
+	c, err := req.Cookie(oidcFlowCookie)
+	if err != nil {
+		return flow, err
+	}
+
+	var decoded string
+	if err = utils.Decode(c.Value, &decoded); err != nil {
+		return flow, err
+	}
+	if err = json.Unmarshal([]byte(decoded), &flow); err != nil {
+		return flow, err
+	}
+	if time.Now().Unix() > flow.ExpiresAt {
+		return flow, utils.ErrOIDCState
+	}
+
+	return flow, nil
+}
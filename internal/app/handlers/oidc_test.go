@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth/oidctest"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+func TestOIDCHandler(t *testing.T) {
+	idp := oidctest.NewServer("shortener")
+	defer idp.Close()
+	idp.SetClaims(map[string]interface{}{"sub": "42", "groups": []string{"admins"}})
+
+	provider := auth.NewOIDC(auth.OIDCConfig{
+		RoleMapping: map[string]string{"admins": "admin"},
+		Issuer:      idp.URL,
+		ClientID:    "shortener",
+		RedirectURL: "http://localhost/api/auth/oidc/callback",
+		GroupsClaim: "groups",
+	}, idp.Client())
+	links := storage.NewMemory(zap.NewNop())
+	accounts := auth.NewAccounts(auth.NewMemoryAccounts(), links, time.Hour, zap.NewNop())
+	h := NewOIDCHandler(provider, accounts)
+
+	var uid, method string
+	var roles []string
+	r := chi.NewRouter()
+	r.Use(middleware.Session(accounts), middleware.Cookie(zap.NewNop()))
+	r.Get("/api/auth/oidc/login", h.Login)
+	r.Get("/api/auth/oidc/callback", h.Callback)
+	r.Get("/whoami", func(w http.ResponseWriter, req *http.Request) {
+		uid, _ = middleware.UserID(req.Context())
+		method = middleware.AuthMethod(req.Context())
+		roles = middleware.Roles(req.Context())
+	})
+
+	do := func(target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	cookie := func(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		return nil
+	}
+	// login - starts login and follows redirect of identity provider, returning callback URL and flow cookie.
+	login := func() (string, *http.Cookie) {
+		rec := do("/api/auth/oidc/login")
+		require.Equal(t, http.StatusFound, rec.Code)
+		flow := cookie(rec, oidcFlowCookie)
+		require.NotNil(t, flow)
+		assert.True(t, flow.HttpOnly)
+		assert.Equal(t, oidcFlowPath, flow.Path)
+
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "S256", loc.Query().Get("code_challenge_method"))
+
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		resp, err := client.Get(loc.String())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+
+		return callback.RequestURI(), flow
+	}
+
+	anon := do("/whoami")
+	anonCookie := cookie(anon, "uid")
+	require.NotNil(t, anonCookie)
+	anonUID := uid
+	key := "anon"
+	links.Store(&key, "https://anon.ru", anonUID)
+
+	callback, flow := login()
+	rec := do(callback)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "callback without flow cookie must be rejected")
+
+	forged, _ := url.Parse(callback)
+	q := forged.Query()
+	q.Set("state", "forged")
+	forged.RawQuery = q.Encode()
+	rec = do(forged.RequestURI(), flow)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "callback with other state must be rejected")
+
+	rec = do("/api/auth/oidc/callback?error=access_denied", flow)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(callback, flow, anonCookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp OIDCLoginResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "42", resp.Login)
+	assert.Equal(t, []string{"admin"}, resp.Roles)
+	session := cookie(rec, middleware.SessionCookieName)
+	require.NotNil(t, session)
+	cleared := cookie(rec, oidcFlowCookie)
+	require.NotNil(t, cleared)
+	assert.Negative(t, cleared.MaxAge)
+
+	do("/whoami", session)
+	assert.Equal(t, resp.UID, uid)
+	assert.Equal(t, middleware.AuthSession, method)
+	assert.Equal(t, []string{"admin"}, roles)
+
+	merged, ok := links.LinksByUUID(resp.UID)
+	require.True(t, ok, "links of anonymous user must be merged into account")
+	assert.Len(t, merged, 1)
+
+	rec = do(callback, flow)
+	assert.Equal(t, http.StatusBadGateway, rec.Code, "code can't be redeemed twice")
+
+	callback, flow = login()
+	rec = do(callback, flow)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var again OIDCLoginResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&again))
+	assert.Equal(t, resp.UID, again.UID, "same identity must log into same account")
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type QuotaHandler struct {
+	service service.Quotas
+}
+
+// QuotaErrorResponse - a representation of rejected request exceeding quota. Code is one of service.QuotaLinks,
+// service.QuotaDaily or service.QuotaBatch.
+type QuotaErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+	Limit int    `json:"limit"`
+}
+
+// NewQuotaHandler - creates QuotaHandler.
+func NewQuotaHandler(s service.Quotas) *QuotaHandler {
+	return &QuotaHandler{
+		service: s,
+	}
+}
+
+// Usage - returns quota of user making request and how much of it is used. Links of workspace are charged to its
+// members, so member of workspace gets own quota.
+func (h *QuotaHandler) Usage(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.service.Usage(req.Context(), storage.Creator(req.Context(), uid))
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u)
+}
+
+// UserUsage - returns quota of user from path and how much of it is used.
+func (h *QuotaHandler) UserUsage(w http.ResponseWriter, req *http.Request) {
+	u, err := h.service.Usage(req.Context(), chi.URLParam(req, "uid"))
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u)
+}
+
+// SetOverride - overrides limits of user from path with limits from request body. Omitted limits are taken
+// from global quota, zero limit means no limit.
+func (h *QuotaHandler) SetOverride(w http.ResponseWriter, req *http.Request) {
+	var o storage.QuotaOverride
+	if err := json.NewDecoder(req.Body).Decode(&o); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.service.SetOverride(req.Context(), chi.URLParam(req, "uid"), o)
+	if errors.Is(err, utils.ErrWrongUID) || errors.Is(err, utils.ErrWrongQuota) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u)
+}
+
+// DeleteOverride - returns user from path to global quota.
+func (h *QuotaHandler) DeleteOverride(w http.ResponseWriter, req *http.Request) {
+	err := h.service.DeleteOverride(req.Context(), chi.URLParam(req, "uid"))
+	if errors.Is(err, utils.ErrWrongUID) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeQuotaError - responds with 403 and QuotaErrorResponse if err is quota error. Reports whether response
+// was written.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	qe, ok := service.AsQuotaError(err)
+	if !ok {
+		return false
+	}
+
+	utils.JSONError(w, QuotaErrorResponse{Error: qe.Error(), Code: qe.Code, Limit: qe.Limit}, http.StatusForbidden)
+
+	return true
+}
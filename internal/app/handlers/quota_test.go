@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
+)
+
+func TestQuotaHandler(t *testing.T) {
+	links := storage.NewMemory(zap.NewNop())
+	quotas := service.NewQuotaService(links, storage.NewMemoryQuotas(), storage.NewMemoryQuotaLocker(), storage.Quota{MaxLinks: 2, MaxBatch: 1}, zap.NewNop())
+	h := NewQuotaHandler(quotas)
+	shortener := NewURLShortenerHandler(service.NewQuotaShortener(
+		service.NewURLShortenerService(links, sequence.NewSequence(), zap.NewNop()), quotas))
+
+	r := chi.NewRouter()
+	r.Use(middleware.Cookie(zap.NewNop()))
+	r.Post("/", shortener.ShortenURL)
+	r.Post("/api/shorten", shortener.APIShortenURL)
+	r.Post("/api/shorten/batch", NewBatchHandler(&DBMock{}, quotas, zap.NewNop()).BatchInsert)
+	r.Get("/api/user/quota", h.Usage)
+	r.Get("/api/admin/users/{uid}/quota", h.UserUsage)
+	r.Put("/api/admin/users/{uid}/quota", h.SetOverride)
+	r.Delete("/api/admin/users/{uid}/quota", h.DeleteOverride)
+
+	var uidCookie *http.Cookie
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if uidCookie != nil {
+			req.AddCookie(uidCookie)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == "uid" {
+				uidCookie = c
+			}
+		}
+		return rec
+	}
+	quotaError := func(rec *httptest.ResponseRecorder) QuotaErrorResponse {
+		var resp QuotaErrorResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return resp
+	}
+
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/", "https://one.example.com").Code)
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", `{"url":"https://two.example.com"}`).Code)
+
+	rec := do(http.MethodPost, "/api/shorten", `{"url":"https://three.example.com"}`)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, QuotaErrorResponse{Error: "links quota exceeded: limit is 2", Code: service.QuotaLinks, Limit: 2}, quotaError(rec))
+	rec = do(http.MethodPost, "/", "https://three.example.com")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, service.QuotaLinks, quotaError(rec).Code)
+
+	rec = do(http.MethodPost, "/api/shorten/batch", `[{"correlation_id":"1","original_url":"a"},{"correlation_id":"2","original_url":"b"}]`)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, service.QuotaBatch, quotaError(rec).Code)
+
+	rec = do(http.MethodGet, "/api/user/quota", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var u service.QuotaUsage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&u))
+	assert.Equal(t, 2, u.Links)
+	assert.Equal(t, 2, u.LinksToday)
+	assert.Equal(t, storage.Quota{MaxLinks: 2, MaxBatch: 1}, u.Quota)
+
+	uid, _, err := middleware.DecodeUserCookie(uidCookie.Value)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/admin/users/"+uid+"/quota", `{"max_links":-1}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/admin/users/user/quota", `{"max_links":5}`).Code)
+	rec = do(http.MethodPut, "/api/admin/users/"+uid+"/quota", `{"max_links":5}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"override":{"max_links":5}`)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", `{"url":"https://three.example.com"}`).Code,
+		"override must lift quota")
+
+	rec = do(http.MethodGet, "/api/admin/users/"+uid+"/quota", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"links":3`)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/admin/users/"+uid+"/quota", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/shorten", `{"url":"https://four.example.com"}`).Code)
+}
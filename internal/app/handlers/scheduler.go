@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/scheduler"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type SchedulerHandler struct {
+	reporter scheduler.Reporter
+}
+
+func NewSchedulerHandler(r scheduler.Reporter) *SchedulerHandler {
+	return &SchedulerHandler{
+		reporter: r,
+	}
+}
+
+// Statuses - will return last run status of each scheduled task. Works only via trusted subnet.
+func (h *SchedulerHandler) Statuses(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	statuses, err := h.reporter.Statuses(req.Context())
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	errEnc := json.NewEncoder(w).Encode(statuses)
+	if errEnc != nil {
+		utils.JSONError(w, errEnc.Error(), http.StatusInternalServerError)
+		return
+	}
+}
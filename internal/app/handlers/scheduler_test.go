@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/scheduler"
+)
+
+type SchedulerHandlerMock struct {
+	hasError bool
+}
+
+func (s *SchedulerHandlerMock) Statuses(ctx context.Context) ([]scheduler.Status, error) {
+	if s.hasError {
+		return nil, errors.New("error")
+	}
+
+	next := time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	return []scheduler.Status{{Name: "retention", Interval: "1h0m0s", LastStatus: scheduler.StatusNever, NextRunAt: next}}, nil
+}
+
+func TestSchedulerHandler_Statuses(t *testing.T) {
+	type want struct {
+		response string
+		code     int
+	}
+
+	tests := []struct {
+		name     string
+		reporter *SchedulerHandlerMock
+		want     want
+	}{
+		{
+			name:     "On making GET request will retrieve statuses of tasks if has no errors.",
+			reporter: &SchedulerHandlerMock{},
+			want: want{
+				code: http.StatusOK,
+				response: "[{\"next_run_at\":\"2022-01-01T01:00:00Z\",\"name\":\"retention\",\"interval\":\"1h0m0s\"," +
+					"\"last_status\":\"never\",\"is_leader\":false}]\n",
+			},
+		},
+		{
+			name:     "On making GET request will return error if statuses can't be retrieved.",
+			reporter: &SchedulerHandlerMock{hasError: true},
+			want: want{
+				code:     http.StatusInternalServerError,
+				response: "\"error\"\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Get("/api/internal/scheduler", NewSchedulerHandler(tt.reporter).Statuses)
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			resp, body := internalTestRequest(t, ts, http.MethodGet, "/api/internal/scheduler")
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.code, resp.StatusCode)
+			assert.Equal(t, tt.want.response, body)
+		})
+	}
+}
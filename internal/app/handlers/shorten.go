@@ -45,17 +45,18 @@ func (h *URLShortenerHandler) ShortenURL(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	var uid string
-	fmt.Println("Guid:" + middleware.GetUUID())
-	errD := utils.Decode(middleware.GetUUID(), &uid)
-	if errD != nil {
-		http.Error(w, errD.Error(), http.StatusInternalServerError)
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		http.Error(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	key, shortenErr := h.service.ShortenURL(body, uid)
+	key, shortenErr := h.service.ShortenURL(req.Context(), body, uid)
 	hasConflictInURL := errors.Is(shortenErr, utils.ErrLinksConflict)
 
+	if writeQuotaError(w, shortenErr) {
+		return
+	}
 	if shortenErr != nil && !hasConflictInURL {
 		http.Error(w, shortenErr.Error(), http.StatusInternalServerError)
 		return
@@ -92,16 +93,18 @@ func (h *URLShortenerHandler) APIShortenURL(w http.ResponseWriter, req *http.Req
 		return
 	}
 
-	var uid string
-	errD := utils.Decode(middleware.GetUUID(), &uid)
-	if errD != nil {
-		http.Error(w, errD.Error(), http.StatusInternalServerError)
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	key, shortenErr := h.service.ShortenURL(requestData.URL, uid)
+	key, shortenErr := h.service.ShortenURL(req.Context(), requestData.URL, uid)
 	hasConflictInURL := errors.Is(shortenErr, utils.ErrLinksConflict)
 
+	if writeQuotaError(w, shortenErr) {
+		return
+	}
 	if shortenErr != nil && !hasConflictInURL {
 		utils.JSONError(w, shortenErr.Error(), http.StatusInternalServerError)
 		return
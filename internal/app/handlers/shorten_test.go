@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -12,6 +13,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
@@ -21,7 +23,7 @@ type URLShortenHandlerMock struct {
 	hasErrorInShortenURL bool
 }
 
-func (u *URLShortenHandlerMock) ShortenURL(url string, uid string) (string, error) {
+func (u *URLShortenHandlerMock) ShortenURL(ctx context.Context, url string, uid string) (string, error) {
 	if u.hasErrorInShortenURL {
 		return "", errors.New("error")
 	}
@@ -107,7 +109,7 @@ func TestURLShortenerHandler_ShortenURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := chi.NewRouter()
-			r.Use(middleware.Cookie)
+			r.Use(middleware.Cookie(zap.NewNop()))
 			r.Post("/", NewURLShortenerHandler(tt.urlHandler).ShortenURL)
 
 			ts := httptest.NewServer(r)
@@ -187,7 +189,7 @@ func TestURLShortenerHandler_APIShortenURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := chi.NewRouter()
-			r.Use(middleware.Cookie)
+			r.Use(middleware.Cookie(zap.NewNop()))
 			r.Post("/api/shorten", NewURLShortenerHandler(tt.urlHandler).APIShortenURL)
 
 			ts := httptest.NewServer(r)
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type TransferHandler struct {
+	transfers auth.TransferManager
+}
+
+// TransferResponse - a representation of issued transfer code.
+type TransferResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Code      string    `json:"code"`
+}
+
+// RedeemRequest - a representation of request to redeem transfer code. Mode is "adopt" or "merge",
+// links are merged if mode is not provided.
+type RedeemRequest struct {
+	Code string `json:"code"`
+	Mode string `json:"mode"`
+}
+
+// RedeemResponse - a representation of result of redeemed transfer code.
+type RedeemResponse struct {
+	Merged int `json:"merged"`
+}
+
+func NewTransferHandler(t auth.TransferManager) *TransferHandler {
+	return &TransferHandler{
+		transfers: t,
+	}
+}
+
+// Issue - issues one-time code transferring uid of anonymous user to another device.
+func (h *TransferHandler) Issue(w http.ResponseWriter, req *http.Request) {
+	uid := anonymousUserID(req)
+	if uid == "" {
+		utils.JSONError(w, utils.ErrForbidden.Error()+": only anonymous user can be transferred", http.StatusForbidden)
+		return
+	}
+
+	code, c, err := h.transfers.Issue(req.Context(), uid)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, TransferResponse{ExpiresAt: c.ExpiresAt, Code: code})
+}
+
+// Redeem - redeems transfer code on another device and sets uid cookie of transferred user. In merge mode links of
+// anonymous user of device are moved to transferred user, in adopt mode they stay with previous uid.
+func (h *TransferHandler) Redeem(w http.ResponseWriter, req *http.Request) {
+	uid := anonymousUserID(req)
+	if uid == "" {
+		utils.JSONError(w, utils.ErrForbidden.Error()+": only anonymous user can be transferred", http.StatusForbidden)
+		return
+	}
+
+	var rr RedeemRequest
+	if err := json.NewDecoder(req.Body).Decode(&rr); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rr.Mode == "" {
+		rr.Mode = auth.TransferMerge
+	}
+
+	transferred, merged, err := h.transfers.Redeem(req.Context(), rr.Code, uid, rr.Mode)
+	switch {
+	case errors.Is(err, utils.ErrTransferMode):
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, utils.ErrInvalidTransfer):
+		utils.JSONError(w, err.Error(), http.StatusGone)
+		return
+	case err != nil:
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if errCookie := middleware.SetUserCookie(w, transferred); errCookie != nil {
+		utils.JSONError(w, errCookie.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RedeemResponse{Merged: merged})
+}
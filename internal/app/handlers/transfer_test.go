@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
+)
+
+func TestTransferHandler(t *testing.T) {
+	links := storage.NewMemory(zap.NewNop())
+	h := NewTransferHandler(auth.NewTransfers(auth.NewMemoryTransfers(), links, time.Minute, zap.NewNop()))
+	tokens := auth.NewTokens(auth.NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Bearer(tokens), middleware.Cookie(zap.NewNop()))
+	r.Post("/", NewURLShortenerHandler(service.NewURLShortenerService(links, sequence.NewSequence(), zap.NewNop())).ShortenURL)
+	r.Get("/api/user/urls", NewURLExpandHandler(service.NewURLExpandService(links, zap.NewNop())).UserURLs)
+	r.Post("/api/user/transfer", h.Issue)
+	r.Post("/api/user/transfer/redeem", h.Redeem)
+
+	do := func(method, path, body string, c *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if c != nil {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	uidCookie := func(rec *httptest.ResponseRecorder) *http.Cookie {
+		var found *http.Cookie
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == "uid" {
+				require.Nil(t, found, "uid cookie must be set once")
+				found = c
+			}
+		}
+		require.NotNil(t, found)
+		return found
+	}
+	issue := func(c *http.Cookie) TransferResponse {
+		rec := do(http.MethodPost, "/api/user/transfer", "", c)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		var tr TransferResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&tr))
+		return tr
+	}
+
+	rec := do(http.MethodPost, "/", "https://laptop.example.com", nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	laptop := uidCookie(rec)
+	rec = do(http.MethodPost, "/", "https://phone.example.com", nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	phone := uidCookie(rec)
+
+	tr := issue(laptop)
+	assert.NotEmpty(t, tr.Code)
+	assert.True(t, tr.ExpiresAt.After(time.Now()))
+
+	rec = do(http.MethodPost, "/api/user/transfer/redeem", `{"code":"`+tr.Code+`","mode":"steal"}`, phone)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPost, "/api/user/transfer/redeem", `{"code":"`+tr.Code+`"}`, phone)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"merged":1}`, rec.Body.String())
+	phone = uidCookie(rec)
+
+	rec = do(http.MethodPost, "/api/user/transfer/redeem", `{"code":"`+tr.Code+`"}`, phone)
+	assert.Equal(t, http.StatusGone, rec.Code, "code must be redeemed only once")
+
+	for _, c := range []*http.Cookie{laptop, phone} {
+		rec = do(http.MethodGet, "/api/user/urls", "", c)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://laptop.example.com")
+		assert.Contains(t, rec.Body.String(), "https://phone.example.com", "links of both devices must be merged")
+	}
+
+	rec = do(http.MethodPost, "/", "https://tablet.example.com", nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	tablet := uidCookie(rec)
+	tr = issue(laptop)
+	rec = do(http.MethodPost, "/api/user/transfer/redeem", `{"code":"`+tr.Code+`","mode":"adopt"}`, tablet)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"merged":0}`, rec.Body.String())
+	rec = do(http.MethodGet, "/api/user/urls", "", uidCookie(rec))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "https://tablet.example.com", "links of device must not be merged on adopt")
+
+	token, _, err := tokens.Issue(uuid.NewString(), auth.AllScopes, 0)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/user/transfer", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "only anonymous user can be transferred")
+}
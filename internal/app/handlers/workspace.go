@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type WorkspaceHandler struct {
+	workspaces auth.WorkspaceManager
+}
+
+// WorkspaceRequest - a representation of request to create workspace.
+type WorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+// MemberRequest - a representation of request to add member to workspace or change role of member.
+type MemberRequest struct {
+	Role string `json:"role"`
+}
+
+func NewWorkspaceHandler(w auth.WorkspaceManager) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaces: w,
+	}
+}
+
+// Create - creates workspace owned by user.
+func (h *WorkspaceHandler) Create(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var wr WorkspaceRequest
+	if err := json.NewDecoder(req.Body).Decode(&wr); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws, err := h.workspaces.Create(req.Context(), uid, wr.Name)
+	if errors.Is(err, utils.ErrWorkspaceName) {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, ws)
+}
+
+// List - returns workspaces of user with role of user in each of them.
+func (h *WorkspaceHandler) List(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	workspaces, err := h.workspaces.List(req.Context(), uid)
+	if err != nil {
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workspaces)
+}
+
+// Members - returns members of workspace from path. Available to any member of workspace.
+func (h *WorkspaceHandler) Members(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	members, err := h.workspaces.Members(req.Context(), uid, chi.URLParam(req, "id"))
+	if err != nil {
+		workspaceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+// SetMember - adds user from path to workspace or changes role of member. Available to owners of workspace.
+func (h *WorkspaceHandler) SetMember(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var mr MemberRequest
+	if err := json.NewDecoder(req.Body).Decode(&mr); err != nil {
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.workspaces.SetMember(req.Context(), uid, chi.URLParam(req, "id"), chi.URLParam(req, "uid"), mr.Role)
+	if err != nil {
+		workspaceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m)
+}
+
+// RemoveMember - removes user from path from workspace. Available to owners of workspace and to member itself.
+func (h *WorkspaceHandler) RemoveMember(w http.ResponseWriter, req *http.Request) {
+	uid, ok := middleware.UserID(req.Context())
+	if !ok {
+		utils.JSONError(w, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err := h.workspaces.RemoveMember(req.Context(), uid, chi.URLParam(req, "id"), chi.URLParam(req, "uid"))
+	if err != nil {
+		workspaceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// workspaceError - responds with status matching error of workspace management.
+func workspaceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrNotMember), errors.Is(err, utils.ErrForbidden):
+		utils.JSONError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, utils.ErrWrongRole), errors.Is(err, utils.ErrWrongUID):
+		utils.JSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, utils.ErrLastOwner):
+		utils.JSONError(w, err.Error(), http.StatusConflict)
+	default:
+		utils.JSONError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/service"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
+)
+
+type deleteRecorder struct {
+	uids []string
+}
+
+func (d *deleteRecorder) Delete(uid string, r *http.Request) error {
+	d.uids = append(d.uids, uid)
+	return nil
+}
+
+func TestWorkspaceHandler(t *testing.T) {
+	links := storage.NewMemory(zap.NewNop())
+	workspaces := auth.NewWorkspaces(auth.NewMemoryWorkspaces(), zap.NewNop())
+	h := NewWorkspaceHandler(workspaces)
+	deleter := &deleteRecorder{}
+	tokens := auth.NewTokens(auth.NewHMACSigner([]byte("secret")), time.Hour, 24*time.Hour)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Bearer(tokens), middleware.Cookie(zap.NewNop()))
+	r.With(middleware.Workspace(workspaces)).
+		Post("/", NewURLShortenerHandler(service.NewURLShortenerService(links, sequence.NewSequence(), zap.NewNop())).ShortenURL)
+	r.With(middleware.Workspace(workspaces)).
+		Get("/api/user/urls", NewURLExpandHandler(service.NewURLExpandService(links, zap.NewNop())).UserURLs)
+	r.With(middleware.Workspace(workspaces)).Delete("/api/user/urls", NewURLDeleteHandler(deleter).Delete)
+	r.Post("/api/workspaces", h.Create)
+	r.Get("/api/workspaces", h.List)
+	r.Get("/api/workspaces/{id}/members", h.Members)
+	r.Put("/api/workspaces/{id}/members/{uid}", h.SetMember)
+	r.Delete("/api/workspaces/{id}/members/{uid}", h.RemoveMember)
+
+	owner, editor, viewer, outsider := uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()
+	tokenOf := map[string]string{}
+	for _, uid := range []string{owner, editor, viewer, outsider} {
+		token, _, err := tokens.Issue(uid, auth.AllScopes, 0)
+		require.NoError(t, err)
+		tokenOf[uid] = token
+	}
+
+	do := func(method, path, body, uid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tokenOf[uid])
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/workspaces", `{"name":""}`, owner)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPost, "/api/workspaces", `{"name":"Marketing"}`, owner)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var w auth.Workspace
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&w))
+	assert.Equal(t, "Marketing", w.Name)
+
+	rec = do(http.MethodPut, "/api/workspaces/"+w.ID+"/members/"+editor, `{"role":"editor"}`, owner)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = do(http.MethodPut, "/api/workspaces/"+w.ID+"/members/"+viewer, `{"role":"viewer"}`, owner)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = do(http.MethodPut, "/api/workspaces/"+w.ID+"/members/"+outsider, `{"role":"viewer"}`, editor)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "only owner can add members")
+	rec = do(http.MethodPut, "/api/workspaces/"+w.ID+"/members/"+viewer, `{"role":"admin"}`, owner)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do(http.MethodDelete, "/api/workspaces/"+w.ID+"/members/"+owner, "", owner)
+	assert.Equal(t, http.StatusConflict, rec.Code, "the last owner can't leave")
+
+	rec = do(http.MethodGet, "/api/workspaces", "", viewer)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"role":"viewer"`)
+
+	rec = do(http.MethodGet, "/api/workspaces/"+w.ID+"/members", "", viewer)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var members []auth.WorkspaceMember
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&members))
+	assert.Len(t, members, 3)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/workspaces/"+w.ID+"/members", "", outsider).Code)
+
+	rec = do(http.MethodPost, "/?workspace="+w.ID, "https://team.example.com", editor)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec = do(http.MethodPost, "/?workspace="+w.ID, "https://viewer.example.com", viewer)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "viewer can't shorten links into workspace")
+	rec = do(http.MethodPost, "/", "https://personal.example.com", editor)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	for _, uid := range []string{owner, editor, viewer} {
+		rec = do(http.MethodGet, "/api/user/urls?workspace="+w.ID, "", uid)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://team.example.com", "member must see links of workspace")
+		assert.NotContains(t, rec.Body.String(), "https://personal.example.com")
+	}
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/user/urls?workspace="+w.ID, "", outsider).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/user/urls?workspace=marketing", "", owner).Code)
+
+	rec = do(http.MethodGet, "/api/user/urls", "", editor)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "https://personal.example.com")
+	assert.NotContains(t, rec.Body.String(), "https://team.example.com", "links of workspace are not personal links")
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/api/user/urls?workspace="+w.ID, `["a"]`, viewer).Code)
+	assert.Equal(t, http.StatusAccepted, do(http.MethodDelete, "/api/user/urls?workspace="+w.ID, `["a"]`, owner).Code)
+	assert.Equal(t, []string{w.ID}, deleter.uids, "links must be deleted on behalf of workspace")
+
+	rec = do(http.MethodDelete, "/api/workspaces/"+w.ID+"/members/"+editor, "", editor)
+	require.Equal(t, http.StatusNoContent, rec.Code, "member can leave workspace")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/user/urls?workspace="+w.ID, "", editor).Code)
+}
@@ -0,0 +1,52 @@
+// Package jobs - durable background jobs processed by pool of workers with retries and dead-lettering.
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Statuses of Job.
+const (
+	StatusPending = "pending" // job waits for its RunAt time
+	StatusRunning = "running" // job is claimed by worker until LockedUntil
+	StatusDead    = "dead"    // job failed MaxAttempts times and won't be retried
+)
+
+// Job - a representation of background job.
+type Job struct {
+	RunAt       time.Time
+	LockedUntil time.Time
+	Kind        string
+	Status      string
+	LastError   string
+	Payload     []byte
+	ID          int64
+	Attempts    int
+	MaxAttempts int
+}
+
+// Handler - processes payload of job. Returned error causes job to be retried.
+type Handler func(ctx context.Context, payload []byte) error
+
+// Producer - creates jobs.
+type Producer interface {
+	// Enqueue - creates job of provided kind with payload marshalled to json.
+	Enqueue(ctx context.Context, kind string, payload interface{}) error
+}
+
+// Store - storage of jobs.
+type Store interface {
+	Producer
+	// Claim - marks the oldest ready job as running for visibility time and returns it.
+	// Running jobs which were not completed in time are claimed again. Returns nil if there are no ready jobs.
+	Claim(ctx context.Context, visibility time.Duration) (*Job, error)
+	// Complete - removes successfully processed job.
+	Complete(ctx context.Context, id int64) error
+	// Retry - returns job to pending state, so it can be claimed again after runAt.
+	Retry(ctx context.Context, id int64, runAt time.Time, lastErr string) error
+	// DeadLetter - marks job as dead, so it won't be claimed anymore.
+	DeadLetter(ctx context.Context, id int64, lastErr string) error
+	// PurgeDead - removes dead jobs which were run for the last time before provided time and returns their count.
+	PurgeDead(ctx context.Context, before time.Time) (int, error)
+}
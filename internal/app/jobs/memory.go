@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+var _ Store = (*Memory)(nil)
+
+// Memory - in-memory Store used when service runs in memory or file mode.
+type Memory struct {
+	jobs   map[int64]*Job
+	lastID int64
+	mu     sync.Mutex
+}
+
+// NewMemory - creates Memory job store.
+func NewMemory() *Memory {
+	return &Memory{jobs: map[int64]*Job{}}
+}
+
+// Enqueue - creates pending job with payload marshalled to json.
+func (m *Memory) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.lastID++
+	m.jobs[m.lastID] = &Job{
+		ID:          m.lastID,
+		Kind:        kind,
+		Payload:     b,
+		Status:      StatusPending,
+		RunAt:       time.Now(),
+		MaxAttempts: config.JobMaxAttempts(),
+	}
+
+	return nil
+}
+
+// Claim - marks the oldest ready job as running and returns its copy.
+func (m *Memory) Claim(ctx context.Context, visibility time.Duration) (*Job, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	now := time.Now()
+
+	var claimed *Job
+	for _, j := range m.jobs {
+		if !isReady(j, now) {
+			continue
+		}
+		if claimed == nil || j.RunAt.Before(claimed.RunAt) || (j.RunAt.Equal(claimed.RunAt) && j.ID < claimed.ID) {
+			claimed = j
+		}
+	}
+
+	if claimed == nil {
+		return nil, nil
+	}
+
+	claimed.Status = StatusRunning
+	claimed.Attempts++
+	claimed.LockedUntil = now.Add(visibility)
+
+	j := *claimed
+	return &j, nil
+}
+
+// Complete - removes job.
+func (m *Memory) Complete(ctx context.Context, id int64) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.jobs, id)
+
+	return nil
+}
+
+// Retry - returns job to pending state.
+func (m *Memory) Retry(ctx context.Context, id int64, runAt time.Time, lastErr string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if j, ok := m.jobs[id]; ok {
+		j.Status = StatusPending
+		j.RunAt = runAt
+		j.LockedUntil = time.Time{}
+		j.LastError = lastErr
+	}
+
+	return nil
+}
+
+// DeadLetter - marks job as dead.
+func (m *Memory) DeadLetter(ctx context.Context, id int64, lastErr string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if j, ok := m.jobs[id]; ok {
+		j.Status = StatusDead
+		j.LockedUntil = time.Time{}
+		j.LastError = lastErr
+	}
+
+	return nil
+}
+
+// PurgeDead - removes dead jobs which were run for the last time before provided time.
+func (m *Memory) PurgeDead(ctx context.Context, before time.Time) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	purged := 0
+	for id, j := range m.jobs {
+		if j.Status == StatusDead && j.RunAt.Before(before) {
+			delete(m.jobs, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// isReady - reports whether job can be claimed at provided time.
+func isReady(j *Job, now time.Time) bool {
+	switch j.Status {
+	case StatusPending:
+		return !j.RunAt.After(now)
+	case StatusRunning:
+		return j.LockedUntil.Before(now)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Claim(t *testing.T) {
+	tests := []struct {
+		prepare    func(m *Memory)
+		name       string
+		wantKind   string
+		visibility time.Duration
+		wantNil    bool
+	}{
+		{
+			name:    "Nothing is claimed from empty store",
+			prepare: func(m *Memory) {},
+			wantNil: true,
+		},
+		{
+			name: "The oldest pending job is claimed",
+			prepare: func(m *Memory) {
+				require.NoError(t, m.Enqueue(context.Background(), "first", nil))
+				require.NoError(t, m.Enqueue(context.Background(), "second", nil))
+			},
+			visibility: time.Minute,
+			wantKind:   "first",
+		},
+		{
+			name: "Running job is not claimed until visibility timeout expires",
+			prepare: func(m *Memory) {
+				require.NoError(t, m.Enqueue(context.Background(), "first", nil))
+				_, err := m.Claim(context.Background(), time.Minute)
+				require.NoError(t, err)
+			},
+			wantNil: true,
+		},
+		{
+			name: "Running job is claimed again after visibility timeout expires",
+			prepare: func(m *Memory) {
+				require.NoError(t, m.Enqueue(context.Background(), "first", nil))
+				_, err := m.Claim(context.Background(), -time.Second)
+				require.NoError(t, err)
+			},
+			visibility: time.Minute,
+			wantKind:   "first",
+		},
+		{
+			name: "Dead job is never claimed",
+			prepare: func(m *Memory) {
+				require.NoError(t, m.Enqueue(context.Background(), "first", nil))
+				require.NoError(t, m.DeadLetter(context.Background(), 1, "error"))
+			},
+			wantNil: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+			tt.prepare(m)
+
+			j, err := m.Claim(context.Background(), tt.visibility)
+			require.NoError(t, err)
+
+			if tt.wantNil {
+				assert.Nil(t, j)
+				return
+			}
+
+			require.NotNil(t, j)
+			assert.Equal(t, tt.wantKind, j.Kind)
+			assert.Equal(t, StatusRunning, j.Status)
+		})
+	}
+}
+
+func TestMemory_PurgeDead(t *testing.T) {
+	m := NewMemory()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Enqueue(context.Background(), "test", nil))
+	}
+	require.NoError(t, m.DeadLetter(context.Background(), 1, "error"))
+	require.NoError(t, m.DeadLetter(context.Background(), 2, "error"))
+	m.jobs[1].RunAt = time.Now().Add(-2 * time.Hour)
+
+	purged, err := m.PurgeDead(context.Background(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, purged)
+	assert.NotContains(t, m.jobs, int64(1), "old dead job is purged")
+	assert.Contains(t, m.jobs, int64(2), "recently dead job is kept")
+	assert.Contains(t, m.jobs, int64(3), "pending job is kept")
+}
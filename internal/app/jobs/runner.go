@@ -0,0 +1,178 @@
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+// maxRetryBackoff - upper bound of pause before retry of failed job.
+const maxRetryBackoff = time.Hour
+
+// ackTimeout - timeout of reporting result of job to Store, which isn't bounded by visibility timeout of job, so
+// result of job handled right before timeout is still reported.
+const ackTimeout = 5 * time.Second
+
+// Counters of processed jobs, published in expvar.
+var (
+	jobsCompleted = expvar.NewInt("jobs_completed")
+	jobsRetried   = expvar.NewInt("jobs_retried")
+	jobsDead      = expvar.NewInt("jobs_dead")
+)
+
+// Runner - pool of workers claiming jobs from Store and passing them to registered handlers.
+// ctx stops claiming of jobs, handlers run with jobsCtx, which is canceled only if they don't finish on Shutdown.
+type Runner struct {
+	store      Store
+	logger     *zap.Logger
+	handlers   map[string]Handler
+	ctx        context.Context
+	cancel     context.CancelFunc
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewRunner - creates Runner. Handlers must be registered before Start is called.
+func NewRunner(store Store, l *zap.Logger) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+
+	return &Runner{
+		store:      store,
+		logger:     l,
+		handlers:   map[string]Handler{},
+		ctx:        ctx,
+		cancel:     cancel,
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
+	}
+}
+
+// Register - sets handler for jobs of provided kind.
+func (r *Runner) Register(kind string, h Handler) {
+	r.handlers[kind] = h
+}
+
+// Start - starts config.JobWorkers workers.
+func (r *Runner) Start() {
+	workers := config.JobWorkers()
+	if workers <= 0 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+}
+
+// Shutdown - stops claiming new jobs and waits until already claimed ones are processed or ctx is done.
+// Handlers still running then are canceled, their jobs will be claimed again after visibility timeout.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.cancelJobs()
+		return ctx.Err()
+	}
+}
+
+// work - claims and processes jobs until Shutdown is called, pausing for config.JobPollInterval when there are none.
+func (r *Runner) work() {
+	defer r.wg.Done()
+
+	for {
+		j, err := r.store.Claim(r.ctx, config.JobVisibilityTimeout())
+		if err != nil && r.ctx.Err() == nil {
+			r.logger.Error(err.Error(), zap.Error(err))
+		}
+
+		if j != nil {
+			r.process(j)
+			continue
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(config.JobPollInterval()):
+		}
+	}
+}
+
+// process - passes job to its handler and completes, retries or dead-letters it depending on result.
+// Handler is given visibility timeout to finish, so job is not processed by two workers at once.
+// Jobs of handlers canceled by Shutdown are left claimed, so they are not counted as failed attempts.
+func (r *Runner) process(j *Job) {
+	ctx, cancel := context.WithTimeout(r.jobsCtx, config.JobVisibilityTimeout())
+	defer cancel()
+
+	err := r.handle(ctx, j)
+	if r.jobsCtx.Err() != nil {
+		r.logger.Warn("job is canceled on shutdown", zap.Int64("id", j.ID), zap.String("kind", j.Kind))
+		return
+	}
+
+	ctx, cancelAck := context.WithTimeout(r.jobsCtx, ackTimeout)
+	defer cancelAck()
+
+	switch {
+	case err == nil:
+		err = r.store.Complete(ctx, j.ID)
+		jobsCompleted.Add(1)
+	case j.Attempts >= j.MaxAttempts:
+		r.logger.Error("job is dead", zap.Int64("id", j.ID), zap.String("kind", j.Kind), zap.Error(err))
+		err = r.store.DeadLetter(ctx, j.ID, err.Error())
+		jobsDead.Add(1)
+	default:
+		r.logger.Warn("job failed", zap.Int64("id", j.ID), zap.String("kind", j.Kind), zap.Error(err))
+		err = r.store.Retry(ctx, j.ID, time.Now().Add(Backoff(j.Attempts)), err.Error())
+		jobsRetried.Add(1)
+	}
+
+	if err != nil {
+		r.logger.Error(err.Error(), zap.Int64("id", j.ID), zap.Error(err))
+	}
+}
+
+// handle - calls handler registered for kind of job.
+func (r *Runner) handle(ctx context.Context, j *Job) error {
+	h, ok := r.handlers[j.Kind]
+	if !ok {
+		j.Attempts = j.MaxAttempts
+		return fmt.Errorf("no handler for job kind %q", j.Kind)
+	}
+
+	return h(ctx, j.Payload)
+}
+
+// Backoff - returns pause before next attempt of job failed attempt times.
+// Pause starts from config.JobRetryBackoff and is doubled on each attempt up to maxRetryBackoff.
+func Backoff(attempt int) time.Duration {
+	d := config.JobRetryBackoff()
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+
+	if d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+
+	return d
+}
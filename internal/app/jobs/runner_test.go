@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+func TestRunner_process(t *testing.T) {
+	tests := []struct {
+		handler    Handler
+		name       string
+		kind       string
+		wantStatus string
+		wantExists bool
+	}{
+		{
+			name:       "Completed job is removed",
+			kind:       "test",
+			handler:    func(ctx context.Context, payload []byte) error { return nil },
+			wantExists: false,
+		},
+		{
+			name:       "Failed job is retried",
+			kind:       "test",
+			handler:    func(ctx context.Context, payload []byte) error { return errors.New("error") },
+			wantExists: true,
+			wantStatus: StatusPending,
+		},
+		{
+			name:       "Job without handler is dead-lettered",
+			kind:       "unknown",
+			handler:    func(ctx context.Context, payload []byte) error { return nil },
+			wantExists: true,
+			wantStatus: StatusDead,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+			require.NoError(t, m.Enqueue(context.Background(), tt.kind, nil))
+
+			r := NewRunner(m, zap.NewNop())
+			r.Register("test", tt.handler)
+
+			j, err := m.Claim(context.Background(), time.Minute)
+			require.NoError(t, err)
+			r.process(j)
+
+			stored, ok := m.jobs[j.ID]
+			assert.Equal(t, tt.wantExists, ok)
+			if ok {
+				assert.Equal(t, tt.wantStatus, stored.Status)
+			}
+		})
+	}
+}
+
+func TestRunner_DeadLetterAfterMaxAttempts(t *testing.T) {
+	config.NewConfig(config.WithJobMaxAttempts(2), config.WithJobWorkers(1))
+	defer config.NewConfig(config.WithJobMaxAttempts(5), config.WithJobWorkers(2))
+
+	m := NewMemory()
+	require.NoError(t, m.Enqueue(context.Background(), "test", nil))
+
+	var calls int32
+	r := NewRunner(m, zap.NewNop())
+	r.Register("test", func(ctx context.Context, payload []byte) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("error")
+	})
+
+	for i := 0; i < 2; i++ {
+		m.jobs[1].RunAt = time.Now()
+		j, err := m.Claim(context.Background(), time.Minute)
+		require.NoError(t, err)
+		r.process(j)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, StatusDead, m.jobs[1].Status)
+	assert.Equal(t, "error", m.jobs[1].LastError)
+}
+
+func TestRunner_Shutdown(t *testing.T) {
+	config.NewConfig(config.WithJobWorkers(2))
+
+	m := NewMemory()
+	done := make(chan struct{})
+
+	r := NewRunner(m, zap.NewNop())
+	r.Register("test", func(ctx context.Context, payload []byte) error {
+		close(done)
+		return nil
+	})
+	r.Start()
+
+	require.NoError(t, m.Enqueue(context.Background(), "test", nil))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not processed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, r.Shutdown(ctx))
+}
+
+func TestRunner_ShutdownCancelsHangingHandler(t *testing.T) {
+	config.NewConfig(config.WithJobWorkers(1))
+	defer config.NewConfig(config.WithJobWorkers(2))
+
+	m := NewMemory()
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+
+	r := NewRunner(m, zap.NewNop())
+	r.Register("test", func(ctx context.Context, payload []byte) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+	r.Start()
+
+	require.NoError(t, m.Enqueue(context.Background(), "test", nil))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not processed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, r.Shutdown(ctx), context.DeadlineExceeded)
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not canceled")
+	}
+	r.wg.Wait()
+	assert.Equal(t, StatusRunning, m.jobs[1].Status, "job is left claimed and not counted as failed")
+	assert.Empty(t, m.jobs[1].LastError)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{name: "First retry waits for initial backoff", attempt: 1, want: time.Second},
+		{name: "Backoff is doubled on each attempt", attempt: 3, want: 4 * time.Second},
+		{name: "Backoff is capped", attempt: 100, want: maxRetryBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Backoff(tt.attempt))
+		})
+	}
+}
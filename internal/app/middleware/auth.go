@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Methods of user authentication.
+const (
+	AuthCookie     = "cookie"
+	AuthBearer     = "bearer"
+	AuthAPIKey     = "api_key"
+	AuthSession    = "session"
+	AuthClientCert = "client_cert"
+)
+
+// SessionCookieName - name of cookie with token of login session.
+const SessionCookieName = "session"
+
+// APIKeyHeader - header carrying API key.
+const APIKeyHeader = "X-API-Key"
+
+// Bearer - authenticates requests with "Authorization: Bearer <token>" header. User ID and scopes of token
+// are passed to next handler in request context. Requests with invalid or expired token are rejected
+// with 401, requests without token are passed as is, so Cookie can identify them.
+func Bearer(v auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			header := request.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				unauthorized(writer, utils.ErrInvalidToken)
+				return
+			}
+
+			claims, err := v.Verify(strings.TrimSpace(token))
+			if err != nil {
+				unauthorized(writer, err)
+				return
+			}
+
+			ctx := WithUserID(request.Context(), claims.UID)
+			ctx = WithScopes(ctx, claims.Scopes())
+			ctx = WithAuthMethod(ctx, AuthBearer)
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// APIKey - authenticates requests with X-API-Key header. Owner and scopes of key are passed to next handler
+// in request context. Requests with unknown or revoked key are rejected with 401, requests over rate limit of key
+// are rejected with 429 and Retry-After header. Requests without key are passed as is.
+func APIKey(a auth.KeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			key := request.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			k, err := a.Authenticate(request.Context(), key)
+			if errors.Is(err, utils.ErrInvalidAPIKey) {
+				http.Error(writer, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if ok, retryAfter := a.Allow(k); !ok {
+				writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(writer, utils.ErrRateLimited.Error(), http.StatusTooManyRequests)
+				return
+			}
+
+			ctx := WithUserID(request.Context(), k.UID)
+			ctx = WithScopes(ctx, k.Scopes)
+			ctx = WithAuthMethod(ctx, AuthAPIKey)
+			ctx = WithAPIKeyID(ctx, k.ID)
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// Session - identifies requests by session cookie of account. Account ID with scopes of its roles and roles
+// of session is passed to next handler in request context. Requests without cookie or with unknown or expired session are passed as is, so Cookie
+// identifies them as anonymous users.
+func Session(a auth.SessionAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if _, ok := UserID(request.Context()); ok {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			c, err := request.Cookie(SessionCookieName)
+			if err != nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			s, err := a.Session(request.Context(), c.Value)
+			if err != nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			ctx := WithUserID(request.Context(), s.UID)
+			ctx = WithScopes(ctx, auth.RoleScopes(s.Roles))
+			ctx = WithAuthMethod(ctx, AuthSession)
+			ctx = WithRoles(ctx, s.Roles)
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope - rejects with 403 requests which were not granted provided scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if !auth.HasScope(Scopes(request.Context()), scope) {
+				http.Error(writer, utils.ErrForbidden.Error()+": scope "+scope+" is required", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// RequireAdmin - rejects with 403 requests which were not granted auth.ScopeAdmin and were not made from trusted subnet.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !auth.HasScope(Scopes(request.Context()), auth.ScopeAdmin) && !fromTrustedSubnet(request) {
+			http.Error(writer, utils.ErrForbidden.Error()+": admin is required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// RejectBanned - rejects with 403 requests of users banned by admin.
+func RejectBanned(b auth.BanChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			uid, ok := UserID(request.Context())
+			if !ok {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			banned, err := b.IsBanned(request.Context(), uid)
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if banned {
+				http.Error(writer, utils.ErrUserBanned.Error(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// unauthorized - responds with 401 and WWW-Authenticate header describing error.
+func unauthorized(writer http.ResponseWriter, err error) {
+	description := utils.ErrInvalidToken.Error()
+	if errors.Is(err, utils.ErrTokenExpired) {
+		description = utils.ErrTokenExpired.Error()
+	}
+
+	writer.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+description+`"`)
+	http.Error(writer, description, http.StatusUnauthorized)
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+func TestBearer(t *testing.T) {
+	tokens := auth.NewTokens(auth.NewHMACSigner([]byte("secret")), time.Hour, 0)
+	readToken, _, err := tokens.Issue("token-user", []string{auth.ScopeRead}, 0)
+	require.NoError(t, err)
+
+	type want struct {
+		uid      string
+		method   string
+		code     int
+		isCookie bool
+	}
+	tests := []struct {
+		name   string
+		header string
+		scope  string
+		want   want
+	}{
+		{
+			name:   "User is identified by valid token",
+			header: "Bearer " + readToken,
+			scope:  auth.ScopeRead,
+			want:   want{code: http.StatusOK, uid: "token-user", method: AuthBearer},
+		},
+		{
+			name:   "Request without scope of token is forbidden",
+			header: "Bearer " + readToken,
+			scope:  auth.ScopeDelete,
+			want:   want{code: http.StatusForbidden},
+		},
+		{
+			name:   "Request with invalid token is rejected",
+			header: "Bearer " + readToken + "x",
+			scope:  auth.ScopeRead,
+			want:   want{code: http.StatusUnauthorized},
+		},
+		{
+			name:   "Request with other scheme is rejected",
+			header: "Basic dXNlcjpwYXNz",
+			scope:  auth.ScopeRead,
+			want:   want{code: http.StatusUnauthorized},
+		},
+		{
+			name:  "Request without token is identified by cookie with all scopes",
+			scope: auth.ScopeDelete,
+			want:  want{code: http.StatusOK, method: AuthCookie, isCookie: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var uid, method string
+			h := Bearer(tokens)(Cookie(zap.NewNop())(RequireScope(tt.scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				uid, _ = UserID(r.Context())
+				method = AuthMethod(r.Context())
+			}))))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want.code, rec.Code)
+			assert.Equal(t, tt.want.method, method)
+			assert.Equal(t, tt.want.isCookie, len(rec.Result().Cookies()) > 0, "cookie must be set only for cookie identity")
+			if tt.want.uid != "" {
+				assert.Equal(t, tt.want.uid, uid)
+			}
+			if tt.want.code == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	keys := auth.NewAPIKeys(auth.NewMemoryKeys(), 0, zap.NewNop())
+	readKey, _, err := keys.Create(context.Background(), "key-user", "ci", []string{auth.ScopeRead}, 1)
+	require.NoError(t, err)
+
+	type want struct {
+		uid        string
+		method     string
+		retryAfter string
+		code       int
+	}
+	tests := []struct {
+		name  string
+		key   string
+		scope string
+		want  want
+	}{
+		{
+			name:  "User is identified by valid key",
+			key:   readKey,
+			scope: auth.ScopeRead,
+			want:  want{code: http.StatusOK, uid: "key-user", method: AuthAPIKey},
+		},
+		{
+			name:  "Request over rate limit of key is rejected",
+			key:   readKey,
+			scope: auth.ScopeRead,
+			want:  want{code: http.StatusTooManyRequests, retryAfter: "60"},
+		},
+		{
+			name:  "Request with unknown key is rejected",
+			key:   "usk_unknown",
+			scope: auth.ScopeRead,
+			want:  want{code: http.StatusUnauthorized},
+		},
+		{
+			name:  "Request without key is identified by cookie",
+			scope: auth.ScopeDelete,
+			want:  want{code: http.StatusOK, method: AuthCookie},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var uid, method string
+			h := APIKey(keys)(Cookie(zap.NewNop())(RequireScope(tt.scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				uid, _ = UserID(r.Context())
+				method = AuthMethod(r.Context())
+			}))))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want.code, rec.Code)
+			assert.Equal(t, tt.want.method, method)
+			assert.Equal(t, tt.want.retryAfter, rec.Header().Get("Retry-After"))
+			if tt.want.uid != "" {
+				assert.Equal(t, tt.want.uid, uid)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	cfg := config.NewConfig(config.WithTrustedSubnet("10.0.0.0/8"))
+	defer func() { cfg.TrustedSubnet = "" }()
+
+	tests := []struct {
+		name     string
+		remote   string
+		realIP   string
+		scopes   []string
+		wantCode int
+	}{
+		{
+			name:     "Request with admin scope is passed",
+			scopes:   auth.RoleScopes([]string{auth.RoleAdmin}),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request from trusted subnet is passed",
+			remote:   "10.1.2.3:4321",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request with X-Real-IP from trusted subnet but not from trusted proxy is rejected",
+			realIP:   "10.1.2.3",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Request with scopes of user is rejected",
+			scopes:   auth.RoleScopes(nil),
+			remote:   "192.168.1.1:4321",
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(WithScopes(req.Context(), tt.scopes))
+			req.Header.Set("X-Real-IP", tt.realIP)
+			if tt.remote != "" {
+				req.RemoteAddr = tt.remote
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
+
+type banCheckerMock map[string]bool
+
+func (b banCheckerMock) IsBanned(ctx context.Context, uid string) (bool, error) {
+	return b[uid], nil
+}
+
+func TestRejectBanned(t *testing.T) {
+	h := RejectBanned(banCheckerMock{"banned": true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for uid, wantCode := range map[string]int{"banned": http.StatusForbidden, "user": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req = req.WithContext(WithUserID(req.Context(), uid))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, wantCode, rec.Code, uid)
+	}
+}
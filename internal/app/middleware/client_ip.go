@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+// RequestClientIP - returns IP of client made request, see ResolveClientIP.
+func RequestClientIP(request *http.Request) string {
+	return ResolveClientIP(request.RemoteAddr, request.Header.Get("X-Forwarded-For"), request.Header.Get("X-Real-IP"))
+}
+
+// ResolveClientIP - returns IP of client from remote address of connection. Headers set by proxies are used only
+// if connection comes from one of TRUSTED_PROXIES, so clients can't choose their IP themselves. Then
+// forwardedFor, value of X-Forwarded-For, is walked from the right skipping trusted proxies and first address
+// which is not a trusted proxy is client IP. realIP, value of X-Real-IP, is used if forwardedFor is empty.
+func ResolveClientIP(remoteAddr string, forwardedFor string, realIP string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	proxies, _ := ParseCIDRs(config.TrustedProxies())
+	if !containsIP(proxies, ip) {
+		return ip.String()
+	}
+
+	if forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		var client net.IP
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				break
+			}
+			client = hop
+			if !containsIP(proxies, hop) {
+				break
+			}
+		}
+		if client != nil {
+			return client.String()
+		}
+	}
+
+	if client := net.ParseIP(strings.TrimSpace(realIP)); client != nil {
+		return client.String()
+	}
+
+	return ip.String()
+}
+
+// ParseCIDRs - parses IPv4 and IPv6 CIDRs. Address without prefix length is treated as network of single host.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "CIDR address", Text: cidr}
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, subnet)
+	}
+
+	return nets, nil
+}
+
+// containsIP - reports whether ip belongs to one of nets.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, subnet := range nets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
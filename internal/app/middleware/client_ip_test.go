@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	config.NewConfig(config.WithTrustedProxies("10.0.0.0/8, fd00::/8"))
+	defer config.NewConfig(config.WithTrustedProxies(""))
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		want         string
+	}{
+		{
+			name:       "Client IP is remote address of connection",
+			remoteAddr: "192.0.2.1:1234",
+			want:       "192.0.2.1",
+		},
+		{
+			name:         "Headers of untrusted peer are ignored",
+			remoteAddr:   "192.0.2.1:1234",
+			forwardedFor: "198.51.100.7",
+			realIP:       "198.51.100.8",
+			want:         "192.0.2.1",
+		},
+		{
+			name:         "Last untrusted address of X-Forwarded-For is client IP",
+			remoteAddr:   "10.1.2.3:1234",
+			forwardedFor: "203.0.113.9, 198.51.100.7, 10.0.0.5",
+			realIP:       "198.51.100.8",
+			want:         "198.51.100.7",
+		},
+		{
+			name:         "First address of X-Forwarded-For is client IP if all hops are trusted proxies",
+			remoteAddr:   "10.1.2.3:1234",
+			forwardedFor: "10.0.0.7,10.0.0.5",
+			want:         "10.0.0.7",
+		},
+		{
+			name:       "X-Real-IP of trusted proxy is client IP without X-Forwarded-For",
+			remoteAddr: "10.1.2.3:1234",
+			realIP:     "198.51.100.8",
+			want:       "198.51.100.8",
+		},
+		{
+			name:         "Malformed headers of trusted proxy are ignored",
+			remoteAddr:   "10.1.2.3:1234",
+			forwardedFor: "unknown",
+			realIP:       "unknown",
+			want:         "10.1.2.3",
+		},
+		{
+			name:         "IPv6 trusted proxy is supported",
+			remoteAddr:   "[fd00::1]:1234",
+			forwardedFor: "2001:db8::7",
+			want:         "2001:db8::7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResolveClientIP(tt.remoteAddr, tt.forwardedFor, tt.realIP))
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1", "::1"})
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(nets))
+	for _, n := range nets {
+		got = append(got, n.String())
+	}
+	assert.Equal(t, []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1/32", "::1/128"}, got)
+
+	_, err = ParseCIDRs([]string{"10.0.0.0/8", "wrong"})
+	assert.Error(t, err)
+}
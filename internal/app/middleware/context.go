@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+)
+
+// ctxKey - type of context keys set by middlewares, so they never collide with keys of other packages.
+type ctxKey int
+
+const (
+	userIDKey     ctxKey = iota // ID of user making request
+	scopesKey                   // scopes granted to request
+	authMethodKey               // method which identified user
+	rolesKey                    // roles of user
+	workspaceKey                // membership of user in workspace which links are accessed
+	apiKeyIDKey                 // ID of API key which identified user
+)
+
+// WithUserID - returns copy of ctx carrying ID of user making request.
+func WithUserID(ctx context.Context, uid string) context.Context {
+	return context.WithValue(ctx, userIDKey, uid)
+}
+
+// UserID - returns ID of user making request stored in ctx by Cookie, Session, Bearer or APIKey middleware or WithUserID.
+func UserID(ctx context.Context) (string, bool) {
+	uid, ok := ctx.Value(userIDKey).(string)
+	return uid, ok && uid != ""
+}
+
+// WithScopes - returns copy of ctx carrying scopes granted to request.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// Scopes - returns scopes granted to request.
+func Scopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopesKey).([]string)
+	return scopes
+}
+
+// WithAuthMethod - returns copy of ctx carrying method which identified user.
+func WithAuthMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, authMethodKey, method)
+}
+
+// AuthMethod - returns method which identified user, AuthCookie, AuthSession, AuthBearer or AuthAPIKey.
+func AuthMethod(ctx context.Context) string {
+	method, _ := ctx.Value(authMethodKey).(string)
+	return method
+}
+
+// WithRoles - returns copy of ctx carrying roles of user.
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+// Roles - returns roles of user, roles are granted only to accounts logged in with identity provider.
+func Roles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return roles
+}
+
+// WithWorkspaceMember - returns copy of ctx carrying membership of user in workspace which links are accessed.
+func WithWorkspaceMember(ctx context.Context, m auth.WorkspaceMember) context.Context {
+	return context.WithValue(ctx, workspaceKey, m)
+}
+
+// WorkspaceMember - returns membership of user in workspace stored in ctx by Workspace middleware.
+func WorkspaceMember(ctx context.Context) (auth.WorkspaceMember, bool) {
+	m, ok := ctx.Value(workspaceKey).(auth.WorkspaceMember)
+	return m, ok
+}
+
+// WithAPIKeyID - returns copy of ctx carrying ID of API key which identified user.
+func WithAPIKeyID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, apiKeyIDKey, id)
+}
+
+// APIKeyID - returns ID of API key stored in ctx by APIKey middleware.
+func APIKeyID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(apiKeyIDKey).(string)
+	return id, ok && id != ""
+}
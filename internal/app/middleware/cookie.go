@@ -2,78 +2,144 @@ package middleware
 
 import (
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
-type cookieUUID struct {
-	name string
-	uuid string
-	mu   sync.Mutex
-}
-
-var cookie cookieUUID
-
-func init() {
-	cookie = *New()
+// cookieSeparator - separates user ID and time of issuing in payload of uid cookie.
+const cookieSeparator = "|"
+
+// Cookie - uid cookie middleware that reads and decodes uid cookie, if there is no cookie, it generates ID
+// of a new user. Name, domain, lifetime, SameSite and Secure of cookie are taken from config.
+// Cookie is set for new users and, if sliding expiry is enabled, re-issued when half of its lifetime has passed.
+// Cookies which can't be decoded are rejected with 401, removed and logged, so tampering never silently
+// replaces identity of user. User ID with all scopes is passed to next handler in request context, so it can be
+// read via UserID. Requests already identified by previous middleware are passed as is.
+func Cookie(l *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if _, ok := UserID(request.Context()); ok {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			uid, issuedAt, err := readCookie(request)
+			if err != nil {
+				l.Warn("audit: tampered uid cookie rejected",
+					zap.String("remote_addr", request.RemoteAddr),
+					zap.String("method", request.Method),
+					zap.String("path", request.URL.Path),
+					zap.String("user_agent", request.UserAgent()),
+					zap.Error(err))
+
+				http.SetCookie(writer, userCookie("", -1))
+				http.Error(writer, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			if uid == "" || (config.CookieSliding() && time.Since(issuedAt) >= config.CookieTTL()/2) {
+				if uid == "" {
+					uid = uuid.New().String()
+				}
+				if errSet := SetUserCookie(writer, uid); errSet != nil {
+					http.Error(writer, errSet.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+
+			ctx := WithUserID(request.Context(), uid)
+			ctx = WithScopes(ctx, auth.AllScopes)
+			ctx = WithAuthMethod(ctx, AuthCookie)
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
 }
 
-func New() *cookieUUID {
-	return &cookieUUID{name: "uid"}
-}
+// SetUserCookie - sets uid cookie carrying encrypted user ID and time of issuing. Cookie already set for response
+// is replaced, so handlers can switch device to another user ID.
+func SetUserCookie(writer http.ResponseWriter, uid string) error {
+	sha, err := utils.Encode(uid + cookieSeparator + strconv.FormatInt(time.Now().Unix(), 10))
+	if err != nil {
+		return err
+	}
 
-// Cookie - uuid cookie middleware that attempts to read uid cookie and set it cookieUUID
-// if no cookie was read, it creates a new one and stores it in cookieUUID.
-// finally it adds uid cookie to http.ResponseWriter
-func Cookie(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		sha, err := setCookie(writer, request)
-		if err != nil {
-			http.Error(writer, err.Error(), http.StatusInternalServerError)
-			return
+	header := writer.Header()
+	cookies := header.Values("Set-Cookie")
+	header.Del("Set-Cookie")
+	for _, c := range cookies {
+		if !strings.HasPrefix(c, config.CookieName()+"=") {
+			header.Add("Set-Cookie", c)
 		}
+	}
 
-		cookie.uuid = sha
-
-		http.SetCookie(writer, &http.Cookie{
-			Name:   cookie.name,
-			Value:  cookie.uuid,
-			Path:   "/",
-			Secure: false,
-			MaxAge: 300000,
-		})
+	http.SetCookie(writer, userCookie(sha, int(config.CookieTTL().Seconds())))
 
-		next.ServeHTTP(writer, request)
-	})
+	return nil
 }
 
-// setCookie - attempts to read cookie from http.Request and generate a new one if could not.
-func setCookie(writer http.ResponseWriter, request *http.Request) (string, error) {
-	defer cookie.mu.Unlock()
-	cookie.mu.Lock()
+// DecodeUserCookie - decrypts value of uid cookie and returns user ID with time of issuing. Returns
+// utils.ErrTamperedCookie if value can't be decoded or has no time of issuing, so values encrypted by the same
+// keyring for other purposes, like CSRF token, are not accepted as uid cookie.
+func DecodeUserCookie(value string) (string, time.Time, error) {
+	var payload string
+	if err := utils.Decode(value, &payload); err != nil {
+		return "", time.Time{}, utils.ErrTamperedCookie
+	}
 
-	cookie.uuid = uuid.New().String()
+	uid, issued, found := strings.Cut(payload, cookieSeparator)
+	if uid == "" || !found {
+		return "", time.Time{}, utils.ErrTamperedCookie
+	}
 
-	if cookieUserID, err := request.Cookie(cookie.name); err == nil {
-		utils.Decode(cookieUserID.Value, &cookie.uuid)
+	unix, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return "", time.Time{}, utils.ErrTamperedCookie
 	}
 
-	sha, err := utils.Encode(cookie.uuid)
+	return uid, time.Unix(unix, 0), nil
+}
+
+// readCookie - reads user ID and time of issuing from uid cookie of http.Request. Returns empty user ID
+// if there is no cookie.
+func readCookie(request *http.Request) (string, time.Time, error) {
+	c, err := request.Cookie(config.CookieName())
 	if err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
-		return "", err
+		return "", time.Time{}, nil
 	}
 
-	return sha, nil
+	return DecodeUserCookie(c.Value)
 }
 
-// GetUUID - gets uuid from cookieUUID.
-func GetUUID() string {
-	defer cookie.mu.Unlock()
-	cookie.mu.Lock()
+// userCookie - creates uid cookie with attributes from config.
+func userCookie(value string, maxAge int) *http.Cookie {
+	return configuredCookie(config.CookieName(), value, maxAge)
+}
 
-	return cookie.uuid
+// SessionCookie - creates session cookie with the same domain, SameSite and Secure as uid cookie. Negative maxAge
+// removes cookie.
+func SessionCookie(value string, maxAge int) *http.Cookie {
+	return configuredCookie(SessionCookieName, value, maxAge)
+}
+
+// configuredCookie - creates cookie with attributes from config.
+func configuredCookie(name string, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   config.CookieDomain(),
+		MaxAge:   maxAge,
+		Secure:   config.CookieSecure(),
+		HttpOnly: true,
+		SameSite: config.CookieSameSite(),
+	}
 }
@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestUserID(t *testing.T) {
+	tests := []struct {
+		ctx    context.Context
+		name   string
+		wantID string
+		wantOK bool
+	}{
+		{
+			name:   "User ID can be read from context",
+			ctx:    WithUserID(context.Background(), "1"),
+			wantID: "1",
+			wantOK: true,
+		},
+		{
+			name: "Context without user ID is reported",
+			ctx:  context.Background(),
+		},
+		{
+			name: "Empty user ID is reported as missing",
+			ctx:  WithUserID(context.Background(), ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uid, ok := UserID(tt.ctx)
+			assert.Equal(t, tt.wantID, uid)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestCookie(t *testing.T) {
+	legacy, err := utils.Encode("known-user")
+	require.NoError(t, err)
+	fresh, err := utils.Encode("known-user|" + strconv.FormatInt(time.Now().Unix(), 10))
+	require.NoError(t, err)
+	old, err := utils.Encode("known-user|" + strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	require.NoError(t, err)
+	badTime, err := utils.Encode("known-user|yesterday")
+	require.NoError(t, err)
+	csrfToken, err := IssueCSRFToken(httptest.NewRecorder(), "known-user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		cookie     *http.Cookie
+		name       string
+		wantID     string
+		isSliding  bool
+		wantCode   int
+		wantCookie bool
+		wantAudit  bool
+	}{
+		{
+			name:      "Cookie without time of issuing is rejected",
+			cookie:    &http.Cookie{Name: "uid", Value: legacy},
+			isSliding: true,
+			wantCode:  http.StatusUnauthorized,
+			wantAudit: true,
+		},
+		{
+			name:      "CSRF token is not accepted as cookie",
+			cookie:    &http.Cookie{Name: "uid", Value: csrfToken},
+			isSliding: true,
+			wantCode:  http.StatusUnauthorized,
+			wantAudit: true,
+		},
+		{
+			name:      "Fresh cookie is not re-issued",
+			cookie:    &http.Cookie{Name: "uid", Value: fresh},
+			wantID:    "known-user",
+			isSliding: true,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:       "Cookie is re-issued when half of its lifetime has passed",
+			cookie:     &http.Cookie{Name: "uid", Value: old},
+			wantID:     "known-user",
+			isSliding:  true,
+			wantCode:   http.StatusOK,
+			wantCookie: true,
+		},
+		{
+			name:     "Cookie is not re-issued without sliding expiry",
+			cookie:   &http.Cookie{Name: "uid", Value: old},
+			wantID:   "known-user",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Tampered cookie is rejected",
+			cookie:    &http.Cookie{Name: "uid", Value: "tampered"},
+			isSliding: true,
+			wantCode:  http.StatusUnauthorized,
+			wantAudit: true,
+		},
+		{
+			name:      "Cookie with malformed time of issuing is rejected",
+			cookie:    &http.Cookie{Name: "uid", Value: badTime},
+			isSliding: true,
+			wantCode:  http.StatusUnauthorized,
+			wantAudit: true,
+		},
+		{
+			name:       "New user ID is generated without cookie",
+			isSliding:  true,
+			wantCode:   http.StatusOK,
+			wantCookie: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(config.WithCookieTTL(90*time.Minute), config.WithCookieSliding(tt.isSliding))
+			defer config.NewConfig(config.WithCookieTTL(720*time.Hour), config.WithCookieSliding(true))
+
+			core, logs := observer.New(zap.WarnLevel)
+			var got string
+			h := Cookie(zap.New(core))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = UserID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantAudit, logs.FilterMessageSnippet("audit").Len() == 1)
+
+			cookies := rec.Result().Cookies()
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, got, "request with tampered cookie must not reach handler")
+				require.Len(t, cookies, 1)
+				assert.Negative(t, cookies[0].MaxAge, "tampered cookie must be removed")
+				return
+			}
+
+			require.NotEmpty(t, got)
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, got)
+			}
+
+			if !tt.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+
+			require.Len(t, cookies, 1)
+			assert.True(t, cookies[0].HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+			assert.Equal(t, int((90 * time.Minute).Seconds()), cookies[0].MaxAge)
+
+			fromCookie, issuedAt, err := DecodeUserCookie(cookies[0].Value)
+			require.NoError(t, err)
+			assert.Equal(t, got, fromCookie)
+			assert.WithinDuration(t, time.Now(), issuedAt, time.Minute)
+		})
+	}
+}
+
+func TestCookieAttributes(t *testing.T) {
+	tests := []struct {
+		opts       []config.OptionConfig
+		name       string
+		wantName   string
+		wantSecure bool
+	}{
+		{
+			name:     "Cookie is not secure without HTTPS",
+			wantName: "uid",
+		},
+		{
+			name:       "Cookie is secure when HTTPS is enabled",
+			opts:       []config.OptionConfig{config.WithEnableHTTPS(true)},
+			wantName:   "uid",
+			wantSecure: true,
+		},
+		{
+			name:     "Secure can be disabled explicitly",
+			opts:     []config.OptionConfig{config.WithEnableHTTPS(true), config.WithCookieSecure("false")},
+			wantName: "uid",
+		},
+		{
+			name:     "Name of cookie can be configured",
+			opts:     []config.OptionConfig{config.WithCookieName("sid")},
+			wantName: "sid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(tt.opts...)
+			defer config.NewConfig(config.WithEnableHTTPS(false), config.WithCookieSecure(""), config.WithCookieName("uid"))
+
+			rec := httptest.NewRecorder()
+			require.NoError(t, SetUserCookie(rec, "user"))
+
+			cookies := rec.Result().Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, tt.wantName, cookies[0].Name)
+			assert.Equal(t, tt.wantSecure, cookies[0].Secure)
+		})
+	}
+}
+
+func TestSessionCookie(t *testing.T) {
+	config.NewConfig(config.WithCookieSecure("true"))
+	defer config.NewConfig(config.WithCookieSecure(""))
+
+	c := SessionCookie("token", -1)
+	assert.Equal(t, SessionCookieName, c.Name)
+	assert.True(t, c.Secure, "session cookie must follow COOKIE_SECURE like uid cookie")
+	assert.Equal(t, config.CookieSameSite(), c.SameSite)
+	assert.Equal(t, -1, c.MaxAge)
+}
+
+// TestCookieConcurrentUsers - concurrent requests of different users must never see identity of each other.
+// Meant to be run with -race.
+func TestCookieConcurrentUsers(t *testing.T) {
+	ts := httptest.NewServer(Cookie(zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := UserID(r.Context())
+		fmt.Fprint(w, uid)
+	})))
+	defer ts.Close()
+
+	const (
+		users    = 20
+		requests = 25
+	)
+
+	var wg sync.WaitGroup
+	for u := 0; u < users; u++ {
+		uid := fmt.Sprintf("user-%d", u)
+		sha, err := utils.Encode(uid + cookieSeparator + strconv.FormatInt(time.Now().Unix(), 10))
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < requests; i++ {
+				req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+				if !assert.NoError(t, err) {
+					return
+				}
+				req.AddCookie(&http.Cookie{Name: "uid", Value: sha})
+
+				resp, err := http.DefaultClient.Do(req)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				assert.NoError(t, err)
+				assert.Equal(t, uid, string(body))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Modes of CSRF protection.
+const (
+	CSRFOff    = "off"    // requests are not checked
+	CSRFOrigin = "origin" // Origin or Referer of request must be trusted
+	CSRFToken  = "token"  // X-CSRF-Token header must match csrf_token cookie issued for user
+	CSRFStrict = "strict" // both Origin and token are checked
+)
+
+// CSRFCookieName - name of cookie with CSRF token, readable by scripts of site.
+const CSRFCookieName = "csrf_token"
+
+// CSRFHeader - header carrying CSRF token copied from cookie.
+const CSRFHeader = "X-CSRF-Token"
+
+// csrfPurpose - prefix of payload of CSRF token, so token can't be used as uid cookie.
+const csrfPurpose = "csrf" + cookieSeparator
+
+// CSRF - protects state-changing requests of users identified by cookies from cross-site request forgery.
+// Safe methods and requests authenticated by bearer token or API key are not checked, because browser never
+// attaches them automatically. In origin mode Origin, or Referer if Origin is missing, must be own host,
+// BASE_URL or one of CSRF_TRUSTED_ORIGINS, or CORS_ALLOWED_ORIGINS if CORS requests may carry cookies, requests
+// without both headers are made by non-browser clients and are passed. In token mode CSRF token issued by IssueCSRFToken must be sent both in cookie and header.
+// Unknown mode is treated as strict. Rejected requests get 403.
+func CSRF(mode string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if mode == CSRFOff || isSafeMethod(request.Method) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+			if method := AuthMethod(request.Context()); method == AuthBearer || method == AuthAPIKey {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			if mode != CSRFToken && !trustedOrigin(request) {
+				http.Error(writer, utils.ErrCSRFOrigin.Error(), http.StatusForbidden)
+				return
+			}
+			if mode != CSRFOrigin && !validCSRFToken(request) {
+				http.Error(writer, utils.ErrCSRFToken.Error(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// IssueCSRFToken - sets csrf_token cookie with token bound to user ID and returns token, so it can be sent
+// in X-CSRF-Token header. Token is encrypted by keyring of uid cookie, so it can't be forged or reused by
+// another user. Payload of token is prefixed by its purpose, so token readable by scripts is never accepted
+// as uid cookie.
+func IssueCSRFToken(writer http.ResponseWriter, uid string) (string, error) {
+	token, err := utils.Encode(csrfPurpose + uid)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(writer, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Domain:   config.CookieDomain(),
+		MaxAge:   int(config.CookieTTL().Seconds()),
+		Secure:   config.CookieSecure(),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return token, nil
+}
+
+// isSafeMethod - reports whether method can't change state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// trustedOrigin - reports whether Origin or Referer of request is trusted. Request without both headers is trusted.
+func trustedOrigin(request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		referer := request.Header.Get("Referer")
+		if referer == "" {
+			return true
+		}
+		origin = referer
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, request.Host) {
+		return true
+	}
+
+	origin = u.Scheme + "://" + u.Host
+	if config.CORSAllowCredentials() && corsTrusted(origin) {
+		return true
+	}
+	for _, trusted := range append(config.CSRFTrustedOrigins(), config.BaseURL()) {
+		if t, errParse := url.Parse(trusted); errParse == nil && strings.EqualFold(t.Scheme+"://"+t.Host, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// corsTrusted - reports whether origin is explicitly allowed by CORS_ALLOWED_ORIGINS. Wildcard "*" is not
+// trusted, because it would disable origin check for any site.
+func corsTrusted(origin string) bool {
+	return HeadersPolicy{AllowedOrigins: config.CORSAllowedOrigins()}.allowsOriginExplicitly(origin)
+}
+
+// validCSRFToken - reports whether X-CSRF-Token header matches csrf_token cookie and token was issued for user
+// of request.
+func validCSRFToken(request *http.Request) bool {
+	header := request.Header.Get(CSRFHeader)
+	c, err := request.Cookie(CSRFCookieName)
+	if header == "" || err != nil || subtle.ConstantTimeCompare([]byte(header), []byte(c.Value)) != 1 {
+		return false
+	}
+
+	var payload string
+	if utils.Decode(header, &payload) != nil {
+		return false
+	}
+	uid, ok := UserID(request.Context())
+
+	return ok && strings.HasPrefix(payload, csrfPurpose) && strings.TrimPrefix(payload, csrfPurpose) == uid
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestCSRF(t *testing.T) {
+	config.NewConfig(config.WithCSRFTrustedOrigins("https://app.example.com, https://admin.example.com/"))
+	defer config.NewConfig(config.WithCSRFTrustedOrigins(""))
+	config.NewConfig(config.WithCORSAllowedOrigins("*, https://*.spa.example.net"), config.WithCORSAllowCredentials(true))
+	defer config.NewConfig(config.WithCORSAllowedOrigins(""), config.WithCORSAllowCredentials(false))
+
+	token, err := IssueCSRFToken(httptest.NewRecorder(), "user")
+	require.NoError(t, err)
+	otherToken, err := IssueCSRFToken(httptest.NewRecorder(), "other")
+	require.NoError(t, err)
+	withoutPurpose, err := utils.Encode("user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		headers    map[string]string
+		cookie     string
+		name       string
+		mode       string
+		method     string
+		authMethod string
+		wantCode   int
+	}{
+		{
+			name:     "Safe method is not checked",
+			mode:     CSRFStrict,
+			method:   http.MethodGet,
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request from another site is rejected",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Request with opaque origin is rejected",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Origin": "null"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Request from another site is checked by Referer without Origin",
+			mode:     CSRFOrigin,
+			method:   http.MethodDelete,
+			headers:  map[string]string{"Referer": "https://evil.example.com/page"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Request from own host is passed",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Origin": "http://example.com"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request from trusted origin is passed",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Referer": "https://admin.example.com/links"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request from base URL is passed",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Origin": "http://localhost:8080"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request from origin allowed by CORS with credentials is passed",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Origin": "https://eu.spa.example.net"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request without Origin and Referer is passed in origin mode",
+			mode:     CSRFOrigin,
+			method:   http.MethodPost,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Request without token is rejected in token mode",
+			mode:     CSRFToken,
+			method:   http.MethodPost,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Request with matching token is passed",
+			mode:     CSRFToken,
+			method:   http.MethodPost,
+			headers:  map[string]string{CSRFHeader: token},
+			cookie:   token,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Token must be sent in cookie as well",
+			mode:     CSRFToken,
+			method:   http.MethodPost,
+			headers:  map[string]string{CSRFHeader: token},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Token issued for another user is rejected",
+			mode:     CSRFToken,
+			method:   http.MethodPost,
+			headers:  map[string]string{CSRFHeader: otherToken},
+			cookie:   otherToken,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Value encrypted for user without purpose of CSRF token is rejected",
+			mode:     CSRFToken,
+			method:   http.MethodPost,
+			headers:  map[string]string{CSRFHeader: withoutPurpose},
+			cookie:   withoutPurpose,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Forged token is rejected",
+			mode:     CSRFToken,
+			method:   http.MethodPost,
+			headers:  map[string]string{CSRFHeader: "forged"},
+			cookie:   "forged",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Valid token from another site is rejected in strict mode",
+			mode:     CSRFStrict,
+			method:   http.MethodPost,
+			headers:  map[string]string{CSRFHeader: token, "Origin": "https://evil.example.com"},
+			cookie:   token,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:       "Request authenticated by bearer token is exempt",
+			mode:       CSRFStrict,
+			method:     http.MethodPost,
+			headers:    map[string]string{"Origin": "https://evil.example.com"},
+			authMethod: AuthBearer,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "Request authenticated by API key is exempt",
+			mode:       CSRFStrict,
+			method:     http.MethodDelete,
+			authMethod: AuthAPIKey,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "Request authenticated by session is checked",
+			mode:       CSRFOrigin,
+			method:     http.MethodPost,
+			headers:    map[string]string{"Origin": "https://evil.example.com"},
+			authMethod: AuthSession,
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:     "Nothing is checked when protection is off",
+			mode:     CSRFOff,
+			method:   http.MethodPost,
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authMethod := tt.authMethod
+			if authMethod == "" {
+				authMethod = AuthCookie
+			}
+
+			h := CSRF(tt.mode)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(tt.method, "http://example.com/api/user/urls", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookie})
+			}
+			ctx := WithUserID(req.Context(), "user")
+			ctx = WithAuthMethod(ctx, authMethod)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req.WithContext(ctx))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
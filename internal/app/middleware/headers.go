@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+// HeadersPolicy - CORS rules and security headers applied to responses by Headers.
+type HeadersPolicy struct {
+	AllowedOrigins   []string      // origins allowed to call API from browser, "https://*.example.com" allows subdomains, "*" any origin
+	AllowedMethods   []string      // methods allowed in cross-origin requests
+	AllowedHeaders   []string      // request headers allowed in cross-origin requests
+	ExposedHeaders   []string      // response headers readable by scripts of allowed origins
+	AllowCredentials bool          // allow cookies in cross-origin requests
+	MaxAge           time.Duration // how long browser caches result of preflight request
+	HSTSMaxAge       time.Duration // max-age of Strict-Transport-Security, 0 disables header
+	CSP              string        // Content-Security-Policy, empty disables header
+	ReferrerPolicy   string        // Referrer-Policy, empty disables header
+}
+
+// HeadersPolicyFromConfig - creates HeadersPolicy from CORS_* and security headers settings of config.
+func HeadersPolicyFromConfig() HeadersPolicy {
+	return HeadersPolicy{
+		AllowedOrigins:   config.CORSAllowedOrigins(),
+		AllowedMethods:   config.CORSAllowedMethods(),
+		AllowedHeaders:   config.CORSAllowedHeaders(),
+		ExposedHeaders:   config.CORSExposedHeaders(),
+		AllowCredentials: config.CORSAllowCredentials(),
+		MaxAge:           config.CORSMaxAge(),
+		HSTSMaxAge:       config.HSTSMaxAge(),
+		CSP:              config.ContentSecurityPolicy(),
+		ReferrerPolicy:   config.ReferrerPolicy(),
+	}
+}
+
+// WithoutCORS - returns copy of policy which doesn't allow cross-origin requests, security headers are kept.
+func (p HeadersPolicy) WithoutCORS() HeadersPolicy {
+	p.AllowedOrigins = nil
+
+	return p
+}
+
+// Headers - CORS and security headers middleware. Policy of request is the override registered for the longest
+// path prefix matching request path, or default policy if there is no such override, so route groups like
+// admin API can have own rules for both preflight and actual requests. Preflight requests are answered with 204
+// and not passed to next handler, preflight from not allowed origin or with not allowed method or headers gets 403.
+// Actual requests from not allowed origins are passed without CORS headers, so browser hides response from script.
+func Headers(def HeadersPolicy, overrides map[string]HeadersPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			p := policyOf(request.URL.Path, def, overrides)
+			p.writeSecurityHeaders(writer)
+
+			origin := request.Header.Get("Origin")
+			if len(p.AllowedOrigins) > 0 {
+				writer.Header().Add("Vary", "Origin")
+			}
+
+			preflight := request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				writer.Header().Add("Vary", "Access-Control-Request-Method")
+				writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !p.AllowsOrigin(origin) {
+				if preflight {
+					writer.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			p.writeAllowOrigin(writer, origin)
+
+			if !preflight {
+				if len(p.ExposedHeaders) > 0 {
+					writer.Header().Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			method := request.Header.Get("Access-Control-Request-Method")
+			headers := splitHeaderList(request.Header.Get("Access-Control-Request-Headers"))
+			if !p.allowsMethod(method) || !p.allowsHeaders(headers) {
+				writer.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			writer.Header().Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+			if len(headers) > 0 {
+				writer.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			}
+			if p.MaxAge > 0 {
+				writer.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+			}
+			writer.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// AllowsOrigin - reports whether origin matches one of allowed origins. Wildcard "https://*.example.com" matches
+// subdomains of any depth with the same scheme, but not example.com itself.
+func (p HeadersPolicy) AllowsOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+
+		scheme, host, found := strings.Cut(allowed, "://")
+		if !found || !strings.EqualFold(scheme, u.Scheme) {
+			continue
+		}
+		if suffix := strings.TrimPrefix(host, "*"); suffix != host {
+			if len(u.Host) > len(suffix) && strings.HasSuffix(strings.ToLower(u.Host), strings.ToLower(suffix)) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(host, u.Host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowsOriginExplicitly - reports whether origin matches one of allowed origins other than "*".
+func (p HeadersPolicy) allowsOriginExplicitly(origin string) bool {
+	origins := make([]string, 0, len(p.AllowedOrigins))
+	for _, o := range p.AllowedOrigins {
+		if o != "*" {
+			origins = append(origins, o)
+		}
+	}
+
+	return HeadersPolicy{AllowedOrigins: origins}.AllowsOrigin(origin)
+}
+
+// writeSecurityHeaders - sets HSTS, CSP and Referrer-Policy headers of policy.
+func (p HeadersPolicy) writeSecurityHeaders(writer http.ResponseWriter) {
+	if p.HSTSMaxAge > 0 {
+		writer.Header().Set("Strict-Transport-Security",
+			"max-age="+strconv.Itoa(int(p.HSTSMaxAge.Seconds()))+"; includeSubDomains")
+	}
+	if p.CSP != "" {
+		writer.Header().Set("Content-Security-Policy", p.CSP)
+	}
+	if p.ReferrerPolicy != "" {
+		writer.Header().Set("Referrer-Policy", p.ReferrerPolicy)
+	}
+}
+
+// writeAllowOrigin - sets Access-Control-Allow-Origin and Access-Control-Allow-Credentials for allowed origin.
+// Origin is echoed when credentials are allowed, because browsers reject "*" in credentialed requests.
+// Credentials are allowed only for explicitly listed origins, origin matched by "*" gets "*" without them,
+// so any site can't make credentialed requests.
+func (p HeadersPolicy) writeAllowOrigin(writer http.ResponseWriter, origin string) {
+	if p.AllowCredentials && p.allowsOriginExplicitly(origin) {
+		writer.Header().Set("Access-Control-Allow-Origin", origin)
+		writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		return
+	}
+
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			writer.Header().Set("Access-Control-Allow-Origin", "*")
+			return
+		}
+	}
+	writer.Header().Set("Access-Control-Allow-Origin", origin)
+}
+
+// allowsMethod - reports whether method is allowed in cross-origin requests.
+func (p HeadersPolicy) allowsMethod(method string) bool {
+	for _, m := range p.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowsHeaders - reports whether all requested headers are allowed in cross-origin requests.
+func (p HeadersPolicy) allowsHeaders(headers []string) bool {
+	for _, h := range headers {
+		allowed := false
+		for _, a := range p.AllowedHeaders {
+			if strings.EqualFold(a, h) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// policyOf - returns policy registered for the longest path prefix matching path, or default policy.
+func policyOf(path string, def HeadersPolicy, overrides map[string]HeadersPolicy) HeadersPolicy {
+	best := ""
+	p := def
+	for prefix, override := range overrides {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if (path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) >= len(best) {
+			best, p = prefix, override
+		}
+	}
+
+	return p
+}
+
+// splitHeaderList - splits comma separated value of Access-Control-Request-Headers.
+func splitHeaderList(value string) []string {
+	headers := make([]string, 0)
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, h)
+		}
+	}
+
+	return headers
+}
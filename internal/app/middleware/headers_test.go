@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaders(t *testing.T) {
+	def := HeadersPolicy{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{"Location"},
+		MaxAge:         10 * time.Minute,
+		HSTSMaxAge:     time.Hour,
+		CSP:            "default-src 'none'",
+		ReferrerPolicy: "no-referrer",
+	}
+	credentialed := def
+	credentialed.AllowCredentials = true
+	public := def
+	public.AllowedOrigins = []string{"*"}
+	credentialedPublic := credentialed
+	credentialedPublic.AllowedOrigins = []string{"*", "https://app.example.com"}
+	overrides := map[string]HeadersPolicy{
+		"/api/admin":         def.WithoutCORS(),
+		"/api/admin/open":    public,
+		"/api/cookies/":      credentialed,
+		"/api/cookies/open/": credentialedPublic,
+	}
+
+	tests := []struct {
+		headers     map[string]string
+		wantHeaders map[string]string
+		name        string
+		method      string
+		path        string
+		wantCode    int
+		wantNext    bool
+	}{
+		{
+			name:     "Security headers are set for same-origin request",
+			method:   http.MethodGet,
+			path:     "/abc",
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Strict-Transport-Security":   "max-age=3600; includeSubDomains",
+				"Content-Security-Policy":     "default-src 'none'",
+				"Referrer-Policy":             "no-referrer",
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:     "Request from allowed origin gets CORS headers",
+			method:   http.MethodPost,
+			path:     "/api/shorten",
+			headers:  map[string]string{"Origin": "https://app.example.com"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Expose-Headers":    "Location",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:     "Request from subdomain of wildcard origin gets CORS headers",
+			method:   http.MethodPost,
+			path:     "/api/shorten",
+			headers:  map[string]string{"Origin": "https://eu.app.example.org"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://eu.app.example.org",
+			},
+		},
+		{
+			name:     "Wildcard origin doesn't match parent domain and another scheme",
+			method:   http.MethodPost,
+			path:     "/api/shorten",
+			headers:  map[string]string{"Origin": "http://example.org"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:     "Request from not allowed origin is passed without CORS headers",
+			method:   http.MethodPost,
+			path:     "/api/shorten",
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "Preflight from allowed origin is answered",
+			method: http.MethodOptions,
+			path:   "/api/shorten",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "content-type",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "content-type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "Preflight with not allowed method is rejected",
+			method: http.MethodOptions,
+			path:   "/api/shorten",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": http.MethodDelete,
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "Preflight with not allowed header is rejected",
+			method: http.MethodOptions,
+			path:   "/api/shorten",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "X-Secret",
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "Preflight from not allowed origin is rejected",
+			method: http.MethodOptions,
+			path:   "/api/shorten",
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": http.MethodPost,
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "OPTIONS request without preflight headers is passed",
+			method:   http.MethodOptions,
+			path:     "/api/shorten",
+			wantCode: http.StatusOK,
+			wantNext: true,
+		},
+		{
+			name:   "Route override disables CORS",
+			method: http.MethodOptions,
+			path:   "/api/admin/links",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": http.MethodPost,
+			},
+			wantCode: http.StatusForbidden,
+			wantHeaders: map[string]string{
+				"Content-Security-Policy": "default-src 'none'",
+			},
+		},
+		{
+			name:     "The longest matching route override is applied",
+			method:   http.MethodGet,
+			path:     "/api/admin/open",
+			headers:  map[string]string{"Origin": "https://any.example.net"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+			},
+		},
+		{
+			name:     "Route override is matched by whole path segments",
+			method:   http.MethodGet,
+			path:     "/api/administrators",
+			headers:  map[string]string{"Origin": "https://app.example.com"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://app.example.com",
+			},
+		},
+		{
+			name:     "Origin is echoed with credentials",
+			method:   http.MethodGet,
+			path:     "/api/cookies/urls",
+			headers:  map[string]string{"Origin": "https://app.example.com"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:     "Origin matched by wildcard gets no credentials",
+			method:   http.MethodGet,
+			path:     "/api/cookies/open/urls",
+			headers:  map[string]string{"Origin": "https://evil.example.net"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:     "Listed origin gets credentials next to wildcard",
+			method:   http.MethodGet,
+			path:     "/api/cookies/open/urls",
+			headers:  map[string]string{"Origin": "https://app.example.com"},
+			wantCode: http.StatusOK,
+			wantNext: true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := Headers(def, overrides)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(tt.method, "https://short.example.com"+tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantNext, called)
+			for k, v := range tt.wantHeaders {
+				assert.Equal(t, v, rec.Header().Get(k), k)
+			}
+		})
+	}
+}
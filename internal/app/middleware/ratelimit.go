@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// RateLimit - limits requests of route class by client. Client is API key, account of bearer token or session,
+// or IP for anonymous users, because they get new uid just by dropping cookie. Requests over limit are rejected
+// with 429 and Retry-After header.
+func RateLimit(l auth.RateLimiter, class string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			client := RateClient(request.Context(), RequestClientIP(request))
+			if ok, retryAfter := l.Allow(request.Context(), class, client); !ok {
+				writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(writer, utils.ErrRateLimited.Error(), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// RateClient - returns client of request identified in ctx for rate limiting, ip is used for anonymous users.
+func RateClient(ctx context.Context, ip string) string {
+	switch AuthMethod(ctx) {
+	case AuthAPIKey:
+		if id, ok := APIKeyID(ctx); ok {
+			return "key:" + id
+		}
+	case AuthBearer, AuthSession, AuthClientCert:
+		if uid, ok := UserID(ctx); ok {
+			return "user:" + uid
+		}
+	}
+
+	return "ip:" + ip
+}
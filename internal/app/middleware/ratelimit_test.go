@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+type limiterRecorder struct {
+	clients []string
+	allow   bool
+}
+
+func (l *limiterRecorder) Allow(ctx context.Context, class string, client string) (bool, time.Duration) {
+	l.clients = append(l.clients, class+":"+client)
+	return l.allow, 1500 * time.Millisecond
+}
+
+func TestRateLimit(t *testing.T) {
+	config.NewConfig(config.WithTrustedProxies("10.0.0.0/8"))
+	defer config.NewConfig(config.WithTrustedProxies(""))
+
+	tests := []struct {
+		ctx            func(ctx context.Context) context.Context
+		name           string
+		remoteAddr     string
+		realIP         string
+		wantClient     string
+		wantRetryAfter string
+		allow          bool
+		wantCode       int
+	}{
+		{
+			name:       "Anonymous user is limited by IP",
+			remoteAddr: "192.0.2.1:1234",
+			ctx: func(ctx context.Context) context.Context {
+				return WithAuthMethod(WithUserID(ctx, "user"), AuthCookie)
+			},
+			allow:      true,
+			wantClient: "shorten:ip:192.0.2.1",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "X-Real-IP of untrusted client is ignored",
+			remoteAddr: "192.0.2.1:1234",
+			realIP:     "198.51.100.7",
+			allow:      true,
+			wantClient: "shorten:ip:192.0.2.1",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "X-Real-IP of trusted proxy is client IP",
+			remoteAddr: "10.1.2.3:1234",
+			realIP:     "198.51.100.7",
+			allow:      true,
+			wantClient: "shorten:ip:198.51.100.7",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "User of bearer token is limited by ID",
+			remoteAddr: "192.0.2.1:1234",
+			ctx: func(ctx context.Context) context.Context {
+				return WithAuthMethod(WithUserID(ctx, "user"), AuthBearer)
+			},
+			allow:      true,
+			wantClient: "shorten:user:user",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "Request with API key is limited by key",
+			remoteAddr: "192.0.2.1:1234",
+			ctx: func(ctx context.Context) context.Context {
+				return WithAPIKeyID(WithAuthMethod(WithUserID(ctx, "user"), AuthAPIKey), "key")
+			},
+			allow:      true,
+			wantClient: "shorten:key:key",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:           "Request over limit is rejected",
+			remoteAddr:     "192.0.2.1:1234",
+			wantClient:     "shorten:ip:192.0.2.1",
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &limiterRecorder{allow: tt.allow}
+			h := RateLimit(l, "shorten")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if tt.ctx != nil {
+				req = req.WithContext(tt.ctx(req.Context()))
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, []string{tt.wantClient}, l.clients)
+			assert.Equal(t, tt.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
@@ -7,27 +7,23 @@ import (
 	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
 )
 
+// TrustedSubnet - rejects with 403 requests of clients whose IP doesn't belong to one of TRUSTED_SUBNET CIDRs.
+// Client IP is resolved by RequestClientIP, so X-Forwarded-For and X-Real-IP are taken into account only when
+// they are set by trusted proxy.
 func TrustedSubnet(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		if config.TrustedSubnet() == "" {
+		if len(config.TrustedSubnets()) == 0 {
 			http.Error(writer, "Trusted subnet is not defined. Forbidden.", http.StatusForbidden)
 			return
 		}
 
-		xRealIP := request.Header.Get("X-Real-IP")
-		if xRealIP == "" {
-			http.Error(writer, "X-Real-IP is not present.", http.StatusBadRequest)
-			return
-		}
-
-		ip := net.ParseIP(xRealIP)
-		_, subnet, err := net.ParseCIDR(config.TrustedSubnet())
+		trusted, err := InTrustedSubnet(RequestClientIP(request))
 		if err != nil {
 			http.Error(writer, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		if !subnet.Contains(ip) {
+		if !trusted {
 			http.Error(writer, "This IP address does not belongs to defined trusted subnet.", http.StatusForbidden)
 			return
 		}
@@ -35,3 +31,23 @@ func TrustedSubnet(next http.Handler) http.Handler {
 		next.ServeHTTP(writer, request)
 	})
 }
+
+// InTrustedSubnet - reports whether ip belongs to one of TRUSTED_SUBNET CIDRs. Returns error if some of them
+// can't be parsed.
+func InTrustedSubnet(ip string) (bool, error) {
+	subnets, err := ParseCIDRs(config.TrustedSubnets())
+	if err != nil {
+		return false, err
+	}
+
+	client := net.ParseIP(ip)
+
+	return client != nil && containsIP(subnets, client), nil
+}
+
+// fromTrustedSubnet - reports whether client of request belongs to configured trusted subnet.
+func fromTrustedSubnet(request *http.Request) bool {
+	trusted, err := InTrustedSubnet(RequestClientIP(request))
+
+	return err == nil && trusted
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+func TestTrustedSubnet(t *testing.T) {
+	defer config.NewConfig(config.WithTrustedSubnet(""), config.WithTrustedProxies(""))
+
+	tests := []struct {
+		name         string
+		subnet       string
+		remoteAddr   string
+		forwardedFor string
+		wantCode     int
+	}{
+		{
+			name:       "Request is rejected if trusted subnet is not defined",
+			remoteAddr: "10.1.2.3:1234",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "Request from one of trusted subnets is passed",
+			subnet:     "192.168.0.0/16,10.0.0.0/8",
+			remoteAddr: "10.1.2.3:1234",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "Request from IPv6 trusted subnet is passed",
+			subnet:     "10.0.0.0/8,fd00::/8",
+			remoteAddr: "[fd00::1]:1234",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:         "Spoofed X-Forwarded-For of untrusted client is rejected",
+			subnet:       "10.0.0.0/8",
+			remoteAddr:   "192.0.2.1:1234",
+			forwardedFor: "10.1.2.3",
+			wantCode:     http.StatusForbidden,
+		},
+		{
+			name:         "Client forwarded by trusted proxy is passed",
+			subnet:       "10.0.0.0/8",
+			remoteAddr:   "172.16.0.1:1234",
+			forwardedFor: "10.1.2.3",
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:       "Malformed trusted subnet is server error",
+			subnet:     "10.0.0.0/8,wrong",
+			remoteAddr: "10.1.2.3:1234",
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(config.WithTrustedSubnet(tt.subnet), config.WithTrustedProxies("172.16.0.0/12"))
+			h := TrustedSubnet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
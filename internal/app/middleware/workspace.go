@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// WorkspaceParam - query param with ID of workspace which links are accessed.
+const WorkspaceParam = "workspace"
+
+// Workspace - lets members of workspace from "workspace" query param access links of workspace. Reading requests
+// require viewer role and other requests require editor role. Workspace owns its links, so ID of workspace
+// is passed to next handler as user ID and membership of user is kept in context. Links are created by member, so
+// they are charged to quota of member rather than workspace. Requests of users which are not members of workspace
+// or have lower role are rejected with 403, requests without param are passed as is.
+func Workspace(a auth.WorkspaceAuthorizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			workspaceID := request.URL.Query().Get(WorkspaceParam)
+			if workspaceID == "" {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			uid, ok := UserID(request.Context())
+			if !ok {
+				http.Error(writer, utils.ErrUnknownUser.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			role := auth.WorkspaceEditor
+			if request.Method == http.MethodGet || request.Method == http.MethodHead {
+				role = auth.WorkspaceViewer
+			}
+
+			m, err := a.Authorize(request.Context(), workspaceID, uid, role)
+			if errors.Is(err, utils.ErrNotMember) {
+				http.Error(writer, err.Error(), http.StatusForbidden)
+				return
+			}
+			if errors.Is(err, utils.ErrForbidden) {
+				http.Error(writer, err.Error()+": workspace role "+role+" is required", http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			ctx := WithUserID(request.Context(), m.WorkspaceID)
+			ctx = WithWorkspaceMember(ctx, m)
+			ctx = storage.WithCreator(ctx, m.UID)
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,15 @@
+create table if not exists api_keys(
+    id uuid primary key,
+    uid text not null,
+    name text not null default '',
+    hint text not null,
+    hash text not null unique,
+    scopes text[] not null,
+    rate_limit int not null,
+    created_at timestamptz not null default NOW(),
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+
+create index if not exists api_keys_uid_idx
+on api_keys (uid, created_at);
\ No newline at end of file
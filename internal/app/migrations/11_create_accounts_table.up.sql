@@ -0,0 +1,16 @@
+create table if not exists accounts(
+    id uuid primary key,
+    login text not null unique,
+    password_hash text not null,
+    created_at timestamptz not null default NOW()
+);
+
+create table if not exists sessions(
+    hash text primary key,
+    uid uuid not null references accounts (id) on delete cascade,
+    created_at timestamptz not null default NOW(),
+    expires_at timestamptz not null
+);
+
+create index if not exists sessions_expires_at_idx
+on sessions (expires_at);
\ No newline at end of file
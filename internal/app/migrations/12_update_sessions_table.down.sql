@@ -0,0 +1,2 @@
+alter table sessions
+DROP COLUMN IF EXISTS roles
\ No newline at end of file
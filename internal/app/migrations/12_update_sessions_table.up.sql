@@ -0,0 +1,2 @@
+alter table sessions
+add column if not exists roles text[] not null default '{}';
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS banned_users;
+ALTER TABLE links DROP COLUMN IF EXISTS is_disabled
\ No newline at end of file
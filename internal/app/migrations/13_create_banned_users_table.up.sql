@@ -0,0 +1,8 @@
+alter table links
+add column if not exists is_disabled boolean not null default false;
+
+create table if not exists banned_users(
+    uid uuid primary key,
+    reason text not null default '',
+    banned_at timestamptz not null default NOW()
+)
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces
\ No newline at end of file
@@ -0,0 +1,15 @@
+create table if not exists workspaces(
+    id uuid primary key,
+    name text not null,
+    created_at timestamptz not null default NOW()
+);
+
+create table if not exists workspace_members(
+    workspace_id uuid not null references workspaces (id) on delete cascade,
+    uid uuid not null,
+    role text not null,
+    added_at timestamptz not null default NOW(),
+    primary key (workspace_id, uid)
+);
+
+create index if not exists workspace_members_uid_idx on workspace_members (uid)
\ No newline at end of file
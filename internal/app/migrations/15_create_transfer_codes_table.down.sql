@@ -0,0 +1 @@
+DROP TABLE IF EXISTS transfer_codes
\ No newline at end of file
@@ -0,0 +1,9 @@
+create table if not exists transfer_codes(
+    hash text primary key,
+    uid uuid not null,
+    created_at timestamptz not null default NOW(),
+    expires_at timestamptz not null
+);
+
+create index if not exists transfer_codes_expires_at_idx
+on transfer_codes (expires_at)
\ No newline at end of file
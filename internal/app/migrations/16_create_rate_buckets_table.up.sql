@@ -0,0 +1,9 @@
+create table if not exists rate_buckets(
+    key text primary key,
+    tokens double precision not null,
+    allowed boolean not null,
+    updated_at timestamptz not null default NOW()
+);
+
+create index if not exists rate_buckets_updated_at_idx
+on rate_buckets (updated_at)
\ No newline at end of file
@@ -0,0 +1,7 @@
+create table if not exists user_quotas(
+    uid uuid primary key,
+    max_links integer,
+    max_batch integer,
+    max_daily integer,
+    updated_at timestamptz not null default NOW()
+)
\ No newline at end of file
@@ -0,0 +1,4 @@
+drop index if exists links_created_by_created_at_idx;
+
+alter table links
+drop column if exists created_by;
\ No newline at end of file
@@ -0,0 +1,8 @@
+alter table links
+add created_by uuid;
+
+update links
+set created_by = uid;
+
+create index if not exists links_created_by_created_at_idx
+on links (created_by, created_at);
\ No newline at end of file
@@ -0,0 +1,4 @@
+drop index if exists links_deleted_at_idx;
+
+alter table links
+DROP COLUMN IF EXISTS deleted_at
\ No newline at end of file
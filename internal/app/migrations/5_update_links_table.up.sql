@@ -0,0 +1,10 @@
+alter table links
+add deleted_at timestamptz;
+
+update links
+set deleted_at = NOW()
+where is_deleted = true;
+
+create index if not exists links_deleted_at_idx
+on links (deleted_at)
+where is_deleted = true;
\ No newline at end of file
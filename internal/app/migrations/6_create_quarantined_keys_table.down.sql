@@ -0,0 +1 @@
+DROP TABLE IF EXISTS quarantined_keys
\ No newline at end of file
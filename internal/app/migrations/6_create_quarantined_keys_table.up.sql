@@ -0,0 +1,4 @@
+create table if not exists quarantined_keys(
+    url_hash varchar(10) primary key,
+    expires_at timestamptz not null
+)
\ No newline at end of file
@@ -0,0 +1,16 @@
+create table if not exists jobs(
+    id bigserial primary key,
+    kind text not null,
+    payload jsonb not null,
+    status text not null default 'pending',
+    attempts int not null default 0,
+    max_attempts int not null,
+    run_at timestamptz not null default NOW(),
+    locked_until timestamptz,
+    last_error text,
+    created_at timestamptz default NOW()
+);
+
+create index if not exists jobs_ready_idx
+on jobs (run_at)
+where status in ('pending', 'running');
\ No newline at end of file
@@ -0,0 +1 @@
+drop table if exists scheduled_tasks;
\ No newline at end of file
@@ -0,0 +1,7 @@
+create table if not exists scheduled_tasks(
+    name text primary key,
+    last_run_at timestamptz not null,
+    last_status text not null,
+    last_error text,
+    last_duration_ms bigint not null default 0
+);
\ No newline at end of file
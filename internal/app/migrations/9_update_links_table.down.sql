@@ -0,0 +1 @@
+drop index if exists links_uid_created_at_idx;
\ No newline at end of file
@@ -0,0 +1,2 @@
+create index if not exists links_uid_created_at_idx
+on links (uid, created_at, id);
\ No newline at end of file
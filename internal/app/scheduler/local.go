@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+// localLeader - lock held by the only Local backend allowed to lead in this process.
+var localLeader sync.Mutex
+
+var _ Backend = (*Local)(nil)
+
+// Local - Backend for memory and file mode, where only one process works with storage.
+// Leadership is a process-wide lock, last runs are kept in memory.
+type Local struct {
+	runs     map[string]Run
+	mu       sync.Mutex
+	isLeader bool
+}
+
+// NewLocal - creates Local backend.
+func NewLocal() *Local {
+	return &Local{runs: map[string]Run{}}
+}
+
+// TryLead - takes process-wide leader lock if it's free.
+func (l *Local) TryLead(ctx context.Context) (bool, error) {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+
+	if !l.isLeader {
+		l.isLeader = localLeader.TryLock()
+	}
+
+	return l.isLeader, nil
+}
+
+// Resign - releases process-wide leader lock.
+func (l *Local) Resign(ctx context.Context) error {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+
+	if l.isLeader {
+		l.isLeader = false
+		localLeader.Unlock()
+	}
+
+	return nil
+}
+
+// LastRun - returns last run of task.
+func (l *Local) LastRun(ctx context.Context, name string) (Run, error) {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+
+	return l.runs[name], nil
+}
+
+// SaveRun - stores last run of task.
+func (l *Local) SaveRun(ctx context.Context, r Run) error {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+
+	l.runs[r.Name] = r
+
+	return nil
+}
@@ -0,0 +1,244 @@
+// Package scheduler - runs periodic tasks once per interval across all replicas of the service.
+// Only the replica elected as leader runs tasks; last runs are kept in Backend shared between replicas,
+// so a new leader doesn't repeat a run already made in current interval. Tasks refreshing state kept in memory
+// of process run on every replica instead, their last runs are kept by replica itself.
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+// Statuses of task run.
+const (
+	StatusNever  = "never"
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Task - periodic task. Task runs once per Interval, intervals are aligned to Unix epoch,
+// so task with hourly interval runs at the beginning of each hour.
+type Task struct {
+	Run          func(ctx context.Context) error
+	Name         string
+	Interval     time.Duration
+	EveryReplica bool // task runs on every replica regardless of leadership, like refreshing of in-memory state
+}
+
+// Run - a representation of last run of task.
+type Run struct {
+	At       time.Time
+	Name     string
+	Status   string
+	Error    string
+	Duration time.Duration
+}
+
+// Status - a representation of task state exposed via internal routes.
+type Status struct {
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt    time.Time  `json:"next_run_at"`
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval"`
+	LastStatus   string     `json:"last_status"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	IsLeader     bool       `json:"is_leader"`
+}
+
+// Backend - elects leader and keeps last runs of tasks.
+type Backend interface {
+	// TryLead - tries to become leader without waiting. Returns true while this process is leader.
+	TryLead(ctx context.Context) (bool, error)
+	// Resign - gives up leadership.
+	Resign(ctx context.Context) error
+	// LastRun - returns last run of task. Zero Run is returned if task has never been run.
+	LastRun(ctx context.Context, name string) (Run, error)
+	// SaveRun - stores last run of task.
+	SaveRun(ctx context.Context, r Run) error
+}
+
+// Reporter - reports state of scheduled tasks.
+type Reporter interface {
+	Statuses(ctx context.Context) ([]Status, error)
+}
+
+var _ Reporter = (*Scheduler)(nil)
+
+// Scheduler - runs registered tasks when this process is leader.
+type Scheduler struct {
+	backend  Backend
+	logger   *zap.Logger
+	local    map[string]Run // last runs of tasks run on every replica
+	tasks    []Task
+	mu       sync.RWMutex
+	isLeader bool
+}
+
+// New - creates Scheduler. Tasks must be added before Run is called.
+func New(b Backend, l *zap.Logger) *Scheduler {
+	return &Scheduler{backend: b, logger: l, local: map[string]Run{}}
+}
+
+// Add - registers task.
+func (s *Scheduler) Add(t Task) {
+	s.tasks = append(s.tasks, t)
+}
+
+// Run - checks leadership and due tasks every config.SchedulerTick until ctx is done, then resigns.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.SchedulerTick())
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			resignCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.backend.Resign(resignCtx); err != nil {
+				s.logger.Error(err.Error(), zap.Error(err))
+			}
+			cancel()
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick - runs due tasks run on every replica and other due tasks if this process is leader.
+func (s *Scheduler) tick(ctx context.Context) {
+	isLeader, err := s.backend.TryLead(ctx)
+	if err != nil {
+		s.logger.Error(err.Error(), zap.Error(err))
+	}
+
+	s.mu.Lock()
+	if isLeader != s.isLeader {
+		s.logger.Info("scheduler leadership changed", zap.Bool("is_leader", isLeader))
+	}
+	s.isLeader = isLeader
+	s.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range s.tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		if !isLeader && !t.EveryReplica {
+			continue
+		}
+
+		last, errRun := s.lastRun(ctx, t)
+		if errRun != nil {
+			s.logger.Error(errRun.Error(), zap.String("task", t.Name), zap.Error(errRun))
+			continue
+		}
+
+		if !isDue(t, last, now) {
+			continue
+		}
+
+		s.run(ctx, t)
+	}
+}
+
+// run - runs task and saves result of its run.
+func (s *Scheduler) run(ctx context.Context, t Task) {
+	r := Run{Name: t.Name, At: time.Now(), Status: StatusOK}
+
+	if err := t.Run(ctx); err != nil {
+		r.Status, r.Error = StatusFailed, err.Error()
+		s.logger.Error("scheduled task failed", zap.String("task", t.Name), zap.Error(err))
+	}
+	r.Duration = time.Since(r.At)
+
+	if t.EveryReplica {
+		s.mu.Lock()
+		s.local[t.Name] = r
+		s.mu.Unlock()
+
+		return
+	}
+
+	if err := s.backend.SaveRun(ctx, r); err != nil {
+		s.logger.Error(err.Error(), zap.String("task", t.Name), zap.Error(err))
+	}
+}
+
+// lastRun - returns last run of task made by this replica for tasks run on every replica and one kept in Backend
+// otherwise.
+func (s *Scheduler) lastRun(ctx context.Context, t Task) (Run, error) {
+	if !t.EveryReplica {
+		return s.backend.LastRun(ctx, t.Name)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.local[t.Name], nil
+}
+
+// Statuses - returns state of registered tasks sorted by name.
+func (s *Scheduler) Statuses(ctx context.Context) ([]Status, error) {
+	s.mu.RLock()
+	isLeader := s.isLeader
+	s.mu.RUnlock()
+
+	now := time.Now()
+	statuses := make([]Status, 0, len(s.tasks))
+
+	for _, t := range s.tasks {
+		last, err := s.lastRun(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+
+		st := Status{
+			Name:       t.Name,
+			Interval:   t.Interval.String(),
+			LastStatus: StatusNever,
+			NextRunAt:  nextRun(t, last, now),
+			IsLeader:   isLeader,
+		}
+
+		if !last.At.IsZero() {
+			at := last.At
+			st.LastRunAt = &at
+			st.LastStatus = last.Status
+			st.LastError = last.Error
+			st.LastDuration = last.Duration.String()
+		}
+
+		statuses = append(statuses, st)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses, nil
+}
+
+// isDue - reports whether task has not been run in current interval yet.
+func isDue(t Task, last Run, now time.Time) bool {
+	if t.Interval <= 0 {
+		return false
+	}
+
+	return last.At.Before(now.Truncate(t.Interval))
+}
+
+// nextRun - returns time when task will be run next.
+func nextRun(t Task, last Run, now time.Time) time.Time {
+	if isDue(t, last, now) {
+		return now
+	}
+
+	return now.Truncate(t.Interval).Add(t.Interval)
+}
@@ -0,0 +1,180 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestScheduler_tick(t *testing.T) {
+	tests := []struct {
+		name       string
+		taskErr    error
+		wantStatus string
+		wantError  string
+	}{
+		{
+			name:       "Due task is run and its successful run is saved",
+			wantStatus: StatusOK,
+		},
+		{
+			name:       "Due task is run and its failed run is saved",
+			taskErr:    errors.New("boom"),
+			wantStatus: StatusFailed,
+			wantError:  "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			b := NewLocal()
+			defer b.Resign(ctx)
+
+			runs := 0
+			s := New(b, zap.NewNop())
+			s.Add(Task{Name: "task", Interval: time.Hour, Run: func(ctx context.Context) error {
+				runs++
+				return tt.taskErr
+			}})
+
+			s.tick(ctx)
+			s.tick(ctx)
+
+			assert.Equal(t, 1, runs, "task must be run once per interval")
+
+			last, err := b.LastRun(ctx, "task")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, last.Status)
+			assert.Equal(t, tt.wantError, last.Error)
+		})
+	}
+}
+
+func TestScheduler_tickWithoutLeadership(t *testing.T) {
+	ctx := context.Background()
+
+	leader := NewLocal()
+	defer leader.Resign(ctx)
+	isLeader, err := leader.TryLead(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	runs := 0
+	s := New(NewLocal(), zap.NewNop())
+	s.Add(Task{Name: "task", Interval: time.Hour, Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}})
+
+	s.tick(ctx)
+	assert.Equal(t, 0, runs, "task must not be run by follower")
+
+	require.NoError(t, leader.Resign(ctx))
+	s.tick(ctx)
+	assert.Equal(t, 1, runs, "task must be run after follower becomes leader")
+	assert.NoError(t, s.backend.Resign(ctx))
+}
+
+func TestScheduler_tickEveryReplica(t *testing.T) {
+	ctx := context.Background()
+
+	leader := NewLocal()
+	defer leader.Resign(ctx)
+	isLeader, err := leader.TryLead(ctx)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	runs := 0
+	b := NewLocal()
+	s := New(b, zap.NewNop())
+	s.Add(Task{Name: "task", Interval: time.Hour, EveryReplica: true, Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}})
+
+	s.tick(ctx)
+	s.tick(ctx)
+	assert.Equal(t, 1, runs, "task must be run by follower once per interval")
+
+	last, err := b.LastRun(ctx, "task")
+	require.NoError(t, err)
+	assert.True(t, last.At.IsZero(), "run of task must not be saved in backend")
+
+	statuses, err := s.Statuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, StatusOK, statuses[0].LastStatus)
+	assert.False(t, statuses[0].IsLeader)
+}
+
+func TestScheduler_Statuses(t *testing.T) {
+	ctx := context.Background()
+	b := NewLocal()
+	defer b.Resign(ctx)
+
+	s := New(b, zap.NewNop())
+	s.Add(Task{Name: "b", Interval: time.Hour, Run: func(ctx context.Context) error { return nil }})
+	s.Add(Task{Name: "a", Interval: time.Minute, Run: func(ctx context.Context) error { return nil }})
+
+	statuses, err := s.Statuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "a", statuses[0].Name)
+	assert.Equal(t, StatusNever, statuses[0].LastStatus)
+	assert.Nil(t, statuses[0].LastRunAt)
+
+	s.tick(ctx)
+
+	statuses, err = s.Statuses(ctx)
+	require.NoError(t, err)
+	for _, st := range statuses {
+		assert.Equal(t, StatusOK, st.LastStatus)
+		assert.NotNil(t, st.LastRunAt)
+		assert.True(t, st.IsLeader)
+		assert.True(t, st.NextRunAt.After(*st.LastRunAt))
+	}
+}
+
+func Test_isDue(t *testing.T) {
+	now := time.Date(2022, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		last Run
+		task Task
+		want bool
+	}{
+		{
+			name: "Task that has never been run is due",
+			task: Task{Interval: time.Hour},
+			want: true,
+		},
+		{
+			name: "Task run in previous interval is due",
+			task: Task{Interval: time.Hour},
+			last: Run{At: time.Date(2022, 1, 1, 9, 59, 0, 0, time.UTC)},
+			want: true,
+		},
+		{
+			name: "Task run in current interval is not due",
+			task: Task{Interval: time.Hour},
+			last: Run{At: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+			want: false,
+		},
+		{
+			name: "Task without interval is never due",
+			task: Task{},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDue(tt.task, tt.last, now))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var _ Admin = (*AdminService)(nil)
+
+type Admin interface {
+	SearchLinks(ctx context.Context, q storage.SearchQuery) (storage.SearchPage, error)
+	SetLinksDisabled(ctx context.Context, keys []string, disabled bool) (int, error)
+	DeleteLinks(ctx context.Context, keys []string) (int, error)
+	BanUser(ctx context.Context, uid string, reason string) (storage.Ban, error)
+	UnbanUser(ctx context.Context, uid string) error
+	Bans(ctx context.Context) ([]storage.Ban, error)
+	IsBanned(ctx context.Context, uid string) (bool, error)
+}
+
+// AdminService - manages links and users across all users. Every change is logged, so actions of admins
+// can be audited.
+type AdminService struct {
+	storage storage.Moderation
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+// SearchParams - raw parameters of links search received via HTTP query or gRPC request.
+// Empty values disable corresponding filters.
+type SearchParams struct {
+	Key    string // short key of link
+	UID    string // owner of links
+	URL    string // text in original URL
+	Limit  string // max amount of links on page
+	Cursor string // cursor of the next page
+}
+
+func NewAdminService(storage storage.Moderation, l *zap.Logger) *AdminService {
+	return &AdminService{
+		storage: storage,
+		logger:  l,
+		now:     time.Now,
+	}
+}
+
+// ParseSearchQuery - validates raw params and creates storage.SearchQuery. Limit is parsed as in ParseLinksQuery.
+// Returns error wrapping utils.ErrWrongLinksQuery if some param is malformed.
+func ParseSearchQuery(p SearchParams) (storage.SearchQuery, error) {
+	q := storage.SearchQuery{
+		Key:    p.Key,
+		UID:    p.UID,
+		URL:    p.URL,
+		Cursor: p.Cursor,
+	}
+
+	if p.UID != "" {
+		if _, err := uuid.Parse(p.UID); err != nil {
+			return q, fmt.Errorf("%w: uid must be UUID", utils.ErrWrongLinksQuery)
+		}
+	}
+
+	limit, err := parseLimit(p.Limit)
+	if err != nil {
+		return q, err
+	}
+	q.Limit = limit
+
+	return q, nil
+}
+
+// SearchLinks - returns page of links of all users matching provided query.
+func (a *AdminService) SearchLinks(ctx context.Context, q storage.SearchQuery) (storage.SearchPage, error) {
+	return a.storage.SearchLinks(ctx, q)
+}
+
+// SetLinksDisabled - disables or enables links with provided keys. Disabled links are not redirected until enabled.
+func (a *AdminService) SetLinksDisabled(ctx context.Context, keys []string, disabled bool) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	n, err := a.storage.SetLinksDisabled(ctx, keys, disabled)
+	if err != nil {
+		a.logger.Error(err.Error(), zap.Error(err))
+		return 0, err
+	}
+
+	a.logger.Info("admin: links disabled state changed",
+		zap.Strings("keys", keys), zap.Bool("disabled", disabled), zap.Int("changed", n))
+
+	return n, nil
+}
+
+// DeleteLinks - physically removes links with provided keys regardless of their owner. Keys of removed links are
+// quarantined for config.QuarantineDays like keys of purged links.
+func (a *AdminService) DeleteLinks(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	var quarantineUntil time.Time
+	if config.QuarantineDays() > 0 {
+		quarantineUntil = a.now().AddDate(0, 0, config.QuarantineDays())
+	}
+
+	n, err := a.storage.ForceDeleteLinks(ctx, keys, quarantineUntil)
+	if err != nil {
+		a.logger.Error(err.Error(), zap.Error(err))
+		return 0, err
+	}
+
+	a.logger.Info("admin: links deleted", zap.Strings("keys", keys), zap.Int("deleted", n))
+
+	return n, nil
+}
+
+// BanUser - bans user, so user can't shorten links and links of user are not redirected.
+// Returns utils.ErrWrongUID if uid is not UUID.
+func (a *AdminService) BanUser(ctx context.Context, uid string, reason string) (storage.Ban, error) {
+	if _, err := uuid.Parse(uid); err != nil {
+		return storage.Ban{}, utils.ErrWrongUID
+	}
+
+	b := storage.Ban{BannedAt: a.now().UTC(), UID: uid, Reason: reason}
+	if err := a.storage.BanUser(ctx, b); err != nil {
+		a.logger.Error(err.Error(), zap.Error(err))
+		return storage.Ban{}, err
+	}
+
+	a.logger.Info("admin: user banned", zap.String("uid", uid), zap.String("reason", reason))
+
+	return b, nil
+}
+
+// UnbanUser - lifts ban of user. Returns utils.ErrWrongUID if uid is not UUID.
+func (a *AdminService) UnbanUser(ctx context.Context, uid string) error {
+	if _, err := uuid.Parse(uid); err != nil {
+		return utils.ErrWrongUID
+	}
+
+	if err := a.storage.UnbanUser(ctx, uid); err != nil {
+		a.logger.Error(err.Error(), zap.Error(err))
+		return err
+	}
+
+	a.logger.Info("admin: user unbanned", zap.String("uid", uid))
+
+	return nil
+}
+
+// Bans - returns all bans ordered by time.
+func (a *AdminService) Bans(ctx context.Context) ([]storage.Ban, error) {
+	return a.storage.Bans(ctx)
+}
+
+// IsBanned - reports whether user is banned. Users with ID which is not UUID are never banned.
+func (a *AdminService) IsBanned(ctx context.Context, uid string) (bool, error) {
+	if _, err := uuid.Parse(uid); err != nil {
+		return false, nil
+	}
+
+	return a.storage.IsBanned(ctx, uid)
+}
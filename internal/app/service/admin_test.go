@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  SearchParams
+		want    storage.SearchQuery
+		wantErr bool
+	}{
+		{
+			name:   "Default limit is used when params are empty",
+			params: SearchParams{},
+			want:   storage.SearchQuery{Limit: 100},
+		},
+		{
+			name: "All params can be parsed",
+			params: SearchParams{
+				Key:    "key",
+				UID:    "64fb79de-24cf-475a-a042-0aa582ca05bb",
+				URL:    "spam",
+				Limit:  "10",
+				Cursor: "cursor",
+			},
+			want: storage.SearchQuery{
+				Key:    "key",
+				UID:    "64fb79de-24cf-475a-a042-0aa582ca05bb",
+				URL:    "spam",
+				Limit:  10,
+				Cursor: "cursor",
+			},
+		},
+		{
+			name:    "UID which is not UUID can't be parsed",
+			params:  SearchParams{UID: "user"},
+			wantErr: true,
+		},
+		{
+			name:    "Negative limit can't be parsed",
+			params:  SearchParams{Limit: "-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(tt.params)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, utils.ErrWrongLinksQuery)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAdminService_BanUser(t *testing.T) {
+	ctx := context.Background()
+	a := NewAdminService(storage.NewMemory(zap.NewNop()), zap.NewNop())
+	at := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	a.now = func() time.Time { return at }
+	uid := "64fb79de-24cf-475a-a042-0aa582ca05bb"
+
+	_, err := a.BanUser(ctx, "user", "spam")
+	assert.ErrorIs(t, err, utils.ErrWrongUID)
+
+	b, err := a.BanUser(ctx, uid, "spam")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Ban{BannedAt: at, UID: uid, Reason: "spam"}, b)
+
+	banned, err := a.IsBanned(ctx, uid)
+	require.NoError(t, err)
+	assert.True(t, banned)
+
+	banned, err = a.IsBanned(ctx, "user")
+	require.NoError(t, err)
+	assert.False(t, banned, "user with ID which is not UUID is never banned")
+
+	require.NoError(t, a.UnbanUser(ctx, uid))
+	bans, err := a.Bans(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, bans)
+}
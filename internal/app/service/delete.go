@@ -7,51 +7,48 @@ import (
 
 	"go.uber.org/zap"
 
-	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
-	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 type URLDelete interface {
-	Delete(r *http.Request) error
+	Delete(uid string, r *http.Request) error
 }
 
 var _ URLDelete = (*URLDeleteService)(nil)
 
 type URLDeleteService struct {
-	storage storage.DB
-	logger  *zap.Logger
+	queue  storage.DeleteQueuer
+	logger *zap.Logger
 }
 
-func NewURLDeleteService(storage storage.DB, l *zap.Logger) *URLDeleteService {
+func NewURLDeleteService(queue storage.DeleteQueuer, l *zap.Logger) *URLDeleteService {
 	return &URLDeleteService{
-		storage: storage,
-		logger:  l,
+		queue:  queue,
+		logger: l,
 	}
 }
 
-// Delete - soft delete URL.
-func (s *URLDeleteService) Delete(r *http.Request) error {
-	var uid string
-	err := utils.Decode(middleware.GetUUID(), &uid)
-	if err != nil {
-		s.logger.Error(err.Error(), zap.Error(err))
-		return err
-	}
+// Delete - puts request of user to soft delete URL in delete queue.
+// Returns utils.ErrDeleteQueueFull if queue can't accept request right now.
+func (s *URLDeleteService) Delete(uid string, r *http.Request) error {
 	data, err := getDataFromBody(s, r)
 	if err != nil {
 		return err
 	}
 
-	if len(data) > 0 {
-		ch := generateCh(uid, data)
-		s.storage.DeleteThroughCh(ch)
+	if len(data) == 0 {
+		return nil
+	}
+
+	err = s.queue.Enqueue(storage.BatchDelete{UID: uid, Arr: data})
+	if err != nil {
+		s.logger.Error(err.Error(), zap.Error(err))
 	}
 
-	return nil
+	return err
 }
 
-// getDataFromBody - a helper function to read data from body and get uuid from uid cookie.
+// getDataFromBody - a helper function to read keys of links from body.
 func getDataFromBody(s *URLDeleteService, r *http.Request) ([]string, error) {
 	b, errB := ioutil.ReadAll(r.Body)
 	if errB != nil {
@@ -67,17 +64,3 @@ func getDataFromBody(s *URLDeleteService, r *http.Request) ([]string, error) {
 
 	return arr, nil
 }
-
-// generateCh - generates and put into channel storage.BatchDelete
-func generateCh(uid string, data []string) chan storage.BatchDelete {
-	inputCh := make(chan storage.BatchDelete)
-
-	go func() {
-		inputCh <- storage.BatchDelete{
-			UID: uid,
-			Arr: data,
-		}
-	}()
-
-	return inputCh
-}
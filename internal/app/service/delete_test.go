@@ -1,8 +1,6 @@
 package service
 
 import (
-	"context"
-	"errors"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -12,56 +10,27 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
-type DBMock struct {
-	hasError  bool
-	hasConn   bool
-	isConnNil bool
+type deleteQueueMock struct {
+	requests []storage.BatchDelete
+	isFull   bool
 }
 
-func (d *DBMock) Stats() (int, int, error) {
-	return 0, 0, nil
-}
-
-func (d *DBMock) Ping(ctx context.Context) error {
-	if d.hasError {
-		return errors.New("error")
+func (q *deleteQueueMock) Enqueue(r storage.BatchDelete) error {
+	if q.isFull {
+		return utils.ErrDeleteQueueFull
 	}
 
+	q.requests = append(q.requests, r)
 	return nil
 }
 
-func (d *DBMock) Close(ctx context.Context) error {
-	return nil
-}
-
-func (d *DBMock) Store(key *string, url string, uid string) {
-}
-func (d *DBMock) Get(key string) (string, bool, bool) {
-	return "", true, true
-}
-func (d *DBMock) LinksByUUID(uuid string) ([]storage.UserURLs, bool) {
-	return nil, false
-}
-func (d *DBMock) BatchInsert([]storage.BatchRequest, string) ([]storage.BatchLink, error) {
-	return nil, nil
-}
-func (d *DBMock) SoftDeleteUserURLs(uuid string, ids []string) error {
-	return nil
-}
-
-func (d *DBMock) DeleteThroughCh(channels ...chan storage.BatchDelete) {
-}
-
-func (d *DBMock) HasNotNilConn() bool {
-	return d.isConnNil
-}
-
 func TestNewURLDeleteService(t *testing.T) {
 	type args struct {
-		storage storage.DB
-		l       *zap.Logger
+		queue storage.DeleteQueuer
+		l     *zap.Logger
 	}
 	tests := []struct {
 		args args
@@ -71,43 +40,18 @@ func TestNewURLDeleteService(t *testing.T) {
 		{
 			name: "UrlDeleteService can be created",
 			args: args{
-				storage: &DBMock{},
-				l:       &zap.Logger{},
+				queue: &deleteQueueMock{},
+				l:     &zap.Logger{},
 			},
 			want: &URLDeleteService{
-				storage: &DBMock{},
-				logger:  &zap.Logger{},
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, NewURLDeleteService(tt.args.storage, tt.args.l), "NewURLDeleteService(%v, %v)", tt.args.storage, tt.args.l)
-		})
-	}
-}
-
-func Test_generateCh(t *testing.T) {
-	type args struct {
-		uid  string
-		data []string
-	}
-	tests := []struct {
-		want chan storage.BatchDelete
-		name string
-		args args
-	}{
-		{
-			name: "Can generate Ch",
-			args: args{
-				uid:  "1",
-				data: []string{"1", "2"},
+				queue:  &deleteQueueMock{},
+				logger: &zap.Logger{},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.NotNil(t, generateCh(tt.args.uid, tt.args.data))
+			assert.Equalf(t, tt.want, NewURLDeleteService(tt.args.queue, tt.args.l), "NewURLDeleteService(%v, %v)", tt.args.queue, tt.args.l)
 		})
 	}
 }
@@ -139,3 +83,41 @@ func Test_getDataFromBody(t *testing.T) {
 		})
 	}
 }
+
+func TestURLDeleteService_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []storage.BatchDelete
+		isFull  bool
+		wantErr error
+	}{
+		{
+			name: "Request is enqueued for provided user",
+			body: `["a", "b"]`,
+			want: []storage.BatchDelete{{UID: "1", Arr: []string{"a", "b"}}},
+		},
+		{
+			name: "Empty request is not enqueued",
+			body: `[]`,
+		},
+		{
+			name:    "Error is returned when queue is full",
+			body:    `["a"]`,
+			isFull:  true,
+			wantErr: utils.ErrDeleteQueueFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &deleteQueueMock{isFull: tt.isFull}
+			s := NewURLDeleteService(q, zap.NewNop())
+
+			request := http.Request{Body: ioutil.NopCloser(strings.NewReader(tt.body))}
+			err := s.Delete("1", &request)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, q.requests)
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
@@ -12,6 +17,7 @@ import (
 type URLExpand interface {
 	ExpandURL(key string) (string, error)
 	ExpandUserLinks(uuid string) ([]storage.UserURLs, error)
+	FindUserLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error)
 }
 
 var _ URLExpand = (*URLExpandService)(nil)
@@ -33,7 +39,7 @@ func (u *URLExpandService) ExpandURL(key string) (string, error) {
 	url, ok, isDeleted := u.storage.Get(key)
 
 	if !ok || (url == "" && !isDeleted) {
-		return url, errors.New("error in expanding shortened link")
+		return url, utils.ErrLinkNotFound
 	}
 
 	if isDeleted {
@@ -52,3 +58,92 @@ func (u *URLExpandService) ExpandUserLinks(uuid string) ([]storage.UserURLs, err
 
 	return links, nil
 }
+
+// LinksParams - raw parameters of user links listing received via HTTP query or gRPC request.
+// Empty values disable corresponding filters.
+type LinksParams struct {
+	Limit        string // max amount of links on page
+	Cursor       string // cursor of the next page
+	CreatedAfter string // RFC 3339 time
+	Domain       string // host of original URL
+	Deleted      string // "true" or "false"
+	Search       string // text in original URL
+	Sort         string // storage.SortCreatedAsc or storage.SortCreatedDesc
+}
+
+// ParseLinksQuery - validates raw params and creates storage.LinksQuery for links of provided user.
+// Limit defaults to config.UserURLsPageSize and is capped by config.UserURLsMaxPageSize.
+// Returns error wrapping utils.ErrWrongLinksQuery if some param is malformed.
+func ParseLinksQuery(uid string, p LinksParams) (storage.LinksQuery, error) {
+	q := storage.LinksQuery{
+		UID:    uid,
+		Cursor: p.Cursor,
+		Domain: p.Domain,
+		Search: p.Search,
+		Sort:   storage.SortCreatedAsc,
+	}
+
+	limit, err := parseLimit(p.Limit)
+	if err != nil {
+		return q, err
+	}
+	q.Limit = limit
+
+	if p.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, p.CreatedAfter)
+		if err != nil {
+			return q, fmt.Errorf("%w: created_after must be RFC 3339 time", utils.ErrWrongLinksQuery)
+		}
+
+		q.CreatedAfter = createdAfter
+	}
+
+	if p.Deleted != "" {
+		deleted, err := strconv.ParseBool(p.Deleted)
+		if err != nil {
+			return q, fmt.Errorf("%w: deleted must be boolean", utils.ErrWrongLinksQuery)
+		}
+
+		q.Deleted = &deleted
+	}
+
+	switch p.Sort {
+	case "", storage.SortCreatedAsc:
+	case storage.SortCreatedDesc:
+		q.Sort = storage.SortCreatedDesc
+	default:
+		return q, fmt.Errorf(
+			"%w: sort must be %s or %s", utils.ErrWrongLinksQuery, storage.SortCreatedAsc, storage.SortCreatedDesc,
+		)
+	}
+
+	return q, nil
+}
+
+// parseLimit - parses page size. Empty value defaults to config.UserURLsPageSize, values over
+// config.UserURLsMaxPageSize are capped by it.
+func parseLimit(raw string) (int, error) {
+	limit := config.UserURLsPageSize()
+
+	if raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			return 0, fmt.Errorf("%w: limit must be positive integer", utils.ErrWrongLinksQuery)
+		}
+	}
+	if max := config.UserURLsMaxPageSize(); max > 0 && limit > max {
+		limit = max
+	}
+
+	return limit, nil
+}
+
+// FindUserLinks - returns page of user links matching provided query.
+func (u *URLExpandService) FindUserLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error) {
+	page, err := u.storage.FindLinks(ctx, q)
+	if err != nil && !errors.Is(err, utils.ErrInvalidCursor) {
+		u.logger.Error(err.Error(), zap.Error(err))
+	}
+
+	return page, err
+}
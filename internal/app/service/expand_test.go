@@ -1,13 +1,16 @@
 package service
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 type expandStorageMock struct {
@@ -32,7 +35,23 @@ func (sm *expandStorageMock) LinksByUUID(uuid string) ([]storage.UserURLs, bool)
 	return nil, false
 }
 
+func (sm *expandStorageMock) FindLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error) {
+	if q.Cursor == "broken" {
+		return storage.LinksPage{}, utils.ErrInvalidCursor
+	}
+
+	links, _ := sm.LinksByUUID(q.UID)
+	return storage.LinksPage{Links: links}, nil
+}
+
+func (sm *expandStorageMock) SoftDeleteUserURLs(uuid string, ids []string) error {
+	return nil
+}
+
 func (sm *expandStorageMock) Store(key *string, url string, uid string) {}
+func (sm *expandStorageMock) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	return nil
+}
 func (sm *expandStorageMock) Get(key string) (string, bool, bool) {
 	expandedURL := "https://github.com/"
 	if !sm.IsKeyFoundInStore {
@@ -159,3 +178,117 @@ func TestURLExpandService_ExpandUserLinks(t *testing.T) {
 		})
 	}
 }
+
+func TestURLExpandService_FindUserLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   storage.LinksQuery
+		storage storage.Storage
+		wantLen int
+		wantErr error
+	}{
+		{
+			name:    "Service can retrieve page of user links",
+			query:   storage.LinksQuery{UID: "1", Limit: 10},
+			storage: &expandStorageMock{IsKeyFoundInStore: true},
+			wantLen: 1,
+		},
+		{
+			name:    "Service returns ErrInvalidCursor on malformed cursor",
+			query:   storage.LinksQuery{UID: "1", Limit: 10, Cursor: "broken"},
+			storage: &expandStorageMock{IsKeyFoundInStore: true},
+			wantErr: utils.ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := NewURLExpandService(tt.storage, zap.NewNop())
+
+			page, err := u.FindUserLinks(context.Background(), tt.query)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, page.Links, tt.wantLen)
+		})
+	}
+}
+
+func TestParseLinksQuery(t *testing.T) {
+	deleted := true
+	createdAfter := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		params  LinksParams
+		want    storage.LinksQuery
+		wantErr bool
+	}{
+		{
+			name:   "Defaults are used when params are empty",
+			params: LinksParams{},
+			want:   storage.LinksQuery{UID: "1", Sort: storage.SortCreatedAsc, Limit: 100},
+		},
+		{
+			name: "All params can be parsed",
+			params: LinksParams{
+				Limit:        "10",
+				Cursor:       "cursor",
+				CreatedAfter: "2022-01-02T03:04:05Z",
+				Domain:       "github.com",
+				Deleted:      "true",
+				Search:       "go",
+				Sort:         storage.SortCreatedDesc,
+			},
+			want: storage.LinksQuery{
+				UID:          "1",
+				Cursor:       "cursor",
+				CreatedAfter: createdAfter,
+				Domain:       "github.com",
+				Deleted:      &deleted,
+				Search:       "go",
+				Sort:         storage.SortCreatedDesc,
+				Limit:        10,
+			},
+		},
+		{
+			name:   "Limit is capped by max page size",
+			params: LinksParams{Limit: "100000"},
+			want:   storage.LinksQuery{UID: "1", Sort: storage.SortCreatedAsc, Limit: 1000},
+		},
+		{
+			name:    "Error is returned on malformed limit",
+			params:  LinksParams{Limit: "ten"},
+			wantErr: true,
+		},
+		{
+			name:    "Error is returned on malformed created_after",
+			params:  LinksParams{CreatedAfter: "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "Error is returned on malformed deleted",
+			params:  LinksParams{Deleted: "maybe"},
+			wantErr: true,
+		},
+		{
+			name:    "Error is returned on unknown sort",
+			params:  LinksParams{Sort: "url"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLinksQuery("1", tt.params)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, utils.ErrWrongLinksQuery)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
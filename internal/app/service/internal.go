@@ -1,6 +1,10 @@
 package service
 
 import (
+	"context"
+	"expvar"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
@@ -8,6 +12,13 @@ import (
 
 var _ Internal = (*InternalService)(nil)
 
+// Counts of links and users rolled up by InternalService.Rollup, published in expvar.
+var (
+	linksTotal      = expvar.NewInt("links_total")
+	usersTotal      = expvar.NewInt("users_total")
+	statsRolledUpAt = expvar.NewInt("stats_rolled_up_at")
+)
+
 type Internal interface {
 	Stats() (int, int, error)
 }
@@ -28,3 +39,20 @@ func NewInternalService(storage storage.Storage, l *zap.Logger) *InternalService
 func (i *InternalService) Stats() (int, int, error) {
 	return i.storage.Stats()
 }
+
+// Rollup - counts links and users and publishes counts in expvar with unix time of rollup, so metrics can be
+// read without counting them on each request. Counts are kept in memory of process, so it's meant to be run by
+// scheduler on every replica.
+func (i *InternalService) Rollup(ctx context.Context) error {
+	urls, users, err := i.storage.Stats()
+	if err != nil {
+		i.logger.Error(err.Error(), zap.Error(err))
+		return err
+	}
+
+	linksTotal.Set(int64(urls))
+	usersTotal.Set(int64(users))
+	statsRolledUpAt.Set(time.Now().Unix())
+
+	return nil
+}
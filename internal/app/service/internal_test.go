@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,6 +15,10 @@ type InternalStorageMock struct{}
 func (i *InternalStorageMock) Store(key *string, url string, uid string) {
 }
 
+func (i *InternalStorageMock) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	return nil
+}
+
 func (i *InternalStorageMock) Get(key string) (string, bool, bool) {
 	return "test", true, true
 }
@@ -22,6 +27,14 @@ func (i *InternalStorageMock) LinksByUUID(uuid string) ([]storage.UserURLs, bool
 	return nil, false
 }
 
+func (i *InternalStorageMock) FindLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error) {
+	return storage.LinksPage{}, nil
+}
+
+func (i *InternalStorageMock) SoftDeleteUserURLs(uuid string, ids []string) error {
+	return nil
+}
+
 func (i *InternalStorageMock) Stats() (int, int, error) {
 	return 1, 2, nil
 }
@@ -90,3 +103,12 @@ func TestInternalService_Stats(t *testing.T) {
 		})
 	}
 }
+
+func TestInternalService_Rollup(t *testing.T) {
+	i := NewInternalService(&InternalStorageMock{}, zap.NewNop())
+
+	assert.NoError(t, i.Rollup(context.Background()))
+	assert.Equal(t, int64(1), linksTotal.Value())
+	assert.Equal(t, int64(2), usersTotal.Value())
+	assert.NotZero(t, statsRolledUpAt.Value())
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Codes of exceeded quotas reported to clients.
+const (
+	QuotaLinks = "links_quota_exceeded"
+	QuotaDaily = "daily_quota_exceeded"
+	QuotaBatch = "batch_quota_exceeded"
+)
+
+var _ Quotas = (*QuotaService)(nil)
+var _ URLShorten = (*QuotaShortener)(nil)
+
+type Quotas interface {
+	// Usage - returns limits of user and how much of them is used.
+	Usage(ctx context.Context, uid string) (QuotaUsage, error)
+	// Check - returns *QuotaError if creating n links in one request exceeds quota of user.
+	Check(ctx context.Context, uid string, n int) error
+	// Insert - checks quota like Check and calls insert of n links with quota of user locked, so concurrent
+	// requests of user can't exceed quota together. Links must be inserted with ctx passed to insert.
+	Insert(ctx context.Context, uid string, n int, insert func(ctx context.Context) error) error
+	// SetOverride - overrides global quota for user and returns new usage.
+	SetOverride(ctx context.Context, uid string, o storage.QuotaOverride) (QuotaUsage, error)
+	// DeleteOverride - returns user to global quota.
+	DeleteOverride(ctx context.Context, uid string) error
+}
+
+// QuotaUsage - limits of user with global quota and overrides applied, and how much of them is used.
+type QuotaUsage struct {
+	storage.Quota
+	ResetsAt   time.Time              `json:"resets_at"`   // when daily quota is restored
+	Override   *storage.QuotaOverride `json:"override"`    // limits set for user by admin, nil if there are none
+	Links      int                    `json:"links"`       // amount of active links
+	LinksToday int                    `json:"links_today"` // amount of links created today
+}
+
+// QuotaError - error of request exceeding quota of user. Unwraps to utils.ErrLinksQuota, utils.ErrDailyQuota
+// or utils.ErrBatchQuota.
+type QuotaError struct {
+	Err   error
+	Code  string
+	Limit int
+}
+
+// Error - returns message of exceeded quota with its limit.
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: limit is %d", e.Err, e.Limit)
+}
+
+// Unwrap - returns sentinel error of exceeded quota.
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+// QuotaService - enforces hard limits on links of users. Limits are taken from global quota unless admin has
+// overridden them for user. Day of daily quota is UTC day. Concurrent requests of user are serialized by locker
+// in Insert from check until their links are inserted.
+type QuotaService struct {
+	usage    storage.Usage
+	store    storage.QuotaStore
+	locker   storage.QuotaLocker
+	logger   *zap.Logger
+	now      func() time.Time
+	defaults storage.Quota
+}
+
+func NewQuotaService(
+	usage storage.Usage,
+	store storage.QuotaStore,
+	locker storage.QuotaLocker,
+	defaults storage.Quota,
+	l *zap.Logger,
+) *QuotaService {
+	return &QuotaService{
+		usage:    usage,
+		store:    store,
+		locker:   locker,
+		logger:   l,
+		now:      time.Now,
+		defaults: defaults,
+	}
+}
+
+// Usage - returns effective limits of user and counts of its links.
+func (q *QuotaService) Usage(ctx context.Context, uid string) (QuotaUsage, error) {
+	o, err := q.store.QuotaOverride(ctx, uid)
+	if err != nil {
+		q.logger.Error(err.Error(), zap.Error(err))
+		return QuotaUsage{}, err
+	}
+
+	day := q.now().UTC().Truncate(24 * time.Hour)
+	links, today, err := q.usage.UserUsage(ctx, uid, day)
+	if err != nil {
+		q.logger.Error(err.Error(), zap.Error(err))
+		return QuotaUsage{}, err
+	}
+
+	u := QuotaUsage{
+		Quota:      o.Apply(q.defaults),
+		ResetsAt:   day.Add(24 * time.Hour),
+		Links:      links,
+		LinksToday: today,
+	}
+	if o != (storage.QuotaOverride{}) {
+		u.Override = &o
+	}
+
+	return u, nil
+}
+
+// Check - checks batch size first, so too large batch is rejected without counting links.
+func (q *QuotaService) Check(ctx context.Context, uid string, n int) error {
+	quota, err := q.quota(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	return q.check(ctx, uid, n, quota)
+}
+
+// Insert - locks quota of user only if it limits amount of links, so users without limits are not serialized.
+func (q *QuotaService) Insert(ctx context.Context, uid string, n int, insert func(ctx context.Context) error) error {
+	quota, err := q.quota(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if quota.MaxLinks <= 0 && quota.MaxDaily <= 0 {
+		if err = q.check(ctx, uid, n, quota); err != nil {
+			return err
+		}
+		return insert(ctx)
+	}
+
+	return q.locker.WithUserLock(ctx, uid, func(ctx context.Context) error {
+		if err := q.check(ctx, uid, n, quota); err != nil {
+			return err
+		}
+		return insert(ctx)
+	})
+}
+
+// quota - returns limits of user with override applied.
+func (q *QuotaService) quota(ctx context.Context, uid string) (storage.Quota, error) {
+	o, err := q.store.QuotaOverride(ctx, uid)
+	if err != nil {
+		q.logger.Error(err.Error(), zap.Error(err))
+		return storage.Quota{}, err
+	}
+
+	return o.Apply(q.defaults), nil
+}
+
+// check - returns *QuotaError if creating n links exceeds provided limits of user.
+func (q *QuotaService) check(ctx context.Context, uid string, n int, quota storage.Quota) error {
+	if quota.MaxBatch > 0 && n > quota.MaxBatch {
+		return &QuotaError{Err: utils.ErrBatchQuota, Code: QuotaBatch, Limit: quota.MaxBatch}
+	}
+	if quota.MaxLinks <= 0 && quota.MaxDaily <= 0 {
+		return nil
+	}
+
+	links, today, err := q.usage.UserUsage(ctx, uid, q.now().UTC().Truncate(24*time.Hour))
+	if err != nil {
+		q.logger.Error(err.Error(), zap.Error(err))
+		return err
+	}
+	if quota.MaxLinks > 0 && links+n > quota.MaxLinks {
+		return &QuotaError{Err: utils.ErrLinksQuota, Code: QuotaLinks, Limit: quota.MaxLinks}
+	}
+	if quota.MaxDaily > 0 && today+n > quota.MaxDaily {
+		return &QuotaError{Err: utils.ErrDailyQuota, Code: QuotaDaily, Limit: quota.MaxDaily}
+	}
+
+	return nil
+}
+
+// SetOverride - stores override of user. Returns utils.ErrWrongUID if uid is not UUID and utils.ErrWrongQuota
+// if some limit is negative.
+func (q *QuotaService) SetOverride(ctx context.Context, uid string, o storage.QuotaOverride) (QuotaUsage, error) {
+	if _, err := uuid.Parse(uid); err != nil {
+		return QuotaUsage{}, utils.ErrWrongUID
+	}
+	for _, limit := range []*int{o.MaxLinks, o.MaxBatch, o.MaxDaily} {
+		if limit != nil && *limit < 0 {
+			return QuotaUsage{}, utils.ErrWrongQuota
+		}
+	}
+
+	if err := q.store.SaveQuotaOverride(ctx, uid, o); err != nil {
+		q.logger.Error(err.Error(), zap.Error(err))
+		return QuotaUsage{}, err
+	}
+
+	q.logger.Info("admin: quota of user overridden", zap.String("uid", uid), zap.Any("override", o))
+
+	return q.Usage(ctx, uid)
+}
+
+// DeleteOverride - removes override of user. Returns utils.ErrWrongUID if uid is not UUID.
+func (q *QuotaService) DeleteOverride(ctx context.Context, uid string) error {
+	if _, err := uuid.Parse(uid); err != nil {
+		return utils.ErrWrongUID
+	}
+
+	if err := q.store.DeleteQuotaOverride(ctx, uid); err != nil {
+		q.logger.Error(err.Error(), zap.Error(err))
+		return err
+	}
+
+	q.logger.Info("admin: quota override of user removed", zap.String("uid", uid))
+
+	return nil
+}
+
+// QuotaShortener - URLShorten which checks quota of user before shortening.
+type QuotaShortener struct {
+	URLShorten
+	quotas Quotas
+}
+
+// NewQuotaShortener - wraps URLShorten with quota checks.
+func NewQuotaShortener(s URLShorten, q Quotas) *QuotaShortener {
+	return &QuotaShortener{URLShorten: s, quotas: q}
+}
+
+// ShortenURL - shortens URL if user creating it can create one more link. Returns *QuotaError otherwise. Link
+// created by member of workspace is charged to member, see storage.Creator.
+func (s *QuotaShortener) ShortenURL(ctx context.Context, url string, uid string) (string, error) {
+	var key string
+	err := s.quotas.Insert(ctx, storage.Creator(ctx, uid), 1, func(ctx context.Context) error {
+		var err error
+		key, err = s.URLShorten.ShortenURL(ctx, url, uid)
+		return err
+	})
+
+	return key, err
+}
+
+// AsQuotaError - returns *QuotaError wrapped by err, if there is one.
+func AsQuotaError(err error) (*QuotaError, bool) {
+	var qe *QuotaError
+	ok := errors.As(err, &qe)
+
+	return qe, ok
+}
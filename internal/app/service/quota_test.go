@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestQuotaService_Check(t *testing.T) {
+	tests := []struct {
+		override storage.QuotaOverride
+		wantErr  error
+		name     string
+		wantCode string
+		links    int
+		deleted  int
+		n        int
+	}{
+		{
+			name:  "Links within quota can be created",
+			links: 2,
+			n:     1,
+		},
+		{
+			name:     "Links over quota of active links are rejected",
+			links:    3,
+			n:        1,
+			wantErr:  utils.ErrLinksQuota,
+			wantCode: QuotaLinks,
+		},
+		{
+			name:    "Deleted links don't count as active",
+			links:   3,
+			deleted: 1,
+			n:       1,
+		},
+		{
+			name:     "Deleted links count in daily quota",
+			links:    5,
+			deleted:  4,
+			n:        1,
+			wantErr:  utils.ErrDailyQuota,
+			wantCode: QuotaDaily,
+		},
+		{
+			name:     "Batch over quota is rejected",
+			n:        3,
+			wantErr:  utils.ErrBatchQuota,
+			wantCode: QuotaBatch,
+		},
+		{
+			name:     "Override of user replaces global limit",
+			override: storage.QuotaOverride{MaxLinks: intPtr(10), MaxBatch: intPtr(5)},
+			links:    3,
+			n:        1,
+		},
+		{
+			name:     "Limits not set by override are global",
+			override: storage.QuotaOverride{MaxLinks: intPtr(10)},
+			n:        3,
+			wantErr:  utils.ErrBatchQuota,
+			wantCode: QuotaBatch,
+		},
+		{
+			name:     "Zero limit of override disables quota",
+			override: storage.QuotaOverride{MaxLinks: intPtr(0), MaxDaily: intPtr(0), MaxBatch: intPtr(0)},
+			links:    5,
+			n:        100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			uid := uuid.NewString()
+			links := storage.NewMemory(zap.NewNop())
+			quotas := storage.NewMemoryQuotas()
+			require.NoError(t, quotas.SaveQuotaOverride(ctx, uid, tt.override))
+
+			keys := make([]string, 0, tt.links)
+			for i := 0; i < tt.links; i++ {
+				key := uuid.NewString()
+				links.Store(&key, "https://example.com/"+key, uid)
+				keys = append(keys, key)
+			}
+			require.NoError(t, links.SoftDeleteUserURLs(uid, keys[:tt.deleted]))
+
+			q := NewQuotaService(links, quotas, storage.NewMemoryQuotaLocker(), storage.Quota{MaxLinks: 3, MaxBatch: 2, MaxDaily: 5}, zap.NewNop())
+			err := q.Check(ctx, uid, tt.n)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			qe, ok := AsQuotaError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantCode, qe.Code)
+		})
+	}
+}
+
+func TestQuotaService_Usage(t *testing.T) {
+	ctx := context.Background()
+	uid := uuid.NewString()
+	links := storage.NewMemory(zap.NewNop())
+	key := "key"
+	links.Store(&key, "https://example.com", uid)
+
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	q := NewQuotaService(links, storage.NewMemoryQuotas(), storage.NewMemoryQuotaLocker(), storage.Quota{MaxLinks: 3, MaxBatch: 2}, zap.NewNop())
+	q.now = func() time.Time { return now }
+
+	u, err := q.Usage(ctx, uid)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Quota{MaxLinks: 3, MaxBatch: 2}, u.Quota)
+	assert.Equal(t, 1, u.Links)
+	assert.Nil(t, u.Override)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), u.ResetsAt)
+
+	_, err = q.SetOverride(ctx, "user", storage.QuotaOverride{})
+	assert.ErrorIs(t, err, utils.ErrWrongUID)
+	_, err = q.SetOverride(ctx, uid, storage.QuotaOverride{MaxDaily: intPtr(-1)})
+	assert.ErrorIs(t, err, utils.ErrWrongQuota)
+
+	u, err = q.SetOverride(ctx, uid, storage.QuotaOverride{MaxLinks: intPtr(100)})
+	require.NoError(t, err)
+	assert.Equal(t, 100, u.MaxLinks)
+	require.NotNil(t, u.Override)
+
+	require.NoError(t, q.DeleteOverride(ctx, uid))
+	u, err = q.Usage(ctx, uid)
+	require.NoError(t, err)
+	assert.Equal(t, 3, u.MaxLinks)
+	assert.Nil(t, u.Override)
+}
+
+// slowUsage - storage.Usage returning counts of links with delay, so concurrent checks of quota overlap.
+type slowUsage struct {
+	storage.Usage
+}
+
+func (u slowUsage) UserUsage(ctx context.Context, uid string, since time.Time) (int, int, error) {
+	active, created, err := u.Usage.UserUsage(ctx, uid, since)
+	time.Sleep(10 * time.Millisecond)
+
+	return active, created, err
+}
+
+func TestQuotaShortener_ConcurrentRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    storage.Quota
+		requests int
+		want     int
+	}{
+		{
+			name:     "Concurrent requests can't exceed quota of active links together",
+			quota:    storage.Quota{MaxLinks: 3},
+			requests: 20,
+			want:     3,
+		},
+		{
+			name:     "Concurrent requests can't exceed daily quota together",
+			quota:    storage.Quota{MaxDaily: 5},
+			requests: 20,
+			want:     5,
+		},
+		{
+			name:     "Requests of user without limits are not rejected",
+			requests: 20,
+			want:     20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uid := uuid.NewString()
+			links := storage.NewMemory(zap.NewNop())
+			q := NewQuotaService(slowUsage{links}, storage.NewMemoryQuotas(), storage.NewMemoryQuotaLocker(), tt.quota, zap.NewNop())
+			s := NewQuotaShortener(NewURLShortenerService(links, sequence.NewSequence(), zap.NewNop()), q)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tt.requests)
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := s.ShortenURL(context.Background(), "https://example.com/"+uuid.NewString(), uid)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			created := 0
+			for err := range errs {
+				if err == nil {
+					created++
+					continue
+				}
+				_, ok := AsQuotaError(err)
+				assert.True(t, ok, err)
+			}
+			assert.Equal(t, tt.want, created)
+
+			u, err := q.Usage(context.Background(), uid)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, u.Links)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/jobs"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+const (
+	// retentionBatchPause - pause between purged batches, so database has time to vacuum removed rows.
+	retentionBatchPause = 100 * time.Millisecond
+	// KindPurgeDeleted - kind of job purging soft deleted links.
+	KindPurgeDeleted = "purge_deleted"
+)
+
+var _ Retention = (*RetentionService)(nil)
+
+type Retention interface {
+	Purge(ctx context.Context) (int, error)
+	HandleJob(ctx context.Context, payload []byte) error
+	Enqueue(ctx context.Context) error
+}
+
+type RetentionService struct {
+	storage  storage.Retention
+	producer jobs.Producer
+	logger   *zap.Logger
+}
+
+// NewRetentionService - creates RetentionService. Producer is used by Enqueue to enqueue purge jobs and may be nil
+// if only Purge is called.
+func NewRetentionService(storage storage.Retention, producer jobs.Producer, l *zap.Logger) *RetentionService {
+	return &RetentionService{
+		storage:  storage,
+		producer: producer,
+		logger:   l,
+	}
+}
+
+// Purge - physically removes links soft deleted more than config.RetentionDays ago in batches of
+// config.RetentionBatchSize. Keys of removed links are quarantined for config.QuarantineDays.
+// Returns total amount of removed links.
+func (r *RetentionService) Purge(ctx context.Context) (int, error) {
+	if config.RetentionDays() <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	before := now.AddDate(0, 0, -config.RetentionDays())
+
+	var quarantineUntil time.Time
+	if config.QuarantineDays() > 0 {
+		quarantineUntil = now.AddDate(0, 0, config.QuarantineDays())
+	}
+
+	if _, err := r.storage.ReleaseQuarantine(ctx); err != nil {
+		r.logger.Error(err.Error(), zap.Error(err))
+		return 0, err
+	}
+
+	batchSize := config.RetentionBatchSize()
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	total := 0
+	for {
+		purged, err := r.storage.PurgeDeleted(ctx, before, quarantineUntil, batchSize)
+		if err != nil {
+			r.logger.Error(err.Error(), zap.Error(err))
+			return total, err
+		}
+
+		total += purged
+		if purged < batchSize {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(retentionBatchPause):
+		}
+	}
+}
+
+// HandleJob - purges soft deleted links as KindPurgeDeleted job.
+func (r *RetentionService) HandleJob(ctx context.Context, payload []byte) error {
+	purged, err := r.Purge(ctx)
+	if err == nil && purged > 0 {
+		r.logger.Info("soft deleted links purged", zap.Int("count", purged))
+	}
+
+	return err
+}
+
+// Enqueue - enqueues KindPurgeDeleted job. Called by scheduler once per config.RetentionInterval.
+func (r *RetentionService) Enqueue(ctx context.Context) error {
+	if config.RetentionDays() <= 0 || r.producer == nil {
+		return nil
+	}
+
+	return r.producer.Enqueue(ctx, KindPurgeDeleted, struct{}{})
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+)
+
+type retentionStorageMock struct {
+	batches         []int
+	hasError        bool
+	calls           int
+	before          time.Time
+	quarantineUntil time.Time
+	released        bool
+}
+
+func (r *retentionStorageMock) PurgeDeleted(ctx context.Context, before time.Time, quarantineUntil time.Time, limit int) (int, error) {
+	if r.hasError {
+		return 0, errors.New("error")
+	}
+
+	r.before, r.quarantineUntil = before, quarantineUntil
+	if r.calls >= len(r.batches) {
+		return 0, nil
+	}
+
+	r.calls++
+	return r.batches[r.calls-1], nil
+}
+
+func (r *retentionStorageMock) ReleaseQuarantine(ctx context.Context) (int, error) {
+	r.released = true
+	return 0, nil
+}
+
+func TestRetentionService_Purge(t *testing.T) {
+	tests := []struct {
+		storage        *retentionStorageMock
+		name           string
+		retentionDays  int
+		quarantineDays int
+		want           int
+		wantCalls      int
+		wantErr        bool
+	}{
+		{
+			name:          "Nothing is purged if retention is disabled",
+			storage:       &retentionStorageMock{batches: []int{2}},
+			retentionDays: 0,
+			want:          0,
+			wantCalls:     0,
+		},
+		{
+			name:           "Links are purged in batches until last batch is not full",
+			storage:        &retentionStorageMock{batches: []int{2, 2, 1}},
+			retentionDays:  30,
+			quarantineDays: 7,
+			want:           5,
+			wantCalls:      3,
+		},
+		{
+			name:          "Error of storage is returned",
+			storage:       &retentionStorageMock{hasError: true},
+			retentionDays: 30,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(
+				config.WithRetentionDays(tt.retentionDays),
+				config.WithQuarantineDays(tt.quarantineDays),
+				config.WithRetentionBatchSize(2),
+			)
+			defer config.NewConfig(config.WithRetentionDays(0), config.WithQuarantineDays(0))
+
+			got, err := NewRetentionService(tt.storage, nil, zap.NewNop()).Purge(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantCalls, tt.storage.calls)
+
+			if tt.wantCalls > 0 {
+				assert.WithinDuration(t, time.Now().AddDate(0, 0, -tt.retentionDays), tt.storage.before, time.Minute)
+				assert.WithinDuration(t, time.Now().AddDate(0, 0, tt.quarantineDays), tt.storage.quarantineUntil, time.Minute)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"errors"
+
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
@@ -11,7 +14,7 @@ import (
 var _ URLShorten = (*URLShortenerService)(nil)
 
 type URLShorten interface {
-	ShortenURL(url string, uid string) (string, error)
+	ShortenURL(ctx context.Context, url string, uid string) (string, error)
 }
 
 type URLShortenerService struct {
@@ -28,25 +31,22 @@ func NewURLShortenerService(storage storage.Storage, seq sequence.Generator, l *
 	}
 }
 
-// ShortenURL - shortens provided URL and stores it in storage.
-func (u *URLShortenerService) ShortenURL(url string, uid string) (string, error) {
-	for {
-		key, err := u.seq.Generate(8)
-		if err != nil {
-			u.logger.Error(err.Error(), zap.Error(err))
-			return "", err
-		}
-
-		_, ok, _ := u.storage.Get(key)
-		if !ok {
-			keyBeforeStore := key
-			u.storage.Store(&key, url, uid)
-
-			if keyBeforeStore != key {
-				return key, utils.ErrLinksConflict
-			}
+// ShortenURL - shortens provided URL and stores it in storage within ctx. Used keys and quarantined keys of purged
+// links are replaced by storage. Returns key of the same URL stored before with utils.ErrLinksConflict.
+func (u *URLShortenerService) ShortenURL(ctx context.Context, url string, uid string) (string, error) {
+	key, err := u.seq.Generate(8)
+	if err != nil {
+		u.logger.Error(err.Error(), zap.Error(err))
+		return "", err
+	}
 
-			return key, nil
+	if err = u.storage.StoreContext(ctx, &key, url, uid); err != nil {
+		if errors.Is(err, utils.ErrLinksConflict) {
+			return key, err
 		}
+		u.logger.Error(err.Error(), zap.Error(err))
+		return "", err
 	}
+
+	return key, nil
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -8,11 +9,13 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
 )
 
 type shortenStorageMock struct {
 	IsKeyFoundInStore bool
+	IsURLFoundInStore bool
 }
 
 func (sm *shortenStorageMock) Stats() (int, int, error) {
@@ -23,7 +26,22 @@ func (sm *shortenStorageMock) LinksByUUID(uuid string) ([]storage.UserURLs, bool
 	return nil, true
 }
 
+func (sm *shortenStorageMock) FindLinks(ctx context.Context, q storage.LinksQuery) (storage.LinksPage, error) {
+	return storage.LinksPage{}, nil
+}
+
+func (sm *shortenStorageMock) SoftDeleteUserURLs(uuid string, ids []string) error {
+	return nil
+}
+
 func (sm *shortenStorageMock) Store(key *string, url string, uid string) {}
+func (sm *shortenStorageMock) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	if sm.IsURLFoundInStore {
+		*key = "storedKey"
+		return utils.ErrLinksConflict
+	}
+	return nil
+}
 func (sm *shortenStorageMock) Get(key string) (string, bool, bool) {
 	expandedURL := "https://github.com/"
 	if !sm.IsKeyFoundInStore {
@@ -84,9 +102,11 @@ func TestURLShortenerService_ShortenURL(t *testing.T) {
 		uid string
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		args   args
+		wantErr error
+		name    string
+		fields  fields
+		args    args
+		want    string
 	}{
 		{
 			name: "URL can be shortened and stored",
@@ -110,17 +130,32 @@ func TestURLShortenerService_ShortenURL(t *testing.T) {
 				storage: &shortenStorageMock{IsKeyFoundInStore: false},
 				seq:     &sequenceMock{HasErrorInGenerationSeq: true},
 			},
-			args: args{url: "", uid: "9d4f0794-3b01-44e4-ad35-3991b9e421a9"},
+			args:    args{url: "", uid: "9d4f0794-3b01-44e4-ad35-3991b9e421a9"},
+			wantErr: errors.New("to generate random sequence positive number of letters must be provided"),
+		},
+		{
+			name: "Key of URL stored before is returned with conflict",
+			fields: fields{
+				storage: &shortenStorageMock{IsURLFoundInStore: true},
+				seq:     &sequenceMock{HasErrorInGenerationSeq: false},
+			},
+			args:    args{url: "https://github.com/", uid: "9d4f0794-3b01-44e4-ad35-3991b9e421a9"},
+			want:    "storedKey",
+			wantErr: utils.ErrLinksConflict,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			u := NewURLShortenerService(tt.fields.storage, tt.fields.seq, zap.NewNop())
 
-			got, err := u.ShortenURL(tt.args.url, tt.args.uid)
-			if err != nil {
+			got, err := u.ShortenURL(context.Background(), tt.args.url, tt.args.uid)
+			assert.Equal(t, tt.wantErr, err)
+			switch {
+			case tt.want != "":
+				assert.Equal(t, tt.want, got)
+			case err != nil:
 				assert.Empty(t, got)
-			} else {
+			default:
 				assert.NotEmpty(t, got)
 			}
 		})
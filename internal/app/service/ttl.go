@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/jobs"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/storage"
+)
+
+type TTLService struct {
+	retention storage.Retention
+	jobs      jobs.Store
+	logger    *zap.Logger
+}
+
+// NewTTLService - creates TTLService. Retention may be nil if storage can't purge links.
+func NewTTLService(retention storage.Retention, jobs jobs.Store, l *zap.Logger) *TTLService {
+	return &TTLService{
+		retention: retention,
+		jobs:      jobs,
+		logger:    l,
+	}
+}
+
+// Purge - removes records whose time to live is over: releases keys with expired quarantine and removes jobs
+// dead for longer than config.JobDeadTTL. Meant to be run by scheduler, so it runs once per interval
+// across replicas.
+func (s *TTLService) Purge(ctx context.Context) error {
+	if s.retention != nil {
+		released, err := s.retention.ReleaseQuarantine(ctx)
+		if err != nil {
+			s.logger.Error(err.Error(), zap.Error(err))
+			return err
+		}
+		s.logger.Info("ttl: quarantine released", zap.Int("keys", released))
+	}
+
+	if config.JobDeadTTL() <= 0 {
+		return nil
+	}
+
+	purged, err := s.jobs.PurgeDead(ctx, time.Now().Add(-config.JobDeadTTL()))
+	if err != nil {
+		s.logger.Error(err.Error(), zap.Error(err))
+		return err
+	}
+	s.logger.Info("ttl: dead jobs purged", zap.Int("jobs", purged))
+
+	return nil
+}
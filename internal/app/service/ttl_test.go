@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/jobs"
+)
+
+type deadJobsMock struct {
+	jobs.Store
+	before time.Time
+	calls  int
+}
+
+func (d *deadJobsMock) PurgeDead(ctx context.Context, before time.Time) (int, error) {
+	d.before = before
+	d.calls++
+
+	return 1, nil
+}
+
+func TestTTLService_Purge(t *testing.T) {
+	defer config.NewConfig(config.WithJobDeadTTL(168 * time.Hour))
+
+	tests := []struct {
+		retention    *retentionStorageMock
+		name         string
+		deadTTL      time.Duration
+		wantReleased bool
+		wantPurged   bool
+	}{
+		{
+			name:         "Quarantine is released and old dead jobs are purged",
+			retention:    &retentionStorageMock{},
+			deadTTL:      time.Hour,
+			wantReleased: true,
+			wantPurged:   true,
+		},
+		{
+			name:         "Dead jobs are kept if TTL is disabled",
+			retention:    &retentionStorageMock{},
+			wantReleased: true,
+		},
+		{
+			name:       "Storage without retention is skipped",
+			deadTTL:    time.Hour,
+			wantPurged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(config.WithJobDeadTTL(tt.deadTTL))
+
+			store := &deadJobsMock{}
+			s := NewTTLService(nil, store, zap.NewNop())
+			if tt.retention != nil {
+				s = NewTTLService(tt.retention, store, zap.NewNop())
+			}
+			require.NoError(t, s.Purge(context.Background()))
+
+			if tt.retention != nil {
+				assert.Equal(t, tt.wantReleased, tt.retention.released)
+			}
+			assert.Equal(t, tt.wantPurged, store.calls == 1)
+			if tt.wantPurged {
+				assert.WithinDuration(t, time.Now().Add(-tt.deadTTL), store.before, time.Second)
+			}
+		})
+	}
+}
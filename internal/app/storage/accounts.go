@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var _ auth.AccountStore = (*dbAccountStore)(nil)
+var _ auth.AccountStore = (*fileAccountStore)(nil)
+
+// dbAccountStore - auth.AccountStore backed by accounts and sessions tables.
+type dbAccountStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	insertAccount = `insert into accounts (id, login, password_hash, created_at) values ($1, $2, $3, $4)
+		on conflict (login) do nothing`
+	selectAccountByLogin  = `select id, login, password_hash, created_at from accounts where login = $1`
+	insertSession         = `insert into sessions (hash, uid, created_at, expires_at, roles) values ($1, $2, $3, $4, $5)`
+	selectSessionByHash   = `select hash, uid, created_at, expires_at, roles from sessions where hash = $1`
+	deleteSession         = `delete from sessions where hash = $1`
+	deleteExpiredSessions = `delete from sessions where expires_at < $1`
+)
+
+// NewAccountStore - creates auth.AccountStore for provided storage: accounts and sessions tables for database,
+// records in file for file storage and in-memory store otherwise.
+func NewAccountStore(s Storage, l *zap.Logger) auth.AccountStore {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbAccountStore{conn: d.conn, logger: l}
+	}
+	if f, ok := s.(*fileStore); ok {
+		return newFileAccountStore(f, l)
+	}
+
+	return auth.NewMemoryAccounts()
+}
+
+// CreateAccount - inserts account into accounts table.
+func (s *dbAccountStore) CreateAccount(ctx context.Context, a auth.Account) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.conn.Exec(ctx, insertAccount, a.ID, a.Login, a.PasswordHash, a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return utils.ErrLoginTaken
+	}
+
+	return nil
+}
+
+// AccountByLogin - selects account with provided login.
+func (s *dbAccountStore) AccountByLogin(ctx context.Context, login string) (auth.Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var a auth.Account
+	err := s.conn.QueryRow(ctx, selectAccountByLogin, login).Scan(&a.ID, &a.Login, &a.PasswordHash, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.Account{}, utils.ErrBadCredentials
+	}
+
+	return a, err
+}
+
+// SaveSession - inserts session into sessions table.
+func (s *dbAccountStore) SaveSession(ctx context.Context, sess auth.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, insertSession, sess.Hash, sess.UID, sess.CreatedAt, sess.ExpiresAt, sess.Roles)
+
+	return err
+}
+
+// SessionByHash - selects session with provided hash.
+func (s *dbAccountStore) SessionByHash(ctx context.Context, hash string) (auth.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var sess auth.Session
+	err := s.conn.QueryRow(ctx, selectSessionByHash, hash).Scan(&sess.Hash, &sess.UID, &sess.CreatedAt, &sess.ExpiresAt, &sess.Roles)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.Session{}, utils.ErrInvalidSession
+	}
+
+	return sess, err
+}
+
+// DeleteSession - removes session with provided hash.
+func (s *dbAccountStore) DeleteSession(ctx context.Context, hash string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, deleteSession, hash)
+
+	return err
+}
+
+// DeleteExpiredSessions - removes sessions expired before provided time.
+func (s *dbAccountStore) DeleteExpiredSessions(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.conn.Exec(ctx, deleteExpiredSessions, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// fileAccountStore - auth.AccountStore keeping accounts and sessions in memory and saving their changes to file
+// of fileStore.
+type fileAccountStore struct {
+	*auth.MemoryAccounts
+	file     *fileStore
+	logger   *zap.Logger
+	sessions map[string]time.Time // expiration of sessions by hash
+	mu       sync.Mutex
+}
+
+// fileAccount - data of account record. Unlike auth.Account it keeps password hash.
+type fileAccount struct {
+	CreatedAt    time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"password_hash"`
+}
+
+// newFileAccountStore - creates fileAccountStore with accounts and sessions previously saved to file.
+func newFileAccountStore(f *fileStore, l *zap.Logger) *fileAccountStore {
+	s := &fileAccountStore{MemoryAccounts: auth.NewMemoryAccounts(), file: f, logger: l, sessions: map[string]time.Time{}}
+
+	ctx := context.Background()
+	for _, r := range f.extraRecords(recordAccount, recordSession, recordSessionDeleted) {
+		var err error
+		switch r.Kind {
+		case recordAccount:
+			var a fileAccount
+			if err = json.Unmarshal(r.Data, &a); err == nil {
+				err = s.MemoryAccounts.CreateAccount(ctx, auth.Account(a))
+			}
+		case recordSession:
+			var session auth.Session
+			if err = json.Unmarshal(r.Data, &session); err == nil {
+				err = s.MemoryAccounts.SaveSession(ctx, session)
+				s.sessions[session.Hash] = session.ExpiresAt
+			}
+		case recordSessionDeleted:
+			err = s.MemoryAccounts.DeleteSession(ctx, r.Key)
+			delete(s.sessions, r.Key)
+		}
+		if err != nil {
+			l.Error(err.Error(), zap.Error(err))
+		}
+	}
+
+	return s
+}
+
+// CreateAccount - stores account and saves it to file.
+func (s *fileAccountStore) CreateAccount(ctx context.Context, a auth.Account) error {
+	data, err := json.Marshal(fileAccount(a))
+	if err != nil {
+		return err
+	}
+
+	if err = s.MemoryAccounts.CreateAccount(ctx, a); err != nil {
+		return err
+	}
+
+	return s.file.saveExtra(urlRecord{Key: a.Login, Kind: recordAccount, Data: data})
+}
+
+// SaveSession - stores session and saves it to file.
+func (s *fileAccountStore) SaveSession(ctx context.Context, session auth.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	if err = s.MemoryAccounts.SaveSession(ctx, session); err != nil {
+		return err
+	}
+	s.sessions[session.Hash] = session.ExpiresAt
+
+	return s.file.saveExtra(urlRecord{Key: session.Hash, Kind: recordSession, Data: data})
+}
+
+// DeleteSession - removes session and saves removal to file.
+func (s *fileAccountStore) DeleteSession(ctx context.Context, hash string) error {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	if err := s.MemoryAccounts.DeleteSession(ctx, hash); err != nil {
+		return err
+	}
+	delete(s.sessions, hash)
+
+	return s.file.saveExtra(urlRecord{Key: hash, Kind: recordSessionDeleted})
+}
+
+// DeleteExpiredSessions - removes sessions expired before provided time and saves their removal to file.
+func (s *fileAccountStore) DeleteExpiredSessions(ctx context.Context, before time.Time) (int, error) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	n, err := s.MemoryAccounts.DeleteExpiredSessions(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	records := make([]urlRecord, 0, n)
+	for hash, expiresAt := range s.sessions {
+		if expiresAt.Before(before) {
+			delete(s.sessions, hash)
+			records = append(records, urlRecord{Key: hash, Kind: recordSessionDeleted})
+		}
+	}
+	if len(records) == 0 {
+		return n, nil
+	}
+
+	return n, s.file.saveExtra(records...)
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var _ auth.KeyStore = (*dbKeyStore)(nil)
+
+// dbKeyStore - auth.KeyStore backed by api_keys table.
+type dbKeyStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	apiKeyColumns = `id, uid, name, hint, hash, scopes, rate_limit, created_at, last_used_at, revoked_at`
+	insertAPIKey  = `insert into api_keys (id, uid, name, hint, hash, scopes, rate_limit, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	selectAPIKeys      = `select ` + apiKeyColumns + ` from api_keys where $1 = '' or uid = $1 order by created_at, id`
+	selectAPIKeyByHash = `select ` + apiKeyColumns + ` from api_keys where hash = $1`
+	revokeAPIKey       = `update api_keys set revoked_at = coalesce(revoked_at, $2) where id::text = $1`
+	touchAPIKey        = `update api_keys set last_used_at = $2 where id::text = $1`
+)
+
+// NewKeyStore - creates auth.KeyStore for provided storage: api_keys table for database and in-memory store otherwise.
+func NewKeyStore(s Storage, l *zap.Logger) auth.KeyStore {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbKeyStore{conn: d.conn, logger: l}
+	}
+
+	return auth.NewMemoryKeys()
+}
+
+// SaveKey - inserts key into api_keys table.
+func (s *dbKeyStore) SaveKey(ctx context.Context, k auth.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, insertAPIKey, k.ID, k.UID, k.Name, k.Hint, k.Hash, k.Scopes, k.RateLimit, k.CreatedAt)
+
+	return err
+}
+
+// Keys - selects keys of user ordered by creation time, keys of all users if uid is empty.
+func (s *dbKeyStore) Keys(ctx context.Context, uid string) ([]auth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn.Query(ctx, selectAPIKeys, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]auth.APIKey, 0)
+	for rows.Next() {
+		k, errScan := scanAPIKey(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// KeyByHash - selects key with provided hash.
+func (s *dbKeyStore) KeyByHash(ctx context.Context, hash string) (auth.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	k, err := scanAPIKey(s.conn.QueryRow(ctx, selectAPIKeyByHash, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.APIKey{}, utils.ErrInvalidAPIKey
+	}
+
+	return k, err
+}
+
+// RevokeKey - sets revocation time of key, revocation time of already revoked key is kept.
+func (s *dbKeyStore) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.conn.Exec(ctx, revokeAPIKey, id, at)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return utils.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchKey - sets last usage time of key.
+func (s *dbKeyStore) TouchKey(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, touchAPIKey, id, at)
+
+	return err
+}
+
+// scanAPIKey - scans row selected with apiKeyColumns.
+func scanAPIKey(row pgx.Row) (auth.APIKey, error) {
+	var k auth.APIKey
+	err := row.Scan(&k.ID, &k.UID, &k.Name, &k.Hint, &k.Hash, &k.Scopes, &k.RateLimit, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt)
+
+	return k, err
+}
@@ -4,24 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
 	"github.com/sergalkin/go-url-shortener.git/internal/app/migrations"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
 )
 
 var _ DB = (*db)(nil)
+var _ Retention = (*db)(nil)
+var _ Merger = (*db)(nil)
+var _ Moderation = (*db)(nil)
+var _ Usage = (*db)(nil)
 
-// db - representation of *pgx.Conn and *zap.Logger
+// db - representation of *pgxpool.Pool and *zap.Logger
 type db struct {
-	conn   *pgx.Conn
+	conn   *pgxpool.Pool
+	seq    sequence.Generator
 	logger *zap.Logger
 }
 
@@ -48,9 +56,10 @@ type BatchLink struct {
 	ShortURL      string `json:"short_url"`
 }
 
+// BatchDelete - a representation of request to soft delete links of user.
 type BatchDelete struct {
-	UID string
-	Arr []string
+	UID string   `json:"uid"`
+	Arr []string `json:"keys"`
 }
 
 type DB interface {
@@ -60,18 +69,21 @@ type DB interface {
 	Close(ctx context.Context) error
 	// Store - stores given url into database
 	Store(key *string, url string, uid string)
+	// StoreContext - stores given url into database like Store and reports failure of storing.
+	StoreContext(ctx context.Context, key *string, url string, uid string) error
 	// Get - trying to retrieve a URL from database by provided key.
 	// Get - returns URL, bool as status of retrieval, bool as status was URL deleted or is it still present.
 	Get(key string) (string, bool, bool)
 	// LinksByUUID - trying to retrieve slice of UserURLs. On successful retrieval returns true as bool value
 	// and false of failure.
 	LinksByUUID(uuid string) ([]UserURLs, bool)
+	// FindLinks - returns page of links of user matching provided query.
+	FindLinks(ctx context.Context, q LinksQuery) (LinksPage, error)
 	// BatchInsert - mass insert provided links into database
-	BatchInsert([]BatchRequest, string) ([]BatchLink, error)
+	BatchInsert(context.Context, []BatchRequest, string) ([]BatchLink, error)
 	// SoftDeleteUserURLs - marks provided links as deleted. uuid - is user unique id, ids - is slice of links that
 	// needs to be marked as soft deleted.
 	SoftDeleteUserURLs(uuid string, ids []string) error
-	DeleteThroughCh(channels ...chan BatchDelete)
 	Stats() (int, int, error)
 
 	HasNotNilConn() bool
@@ -79,16 +91,71 @@ type DB interface {
 
 const (
 	getURLHash  = `select url_hash from links where url = $1`
-	insertLinks = `insert into links (url_hash, url, uid) values ($1,$2,$3) ON CONFLICT ON CONSTRAINT links_url_key DO NOTHING`
-	stats       = `select count(id) as links,  count(Distinct uid) as url from links where is_deleted = false`
+	insertLinks = `insert into links (url_hash, url, uid, created_by) select $1,$2,$3,$4
+		where not exists (select 1 from quarantined_keys where url_hash = $1 and expires_at > NOW())
+		and not exists (select 1 from links where url_hash = $1)
+		ON CONFLICT ON CONSTRAINT links_url_key DO NOTHING`
+	stats = `select count(id) as links,  count(Distinct uid) as url from links where is_deleted = false`
+
+	softDeleteLinks = `update links set is_deleted = true, deleted_at = NOW()
+		where uid = $1 and is_deleted = false and url_hash = ANY($2)`
+
+	isQuarantined = `select exists(select 1 from quarantined_keys where url_hash = $1 and expires_at > NOW())`
+	purgeLinks    = `delete from links where id in (
+		select id from links where is_deleted = true and deleted_at < $1 order by deleted_at limit $2
+	)`
+	purgeLinksWithQuarantine = `with purged as (
+		delete from links where id in (
+			select id from links where is_deleted = true and deleted_at < $1 order by deleted_at limit $2
+		) returning url_hash
+	)
+	insert into quarantined_keys (url_hash, expires_at) select url_hash, $3 from purged
+	on conflict (url_hash) do update set expires_at = excluded.expires_at`
+	forceDeleteLinksWithQuarantine = `with deleted as (
+		delete from links where url_hash = ANY($1) returning url_hash
+	)
+	insert into quarantined_keys (url_hash, expires_at) select url_hash, $2 from deleted
+	on conflict (url_hash) do update set expires_at = excluded.expires_at`
+	deleteExpiredQuarantine = `delete from quarantined_keys where expires_at <= NOW()`
+
+	mergeLinks = `with created as (update links set created_by = $2 where created_by = $1 and uid <> $1)
+		update links set uid = $2, created_by = case when created_by = $1 then $2 else created_by end where uid = $1`
+
+	selectLink = `select url, is_deleted or is_disabled or exists(select 1 from banned_users b where b.uid = links.uid)
+		from links where url_hash = $1`
+	setLinksDisabled = `update links set is_disabled = $2 where url_hash = ANY($1) and is_disabled <> $2`
+	forceDeleteLinks = `delete from links where url_hash = ANY($1)`
+	insertBan        = `insert into banned_users (uid, reason, banned_at) values ($1, $2, $3)
+		on conflict (uid) do update set reason = excluded.reason`
+	deleteBan    = `delete from banned_users where uid = $1`
+	selectBanned = `select exists(select 1 from banned_users where uid = $1)`
+	selectBans   = `select uid, reason, banned_at from banned_users order by banned_at`
 )
 
+// txKey - key of transaction of QuotaLocker in context.
+type txKey struct{}
+
+// querier - methods shared by pool and transaction.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// withTx - returns copy of ctx carrying transaction, so queries made with ctx are part of it.
+func withTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// likeEscaper - escapes wildcards of LIKE pattern, so search text is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 // NewDBConnection - creates new database connection and attempts to run migrations.
 func NewDBConnection(l *zap.Logger, isNeedToRunMigrations bool) (*db, error) {
-	var database = &db{conn: nil, logger: l}
+	var database = &db{conn: nil, seq: sequence.NewSequence(), logger: l}
 
 	if len(config.DatabaseDSN()) > 0 {
-		conn, err := pgx.Connect(context.Background(), config.DatabaseDSN())
+		conn, err := pgxpool.Connect(context.Background(), config.DatabaseDSN())
 		if err != nil {
 			return nil, err
 		}
@@ -117,41 +184,68 @@ func (d *db) Ping(ctx context.Context) error {
 // Close - closes connection with database.
 func (d *db) Close(ctx context.Context) error {
 	if d.conn != nil {
-		err := d.conn.Close(ctx)
-		if err != nil {
-			return err
-		}
+		d.conn.Close()
 		d.conn = nil
 	}
 
 	return nil
 }
 
-// Store - stores provided url by key in database
-func (d *db) Store(key *string, url string, uid string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// querier - returns transaction of QuotaLocker carried by ctx or pool otherwise.
+func (d *db) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
 
-	r, err := d.conn.Exec(ctx, insertLinks, *key, url, uid)
-	if err != nil {
+	return d.conn
+}
+
+// Store - stores provided url by key in database, see StoreContext. Errors of storing are logged.
+func (d *db) Store(key *string, url string, uid string) {
+	err := d.StoreContext(context.Background(), key, url, uid)
+	if err != nil && !errors.Is(err, utils.ErrLinksConflict) {
 		d.logger.Error(err.Error(), zap.Error(err))
 	}
+}
 
-	if r.RowsAffected() == 0 {
-		row := d.conn.QueryRow(ctx, getURLHash, url)
+// StoreContext - stores provided url by key in database. If url is already stored, key is replaced by key of stored
+// link and utils.ErrLinksConflict is returned. If key is used or quarantined, it's replaced by a new one of the same
+// length. Link is charged to Creator of ctx.
+func (d *db) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-		var tempKey *string
-		err = row.Scan(&tempKey)
+	q := d.querier(ctx)
+	creator := Creator(ctx, uid)
+	for {
+		r, err := q.Exec(ctx, insertLinks, *key, url, uid, creator)
 		if err != nil {
-			d.logger.Error(err.Error(), zap.Error(err))
+			return err
+		}
+		if r.RowsAffected() > 0 {
+			return nil
 		}
 
-		*key = *tempKey
+		var storedKey string
+		err = q.QueryRow(ctx, getURLHash, url).Scan(&storedKey)
+		if err == nil {
+			*key = storedKey
+			return utils.ErrLinksConflict
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		if *key, err = d.seq.Generate(len(*key)); err != nil {
+			return err
+		}
 	}
 }
 
 // Get - attempt to get url from database by its key
 // returns url, bool representation of was url found, bool representation of was url soft deleted.
+// Purged links with not expired quarantine are reported as found and soft deleted.
+// Disabled links and links of banned users are reported as soft deleted.
 func (d *db) Get(key string) (string, bool, bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -159,8 +253,12 @@ func (d *db) Get(key string) (string, bool, bool) {
 	var url string
 	var isDeleted bool
 
-	q := fmt.Sprintf("select url,is_deleted from links where url_hash = '%s'", key)
-	if err := d.conn.QueryRow(ctx, q).Scan(&url, &isDeleted); err != nil {
+	if err := d.conn.QueryRow(ctx, selectLink, key).Scan(&url, &isDeleted); err != nil {
+		var quarantined bool
+		if errQ := d.conn.QueryRow(ctx, isQuarantined, key).Scan(&quarantined); errQ == nil && quarantined {
+			return "", true, true
+		}
+
 		return "", false, false
 	}
 
@@ -210,27 +308,35 @@ func (d *db) LinksByUUID(uuid string) ([]UserURLs, bool) {
 
 // BatchInsert - batch insert links to database with CorrelationID
 // additionally adds uuid to uid column in database gotten form uid cookie.
-func (d *db) BatchInsert(br []BatchRequest, uid string) ([]BatchLink, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// Quarantined keys are not reissued, keys are generated again instead. Links are inserted in transaction
+// of QuotaLocker if ctx carries one and are charged to Creator of ctx.
+func (d *db) BatchInsert(ctx context.Context, br []BatchRequest, uid string) ([]BatchLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
-	tx, err := d.conn.Begin(ctx)
+	tx, err := d.querier(ctx).Begin(ctx)
 	if err != nil {
-		fmt.Println(err)
+		return []BatchLink{}, err
 	}
 	defer tx.Rollback(ctx)
 
-	seqGenerator := sequence.NewSequence()
 	batchLinks := make([]BatchLink, 0)
 
-	q := "insert into links(url_hash, url, uid, correlation_id) values ($1, $2, $3, $4)"
+	q := "insert into links(url_hash, url, uid, correlation_id, created_by) values ($1, $2, $3, $4, $5)"
+	creator := Creator(ctx, uid)
 	for _, val := range br {
-		urlHash, errGen := seqGenerator.Generate(5)
-		if errGen != nil {
-			return []BatchLink{}, errGen
+		var urlHash string
+		for quarantined := true; quarantined; {
+			urlHash, err = d.seq.Generate(5)
+			if err != nil {
+				return []BatchLink{}, err
+			}
+			if err = tx.QueryRow(ctx, isQuarantined, urlHash).Scan(&quarantined); err != nil {
+				return []BatchLink{}, err
+			}
 		}
 
-		_, err = tx.Exec(ctx, q, urlHash, val.OriginalURL, uid, val.CorrelationID)
+		_, err = tx.Exec(ctx, q, urlHash, val.OriginalURL, uid, val.CorrelationID, creator)
 		if err != nil {
 			return []BatchLink{}, err
 		}
@@ -248,86 +354,361 @@ func (d *db) BatchInsert(br []BatchRequest, uid string) ([]BatchLink, error) {
 	return batchLinks, nil
 }
 
-// DeleteThroughCh - Soft deletes URL using channels ang go routine.
-func (d *db) DeleteThroughCh(channels ...chan BatchDelete) {
-	out := fanIn(channels...)
+// SoftDeleteUserURLs - marks URL as deleted in database.
+func (d *db) SoftDeleteUserURLs(uuid string, ids []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
 
-	for c := range out {
-		go func() {
-			err := d.SoftDeleteUserURLs(c.UID, c.Arr)
-			if err != nil {
-				d.logger.Error(err.Error(), zap.Error(err))
-			}
-		}()
-		close(out)
+	_, err := d.conn.Exec(ctx, softDeleteLinks, uuid, ids)
+
+	return err
+}
+
+func (d *db) HasNotNilConn() bool {
+	return d.conn != nil
+}
+
+// Stats - returns count of urls and users stored in DB. Counts only non-soft deleted records.
+func (d *db) Stats() (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var url, users int
+	if err := d.conn.QueryRow(ctx, stats).Scan(&url, &users); err != nil {
+		return 0, 0, err
+	}
+
+	return url, users, nil
+}
+
+// PurgeDeleted - removes up to limit links soft deleted before provided time. If quarantineUntil is not zero,
+// url_hash of removed links is stored in quarantined_keys table until that time.
+func (d *db) PurgeDeleted(ctx context.Context, before time.Time, quarantineUntil time.Time, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var (
+		r   pgconn.CommandTag
+		err error
+	)
+
+	if quarantineUntil.IsZero() {
+		r, err = d.conn.Exec(ctx, purgeLinks, before, limit)
+	} else {
+		r, err = d.conn.Exec(ctx, purgeLinksWithQuarantine, before, limit, quarantineUntil)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return int(r.RowsAffected()), nil
+}
+
+// ReleaseQuarantine - removes expired keys from quarantined_keys table.
+func (d *db) ReleaseQuarantine(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	r, err := d.conn.Exec(ctx, deleteExpiredQuarantine)
+	if err != nil {
+		return 0, err
 	}
+
+	return int(r.RowsAffected()), nil
 }
 
-func fanIn(channels ...chan BatchDelete) chan BatchDelete {
-	outCh := make(chan BatchDelete)
+// MergeUserLinks - moves all links of user from to user to. Links created by user from for workspaces are charged
+// to user to.
+func (d *db) MergeUserLinks(ctx context.Context, from string, to string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := d.conn.Exec(ctx, mergeLinks, from, to)
+	if err != nil {
+		return 0, err
+	}
 
-	go func() {
-		wg := &sync.WaitGroup{}
+	return int(tag.RowsAffected()), nil
+}
 
-		for _, ch := range channels {
-			wg.Add(1)
+// FindLinks - returns page of links of user matching provided query. Links are read with keyset pagination over
+// (created_at, id), so every page is served by links_uid_created_at_idx regardless of its depth.
+func (d *db) FindLinks(ctx context.Context, q LinksQuery) (LinksPage, error) {
+	var (
+		cursor    linksCursor
+		cursorID  int64
+		hasCursor = q.Cursor != ""
+	)
+
+	if hasCursor {
+		var err error
+		if cursor, err = decodeCursor(q.Cursor); err != nil {
+			return LinksPage{}, err
+		}
 
-			go func(ch chan BatchDelete) {
-				defer wg.Done()
-				for i := range ch {
-					outCh <- i
-				}
-			}(ch)
+		if cursorID, err = strconv.ParseInt(cursor.ID, 10, 64); err != nil {
+			return LinksPage{}, utils.ErrInvalidCursor
 		}
+	}
 
-		wg.Wait()
-		close(outCh)
-	}()
+	args := []interface{}{q.UID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
 
-	return outCh
+	var sb strings.Builder
+	sb.WriteString(`select id, url_hash, url, created_at from links where uid = $1`)
+
+	if !q.CreatedAfter.IsZero() {
+		sb.WriteString(` and created_at > ` + arg(q.CreatedAfter))
+	}
+	if q.Deleted != nil {
+		sb.WriteString(` and is_deleted = ` + arg(*q.Deleted))
+	}
+	if q.Domain != "" {
+		sb.WriteString(` and lower(substring(url from '^[^:]+://(?:[^@/]*@)?([^/:?#]+)')) = ` + arg(strings.ToLower(q.Domain)))
+	}
+	if q.Search != "" {
+		sb.WriteString(` and url ilike ` + arg("%"+likeEscaper.Replace(q.Search)+"%"))
+	}
+
+	order, compare := "asc", ">"
+	if q.Sort == SortCreatedDesc {
+		order, compare = "desc", "<"
+	}
+
+	if hasCursor {
+		sb.WriteString(fmt.Sprintf(` and (created_at, id) %s (%s, %s)`, compare, arg(cursor.At), arg(cursorID)))
+	}
+
+	sb.WriteString(fmt.Sprintf(` order by created_at %s, id %s`, order, order))
+	if q.Limit > 0 {
+		// one more link is read to find out whether there is next page
+		sb.WriteString(` limit ` + arg(q.Limit+1))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, sb.String(), args...)
+	if err != nil {
+		d.logger.Error(err.Error(), zap.Error(err))
+		return LinksPage{}, err
+	}
+	defer rows.Close()
+
+	var (
+		page LinksPage
+		last linkRow
+	)
+	for rows.Next() {
+		var r linkRow
+		if err = rows.Scan(&r.ID, &r.URLHash, &r.URL, &r.CreatedAt); err != nil {
+			d.logger.Error(err.Error(), zap.Error(err))
+			return LinksPage{}, err
+		}
+
+		if q.Limit > 0 && len(page.Links) == q.Limit {
+			page.NextCursor = encodeCursor(linksCursor{At: last.CreatedAt, ID: strconv.FormatInt(last.ID, 10)})
+			break
+		}
+
+		page.Links = append(page.Links, UserURLs{ShortURL: r.URLHash, OriginalURL: r.URL})
+		last = r
+	}
+
+	if err = rows.Err(); err != nil {
+		d.logger.Error(err.Error(), zap.Error(err))
+		return LinksPage{}, err
+	}
+
+	return page, nil
 }
 
-// SoftDeleteUserURLs - marks URL as deleted in database.
-func (d *db) SoftDeleteUserURLs(uuid string, ids []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// SearchLinks - returns page of links of all users matching provided query with keyset pagination
+// over (created_at, id).
+func (d *db) SearchLinks(ctx context.Context, q SearchQuery) (SearchPage, error) {
+	var (
+		cursor   linksCursor
+		cursorID int64
+	)
+
+	if q.Cursor != "" {
+		var err error
+		if cursor, err = decodeCursor(q.Cursor); err != nil {
+			return SearchPage{}, err
+		}
+
+		if cursorID, err = strconv.ParseInt(cursor.ID, 10, 64); err != nil {
+			return SearchPage{}, utils.ErrInvalidCursor
+		}
+	}
+
+	args := make([]interface{}, 0, 6)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`select id, url_hash, url, uid, created_at, is_deleted, is_disabled from links where true`)
+
+	if q.Key != "" {
+		sb.WriteString(` and url_hash = ` + arg(q.Key))
+	}
+	if q.UID != "" {
+		sb.WriteString(` and uid = ` + arg(q.UID))
+	}
+	if q.URL != "" {
+		sb.WriteString(` and url ilike ` + arg("%"+likeEscaper.Replace(q.URL)+"%"))
+	}
+	if q.Cursor != "" {
+		sb.WriteString(fmt.Sprintf(` and (created_at, id) > (%s, %s)`, arg(cursor.At), arg(cursorID)))
+	}
+
+	sb.WriteString(` order by created_at, id`)
+	if q.Limit > 0 {
+		// one more link is read to find out whether there is next page
+		sb.WriteString(` limit ` + arg(q.Limit+1))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	tx, err := d.conn.Begin(ctx)
+	rows, err := d.conn.Query(ctx, sb.String(), args...)
 	if err != nil {
 		d.logger.Error(err.Error(), zap.Error(err))
+		return SearchPage{}, err
 	}
-	defer tx.Rollback(ctx)
+	defer rows.Close()
+
+	page := SearchPage{Links: make([]LinkInfo, 0)}
+	var lastID int64
+	for rows.Next() {
+		var (
+			r    linkRow
+			info LinkInfo
+		)
+		if err = rows.Scan(&r.ID, &r.URLHash, &r.URL, &r.UID, &r.CreatedAt, &r.IsDeleted, &info.IsDisabled); err != nil {
+			d.logger.Error(err.Error(), zap.Error(err))
+			return SearchPage{}, err
+		}
+
+		if q.Limit > 0 && len(page.Links) == q.Limit {
+			last := page.Links[len(page.Links)-1]
+			page.NextCursor = encodeCursor(linksCursor{At: last.CreatedAt, ID: strconv.FormatInt(lastID, 10)})
+			break
+		}
 
-	stmt := fmt.Sprintf("update links set is_deleted = true where uid = '%s' and url_hash in ('%s')",
-		uuid,
-		strings.Join(ids, "','"))
+		info.CreatedAt = r.CreatedAt
+		info.Key = r.URLHash
+		info.OriginalURL = r.URL
+		info.UID = r.UID.String()
+		info.IsDeleted = r.IsDeleted
+		page.Links = append(page.Links, info)
+		lastID = r.ID
+	}
+
+	if err = rows.Err(); err != nil {
+		d.logger.Error(err.Error(), zap.Error(err))
+		return SearchPage{}, err
+	}
+
+	return page, nil
+}
 
-	_, err = tx.Exec(ctx, stmt)
+// SetLinksDisabled - sets is_disabled flag of links with provided keys.
+func (d *db) SetLinksDisabled(ctx context.Context, keys []string, disabled bool) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := d.conn.Exec(ctx, setLinksDisabled, keys, disabled)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = tx.Commit(ctx)
+	return int(tag.RowsAffected()), nil
+}
+
+// ForceDeleteLinks - removes links with provided keys regardless of their owner. If quarantineUntil is not zero,
+// url_hash of removed links is stored in quarantined_keys table until that time.
+func (d *db) ForceDeleteLinks(ctx context.Context, keys []string, quarantineUntil time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var (
+		tag pgconn.CommandTag
+		err error
+	)
+
+	if quarantineUntil.IsZero() {
+		tag, err = d.conn.Exec(ctx, forceDeleteLinks, keys)
+	} else {
+		tag, err = d.conn.Exec(ctx, forceDeleteLinksWithQuarantine, keys, quarantineUntil)
+	}
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return int(tag.RowsAffected()), nil
 }
 
-func (d *db) HasNotNilConn() bool {
-	return d.conn != nil
+// BanUser - inserts ban into banned_users table or updates reason of existing one.
+func (d *db) BanUser(ctx context.Context, b Ban) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := d.conn.Exec(ctx, insertBan, b.UID, b.Reason, b.BannedAt)
+
+	return err
 }
 
-// Stats - returns count of urls and users stored in DB. Counts only non-soft deleted records.
-func (d *db) Stats() (int, int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// UnbanUser - removes ban of user from banned_users table.
+func (d *db) UnbanUser(ctx context.Context, uid string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var url, users int
-	if err := d.conn.QueryRow(ctx, stats).Scan(&url, &users); err != nil {
-		return 0, 0, err
+	_, err := d.conn.Exec(ctx, deleteBan, uid)
+
+	return err
+}
+
+// IsBanned - reports whether user is in banned_users table.
+func (d *db) IsBanned(ctx context.Context, uid string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var banned bool
+	err := d.conn.QueryRow(ctx, selectBanned, uid).Scan(&banned)
+
+	return banned, err
+}
+
+// Bans - selects all bans ordered by time.
+func (d *db) Bans(ctx context.Context) ([]Ban, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, selectBans)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return url, users, nil
+	bans := make([]Ban, 0)
+	for rows.Next() {
+		var (
+			b   Ban
+			uid uuid.UUID
+		)
+		if err = rows.Scan(&uid, &b.Reason, &b.BannedAt); err != nil {
+			return nil, err
+		}
+
+		b.UID = uid.String()
+		bans = append(bans, b)
+	}
+
+	return bans, rows.Err()
 }
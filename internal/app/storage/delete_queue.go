@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// maxCoalescedRequests - max amount of queued requests merged by worker into one batch.
+const maxCoalescedRequests = 100
+
+// deleteQueueDepth - amount of delete requests waiting in queues, published in expvar as delete_queue_depth.
+var deleteQueueDepth = expvar.NewInt("delete_queue_depth")
+
+// Deleter - storage that can soft delete links of user.
+type Deleter interface {
+	SoftDeleteUserURLs(uuid string, ids []string) error
+}
+
+// DeleteQueuer - accepts delete requests for asynchronous processing.
+type DeleteQueuer interface {
+	Enqueue(r BatchDelete) error
+}
+
+var _ DeleteQueuer = (*DeleteQueue)(nil)
+
+// DeleteQueue - bounded queue of delete requests processed by pool of workers.
+// Requests of one user that are waiting in queue are merged into one call of Deleter.
+type DeleteQueue struct {
+	deleter Deleter
+	logger  *zap.Logger
+	queue   chan BatchDelete
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// NewDeleteQueue - creates DeleteQueue with size and amount of workers taken from config.
+// Workers are not started until Start is called.
+func NewDeleteQueue(d Deleter, l *zap.Logger) *DeleteQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &DeleteQueue{
+		deleter: d,
+		logger:  l,
+		queue:   make(chan BatchDelete, config.DeleteQueueSize()),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start - starts config.DeleteWorkers workers.
+func (q *DeleteQueue) Start() {
+	workers := config.DeleteWorkers()
+	if workers <= 0 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Enqueue - puts delete request in queue without waiting.
+// Returns utils.ErrDeleteQueueFull if queue is full and utils.ErrDeleteQueueDone after Shutdown.
+func (q *DeleteQueue) Enqueue(r BatchDelete) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return utils.ErrDeleteQueueDone
+	}
+
+	select {
+	case q.queue <- r:
+		deleteQueueDepth.Add(1)
+		return nil
+	default:
+		return utils.ErrDeleteQueueFull
+	}
+}
+
+// Depth - returns amount of requests waiting in queue.
+func (q *DeleteQueue) Depth() int {
+	return len(q.queue)
+}
+
+// Shutdown - stops accepting new requests and waits until already queued ones are processed.
+// If ctx is done earlier, pending retries are aborted and ctx error is returned.
+func (q *DeleteQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
+
+// work - takes requests from queue, merges them by user and soft deletes links until queue is closed.
+func (q *DeleteQueue) work() {
+	defer q.wg.Done()
+
+	for r := range q.queue {
+		deleteQueueDepth.Add(-1)
+
+		for _, batch := range q.collect(r) {
+			q.deleteWithRetry(batch)
+		}
+	}
+}
+
+// collect - takes requests that are already in queue without waiting for new ones and merges them with first.
+func (q *DeleteQueue) collect(first BatchDelete) []BatchDelete {
+	requests := []BatchDelete{first}
+
+	for len(requests) < maxCoalescedRequests {
+		select {
+		case r, ok := <-q.queue:
+			if !ok {
+				return mergeBatchDeletes(requests)
+			}
+
+			deleteQueueDepth.Add(-1)
+			requests = append(requests, r)
+		default:
+			return mergeBatchDeletes(requests)
+		}
+	}
+
+	return mergeBatchDeletes(requests)
+}
+
+// deleteWithRetry - soft deletes batch retrying config.DeleteRetries times with exponential backoff.
+func (q *DeleteQueue) deleteWithRetry(batch BatchDelete) {
+	backoff := config.DeleteRetryBackoff()
+
+	for attempt := 0; ; attempt++ {
+		err := q.deleter.SoftDeleteUserURLs(batch.UID, batch.Arr)
+		if err == nil {
+			return
+		}
+
+		if attempt >= config.DeleteRetries() {
+			q.logger.Error("could not soft delete links", zap.String("uid", batch.UID), zap.Error(err))
+			return
+		}
+
+		select {
+		case <-q.ctx.Done():
+			q.logger.Error("soft delete aborted on shutdown", zap.String("uid", batch.UID), zap.Error(err))
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// mergeBatchDeletes - merges requests of the same user into one, keeping order of users and dropping duplicate keys.
+func mergeBatchDeletes(requests []BatchDelete) []BatchDelete {
+	merged := make([]BatchDelete, 0, len(requests))
+	index := make(map[string]int, len(requests))
+	seen := make(map[string]map[string]struct{}, len(requests))
+
+	for _, r := range requests {
+		i, ok := index[r.UID]
+		if !ok {
+			i = len(merged)
+			index[r.UID] = i
+			seen[r.UID] = map[string]struct{}{}
+			merged = append(merged, BatchDelete{UID: r.UID, Arr: make([]string, 0, len(r.Arr))})
+		}
+
+		for _, key := range r.Arr {
+			if _, dup := seen[r.UID][key]; dup {
+				continue
+			}
+
+			seen[r.UID][key] = struct{}{}
+			merged[i].Arr = append(merged[i].Arr, key)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+type deleterMock struct {
+	mu       sync.Mutex
+	calls    []BatchDelete
+	failures int
+}
+
+func (d *deleterMock) SoftDeleteUserURLs(uuid string, ids []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.failures > 0 {
+		d.failures--
+		return errors.New("error")
+	}
+
+	d.calls = append(d.calls, BatchDelete{UID: uuid, Arr: ids})
+	return nil
+}
+
+func TestDeleteQueue_Enqueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		requests int
+		wantErr  error
+	}{
+		{
+			name:     "Request can be enqueued",
+			size:     2,
+			requests: 2,
+		},
+		{
+			name:     "Error is returned if queue is full",
+			size:     1,
+			requests: 2,
+			wantErr:  utils.ErrDeleteQueueFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(config.WithDeleteQueueSize(tt.size))
+			defer config.NewConfig(config.WithDeleteQueueSize(1000))
+
+			q := NewDeleteQueue(&deleterMock{}, zap.NewNop())
+
+			var err error
+			for i := 0; i < tt.requests; i++ {
+				err = q.Enqueue(BatchDelete{UID: "1", Arr: []string{"a"}})
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.size, q.Depth())
+		})
+	}
+}
+
+func TestDeleteQueue_Shutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls []BatchDelete
+	}{
+		{
+			name: "Queued requests are merged by user and processed on shutdown",
+			wantCalls: []BatchDelete{
+				{UID: "1", Arr: []string{"a", "b", "c"}},
+				{UID: "2", Arr: []string{"d"}},
+			},
+		},
+		{
+			name:     "Failed request is retried",
+			failures: 2,
+			wantCalls: []BatchDelete{
+				{UID: "1", Arr: []string{"a", "b", "c"}},
+				{UID: "2", Arr: []string{"d"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.NewConfig(config.WithDeleteWorkers(1))
+			defer config.NewConfig(config.WithDeleteWorkers(4))
+
+			d := &deleterMock{failures: tt.failures}
+			q := NewDeleteQueue(d, zap.NewNop())
+
+			require.NoError(t, q.Enqueue(BatchDelete{UID: "1", Arr: []string{"a", "b"}}))
+			require.NoError(t, q.Enqueue(BatchDelete{UID: "2", Arr: []string{"d"}}))
+			require.NoError(t, q.Enqueue(BatchDelete{UID: "1", Arr: []string{"b", "c"}}))
+
+			q.Start()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			require.NoError(t, q.Shutdown(ctx))
+			assert.Equal(t, tt.wantCalls, d.calls)
+			assert.ErrorIs(t, q.Enqueue(BatchDelete{UID: "1"}), utils.ErrDeleteQueueDone)
+		})
+	}
+}
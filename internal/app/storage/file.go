@@ -2,39 +2,86 @@ package storage
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"os"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
-
-	"github.com/sergalkin/go-url-shortener.git/internal/app/middleware"
-	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
 )
 
 // if File struct will no longer complains with Storage interface, code will be broken on building stage
 var _ Storage = (*fileStore)(nil)
+var _ Retention = (*fileStore)(nil)
+var _ Merger = (*fileStore)(nil)
+var _ Moderation = (*fileStore)(nil)
+var _ Usage = (*fileStore)(nil)
 
 type fileStore struct {
-	logger   *zap.Logger
-	urls     map[string]string
-	userURLs map[string][]UserURLs
-	filePath string
-	mu       sync.Mutex
+	logger      *zap.Logger
+	urls        map[string]string
+	userURLs    map[string][]UserURLs
+	createdAt   map[string]time.Time
+	deletedAt   map[string]time.Time
+	quarantined map[string]time.Time
+	disabled    map[string]struct{}
+	banned      map[string]Ban
+	creators    map[string]string // creators of links created for other owners, like workspaces
+	extras      []urlRecord
+	filePath    string
+	mu          sync.Mutex
 }
 
+// Kinds of records that are not links, but changes of already stored links.
+const (
+	recordDeleted     = "deleted"
+	recordQuarantined = "quarantined"
+	recordDisabled    = "disabled"
+	recordEnabled     = "enabled"
+	recordBanned      = "banned"
+	recordUnbanned    = "unbanned"
+)
+
+// Kinds of records of workspaces, accounts and quota overrides, that are stored in the same file as links.
+const (
+	recordWorkspace       = "workspace"
+	recordMember          = "member"
+	recordMemberRemoved   = "member_removed"
+	recordAccount         = "account"
+	recordSession         = "session"
+	recordSessionDeleted  = "session_deleted"
+	recordQuotaOverride   = "quota_override"
+	recordQuotaOverridden = "quota_override_deleted"
+)
+
 type urlRecord struct {
-	Key string `json:"key"`
-	URL string `json:"URL"`
+	Key              string          `json:"key"`
+	URL              string          `json:"URL"`
+	UID              string          `json:"uid,omitempty"`
+	Creator          string          `json:"creator,omitempty"`
+	Kind             string          `json:"kind,omitempty"`
+	CreatedAt        *time.Time      `json:"created_at,omitempty"`
+	DeletedAt        *time.Time      `json:"deleted_at,omitempty"`
+	QuarantinedUntil *time.Time      `json:"quarantined_until,omitempty"`
+	BannedAt         *time.Time      `json:"banned_at,omitempty"`
+	Reason           string          `json:"reason,omitempty"`
+	Data             json.RawMessage `json:"data,omitempty"`
 }
 
 // NewFile - creates new fileStore struct.
 func NewFile(fileStoragePath string, l *zap.Logger) *fileStore {
 	fs := fileStore{
-		urls:     map[string]string{},
-		userURLs: map[string][]UserURLs{},
-		filePath: fileStoragePath,
-		logger:   l,
+		urls:        map[string]string{},
+		userURLs:    map[string][]UserURLs{},
+		createdAt:   map[string]time.Time{},
+		deletedAt:   map[string]time.Time{},
+		quarantined: map[string]time.Time{},
+		disabled:    map[string]struct{}{},
+		banned:      map[string]Ban{},
+		creators:    map[string]string{},
+		filePath:    fileStoragePath,
+		logger:      l,
 	}
 
 	if err := fs.loadFromFile(); err != nil {
@@ -53,12 +100,12 @@ func (m *fileStore) loadFromFile() error {
 	}
 	defer f.Close()
 
-	r := &urlRecord{}
 	scanner := bufio.NewScanner(f)
 
 	for scanner.Scan() {
+		r := &urlRecord{}
 		if err := json.Unmarshal(scanner.Bytes(), r); err == nil {
-			m.urls[r.Key] = r.URL
+			m.applyRecord(r)
 		}
 	}
 
@@ -69,30 +116,100 @@ func (m *fileStore) loadFromFile() error {
 	return nil
 }
 
+// applyRecord - applies record read from file to fileStore maps.
+func (m *fileStore) applyRecord(r *urlRecord) {
+	switch r.Kind {
+	case recordDeleted:
+		if r.DeletedAt != nil {
+			m.deletedAt[r.Key] = *r.DeletedAt
+		}
+	case recordQuarantined:
+		if r.QuarantinedUntil != nil && r.QuarantinedUntil.After(time.Now()) {
+			m.quarantined[r.Key] = *r.QuarantinedUntil
+		}
+	case recordDisabled:
+		m.disabled[r.Key] = struct{}{}
+	case recordEnabled:
+		delete(m.disabled, r.Key)
+	case recordBanned:
+		b := Ban{UID: r.UID, Reason: r.Reason}
+		if r.BannedAt != nil {
+			b.BannedAt = *r.BannedAt
+		}
+		m.banned[r.UID] = b
+	case recordUnbanned:
+		delete(m.banned, r.UID)
+	case recordWorkspace, recordMember, recordMemberRemoved, recordAccount, recordSession, recordSessionDeleted,
+		recordQuotaOverride, recordQuotaOverridden:
+		m.extras = append(m.extras, *r)
+	default:
+		m.urls[r.Key] = r.URL
+		delete(m.disabled, r.Key)
+		if r.CreatedAt != nil {
+			m.createdAt[r.Key] = *r.CreatedAt
+		}
+		if r.UID != "" {
+			m.userURLs[r.UID] = append(m.userURLs[r.UID], UserURLs{ShortURL: r.Key, OriginalURL: r.URL})
+		}
+		if r.Creator != "" {
+			setCreator(m.creators, r.Key, r.UID, r.Creator)
+		}
+		if r.DeletedAt != nil {
+			m.deletedAt[r.Key] = *r.DeletedAt
+		}
+	}
+}
+
 // Store - store provided url in urls of fileStore struct using provided key
-// additionally gets UUID from cookie uid and stores that URL in userURL of fileStore struct
-// finally saves generated URL to file.
+// additionally stores that URL in userURL of fileStore struct for provided user
+// finally saves generated URL to file. If key is quarantined, it's replaced by a new one.
 func (m *fileStore) Store(key *string, url string, uid string) {
 	defer m.mu.Unlock()
 	m.mu.Lock()
 
-	m.urls[*key] = url
-
-	var uuid string
-	err := utils.Decode(middleware.GetUUID(), &uuid)
-	if err != nil {
+	if err := replaceQuarantinedKey(key, m.urls, m.quarantined, false); err != nil {
 		m.logger.Error(err.Error(), zap.Error(err))
+		return
 	}
 
-	m.userURLs[uuid] = append(m.userURLs[uuid], UserURLs{ShortURL: *key, OriginalURL: url})
-
-	if err := m.saveToFile(*key, url); err != nil {
+	if err := m.store(*key, url, uid, uid); err != nil {
 		m.logger.Fatal(err.Error())
 	}
 }
 
+// StoreContext - stores provided url in fileStore and file. If key is used or quarantined, it's replaced by a new one.
+// Link is charged to Creator of ctx.
+func (m *fileStore) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if err := replaceQuarantinedKey(key, m.urls, m.quarantined, true); err != nil {
+		return err
+	}
+
+	return m.store(*key, url, uid, Creator(ctx, uid))
+}
+
+// store - puts link in maps of fileStore and saves it to file.
+func (m *fileStore) store(key string, url string, uid string, creator string) error {
+	now := time.Now()
+	m.urls[key] = url
+	m.createdAt[key] = now
+	delete(m.disabled, key)
+	setCreator(m.creators, key, uid, creator)
+
+	m.userURLs[uid] = append(m.userURLs[uid], UserURLs{ShortURL: key, OriginalURL: url})
+
+	r := urlRecord{Key: key, URL: url, UID: uid, CreatedAt: &now}
+	if creator != uid {
+		r.Creator = creator
+	}
+
+	return m.saveToFile(r)
+}
+
 // saveToFile - dumping to file urlRecord using json.Encode.
-func (m *fileStore) saveToFile(key, url string) error {
+func (m *fileStore) saveToFile(records ...urlRecord) error {
 	f, err := os.OpenFile(m.filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 
 	if err != nil {
@@ -101,16 +218,176 @@ func (m *fileStore) saveToFile(key, url string) error {
 	defer f.Close()
 
 	e := json.NewEncoder(f)
-	return e.Encode(urlRecord{key, url})
+	for _, r := range records {
+		if err := e.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveExtra - appends record of workspaces, accounts or quota overrides to file.
+func (m *fileStore) saveExtra(records ...urlRecord) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.extras = append(m.extras, records...)
+
+	return m.saveToFile(records...)
+}
+
+// extraRecords - returns saved records of provided kinds in order they were saved.
+func (m *fileStore) extraRecords(kinds ...string) []urlRecord {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	records := make([]urlRecord, 0)
+	for _, r := range m.extras {
+		for _, kind := range kinds {
+			if r.Kind == kind {
+				records = append(records, r)
+				break
+			}
+		}
+	}
+
+	return records
+}
+
+// compactExtras - keeps only last record of every workspace, member, account, session and quota override,
+// dropping removed ones, so rewritten file doesn't grow with history of changes.
+func (m *fileStore) compactExtras() {
+	removals := map[string]string{
+		recordMemberRemoved:   recordMember,
+		recordSessionDeleted:  recordSession,
+		recordQuotaOverridden: recordQuotaOverride,
+	}
+
+	order := make([]string, 0, len(m.extras))
+	last := make(map[string]urlRecord, len(m.extras))
+	for _, r := range m.extras {
+		kind, removed := removals[r.Kind]
+		if !removed {
+			kind = r.Kind
+		}
+		id := kind + "\x00" + r.Key + "\x00" + r.UID
+
+		if removed {
+			delete(last, id)
+			continue
+		}
+		if _, ok := last[id]; !ok {
+			order = append(order, id)
+		}
+		last[id] = r
+	}
+
+	extras := make([]urlRecord, 0, len(last))
+	for _, id := range order {
+		if r, ok := last[id]; ok {
+			extras = append(extras, r)
+			delete(last, id)
+		}
+	}
+	m.extras = extras
+}
+
+// rewriteFile - replaces file with current state of fileStore, so purged links are physically removed from it.
+func (m *fileStore) rewriteFile() error {
+	tmpPath := m.filePath + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	e := json.NewEncoder(w)
+
+	owners := make(map[string]string, len(m.urls))
+	for uid, links := range m.userURLs {
+		for _, l := range links {
+			owners[l.ShortURL] = uid
+		}
+	}
+
+	for key, url := range m.urls {
+		r := urlRecord{Key: key, URL: url, UID: owners[key], Creator: m.creators[key]}
+		if at, ok := m.createdAt[key]; ok {
+			r.CreatedAt = &at
+		}
+		if at, ok := m.deletedAt[key]; ok {
+			r.DeletedAt = &at
+		}
+
+		if err = e.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	for key, until := range m.quarantined {
+		until := until
+		if err = e.Encode(urlRecord{Key: key, Kind: recordQuarantined, QuarantinedUntil: &until}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	for key := range m.disabled {
+		if err = e.Encode(urlRecord{Key: key, Kind: recordDisabled}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	for _, b := range m.banned {
+		b := b
+		if err = e.Encode(urlRecord{UID: b.UID, Kind: recordBanned, BannedAt: &b.BannedAt, Reason: b.Reason}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	m.compactExtras()
+	for _, r := range m.extras {
+		if err = e.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, m.filePath)
 }
 
 // Get - getting URL from urls of fileStore struct.
+// Links that were purged but still are in quarantine are reported as found and deleted.
+// Disabled links and links of banned users are reported as deleted.
 func (m *fileStore) Get(key string) (string, bool, bool) {
 	defer m.mu.Unlock()
 	m.mu.Lock()
 
 	originalURL, ok := m.urls[key]
-	return originalURL, ok, false
+	if !ok {
+		if until, isQuarantined := m.quarantined[key]; isQuarantined && time.Now().Before(until) {
+			return "", true, true
+		}
+		return "", false, false
+	}
+
+	_, isDeleted := m.deletedAt[key]
+	_, isDisabled := m.disabled[key]
+	return originalURL, ok, isDeleted || isDisabled || isBannedLink(m.userURLs, m.banned, key)
 }
 
 // LinksByUUID - getting URL by UUID from userURLs of fileStore struct.
@@ -122,6 +399,171 @@ func (m *fileStore) LinksByUUID(uuid string) ([]UserURLs, bool) {
 	return links, ok
 }
 
+// FindLinks - returns page of links of user matching provided query.
+func (m *fileStore) FindLinks(ctx context.Context, q LinksQuery) (LinksPage, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return findLinks(m.userURLs[q.UID], m.createdAt, m.deletedAt, q)
+}
+
+// SoftDeleteUserURLs - marks links of provided user as deleted and saves that change to file.
+func (m *fileStore) SoftDeleteUserURLs(uuid string, ids []string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	now := time.Now()
+	marked := markDeleted(m.userURLs[uuid], m.deletedAt, ids, now)
+
+	records := make([]urlRecord, 0, len(marked))
+	for _, key := range marked {
+		records = append(records, urlRecord{Key: key, Kind: recordDeleted, DeletedAt: &now})
+	}
+
+	return m.saveToFile(records...)
+}
+
+// Stats - returns count of urls and users stored in file. Counts only non-soft deleted links.
 func (m *fileStore) Stats() (int, int, error) {
-	return len(m.urls), len(m.userURLs), nil
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	urls, users := countActive(m.urls, m.userURLs, m.deletedAt)
+	return urls, users, nil
+}
+
+// MergeUserLinks - moves all links of user from to user to and rewrites file, so links are loaded with new owner.
+// Links created by user from for workspaces are charged to user to.
+func (m *fileStore) MergeUserLinks(ctx context.Context, from string, to string) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	charged := mergeCreators(m.creators, from, to)
+	n := mergeUserLinks(m.userURLs, from, to)
+	if n == 0 && charged == 0 {
+		return 0, nil
+	}
+
+	return n, m.rewriteFile()
+}
+
+// PurgeDeleted - physically removes up to limit links that were soft deleted before provided time and rewrites file.
+// If quarantineUntil is not zero, keys of removed links can't be reused until that time.
+func (m *fileStore) PurgeDeleted(ctx context.Context, before time.Time, quarantineUntil time.Time, limit int) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	purged := purgeDeleted(m.urls, m.userURLs, m.createdAt, m.deletedAt, before, limit)
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	quarantine(m.quarantined, purged, quarantineUntil)
+	forgetCreators(m.creators, purged)
+	for _, key := range purged {
+		delete(m.disabled, key)
+	}
+
+	return len(purged), m.rewriteFile()
+}
+
+// ReleaseQuarantine - removes keys with expired quarantine, so they can be reused.
+// Expired quarantine records are skipped on loading, so file is left untouched.
+func (m *fileStore) ReleaseQuarantine(ctx context.Context) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return releaseQuarantine(m.quarantined, time.Now()), nil
+}
+
+// SearchLinks - returns page of links of all users matching provided query.
+func (m *fileStore) SearchLinks(ctx context.Context, q SearchQuery) (SearchPage, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return searchLinks(m.userURLs, m.createdAt, m.deletedAt, m.disabled, q)
+}
+
+// SetLinksDisabled - disables or enables links with provided keys and saves that change to file.
+func (m *fileStore) SetLinksDisabled(ctx context.Context, keys []string, disabled bool) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	kind := recordEnabled
+	if disabled {
+		kind = recordDisabled
+	}
+
+	changed := setDisabled(m.urls, m.disabled, keys, disabled)
+	records := make([]urlRecord, 0, len(changed))
+	for _, key := range changed {
+		records = append(records, urlRecord{Key: key, Kind: kind})
+	}
+
+	return len(changed), m.saveToFile(records...)
+}
+
+// ForceDeleteLinks - physically removes links with provided keys regardless of their owner and rewrites file.
+// If quarantineUntil is not zero, keys of removed links can't be reused until that time.
+func (m *fileStore) ForceDeleteLinks(ctx context.Context, keys []string, quarantineUntil time.Time) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	removed := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		removed[key] = struct{}{}
+		delete(m.disabled, key)
+	}
+
+	deleted := removeLinks(m.urls, m.userURLs, m.createdAt, m.deletedAt, removed)
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+	quarantine(m.quarantined, deleted, quarantineUntil)
+	forgetCreators(m.creators, deleted)
+
+	return len(deleted), m.rewriteFile()
+}
+
+// BanUser - bans user or updates reason of existing ban and saves ban to file.
+func (m *fileStore) BanUser(ctx context.Context, b Ban) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if existing, ok := m.banned[b.UID]; ok {
+		b.BannedAt = existing.BannedAt
+	}
+	m.banned[b.UID] = b
+
+	return m.saveToFile(urlRecord{UID: b.UID, Kind: recordBanned, BannedAt: &b.BannedAt, Reason: b.Reason})
+}
+
+// UnbanUser - lifts ban of user and saves that change to file.
+func (m *fileStore) UnbanUser(ctx context.Context, uid string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if _, ok := m.banned[uid]; !ok {
+		return nil
+	}
+	delete(m.banned, uid)
+
+	return m.saveToFile(urlRecord{UID: uid, Kind: recordUnbanned})
+}
+
+// IsBanned - reports whether user is banned.
+func (m *fileStore) IsBanned(ctx context.Context, uid string) (bool, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	_, ok := m.banned[uid]
+	return ok, nil
+}
+
+// Bans - returns all bans ordered by time.
+func (m *fileStore) Bans(ctx context.Context) ([]Ban, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return sortedBans(m.banned), nil
 }
@@ -1,13 +1,18 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
 )
 
 func TestNewFile(t *testing.T) {
@@ -20,10 +25,16 @@ func TestNewFile(t *testing.T) {
 			name: "File store can be created",
 			path: "tmp",
 			want: &fileStore{
-				urls:     map[string]string{},
-				filePath: "tmp",
-				userURLs: map[string][]UserURLs{},
-				logger:   &zap.Logger{},
+				urls:        map[string]string{},
+				filePath:    "tmp",
+				userURLs:    map[string][]UserURLs{},
+				createdAt:   map[string]time.Time{},
+				deletedAt:   map[string]time.Time{},
+				quarantined: map[string]time.Time{},
+				disabled:    map[string]struct{}{},
+				banned:      map[string]Ban{},
+				creators:    map[string]string{},
+				logger:      &zap.Logger{},
 			},
 		},
 	}
@@ -202,7 +213,7 @@ func Test_fileStore_saveToFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := &fileStore{filePath: tt.path}
-			m.saveToFile(tt.args.key, tt.args.url)
+			m.saveToFile(urlRecord{Key: tt.args.key, URL: tt.args.url})
 			assert.FileExists(t, "tmp")
 
 			f, err := os.OpenFile("tmp", os.O_RDONLY, 0644)
@@ -318,3 +329,176 @@ func Test_fileStore_Stats(t *testing.T) {
 		})
 	}
 }
+
+func Test_fileStore_PurgeDeleted(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{
+			name: "Purged links are removed from file and their keys stay quarantined after reload",
+			path: "tmp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer os.Remove(tt.path)
+
+			fs := NewFile(tt.path, zap.NewNop())
+			require.NoError(t, fs.saveToFile(
+				urlRecord{Key: "old", URL: "https://old.ru", UID: "1"},
+				urlRecord{Key: "fresh", URL: "https://fresh.ru", UID: "1"},
+			))
+
+			fs = NewFile(tt.path, zap.NewNop())
+			require.NoError(t, fs.SoftDeleteUserURLs("1", []string{"old", "fresh"}))
+			fs.deletedAt["old"] = time.Now().AddDate(0, 0, -31)
+
+			purged, err := fs.PurgeDeleted(context.Background(), time.Now().AddDate(0, 0, -30), time.Now().Add(time.Hour), 10)
+			require.NoError(t, err)
+			assert.Equal(t, 1, purged)
+
+			reloaded := NewFile(tt.path, zap.NewNop())
+			assert.NotContains(t, reloaded.urls, "old")
+			assert.Contains(t, reloaded.quarantined, "old")
+			assert.Contains(t, reloaded.deletedAt, "fresh")
+			assert.Len(t, reloaded.userURLs["1"], 1)
+
+			_, ok, isDeleted := reloaded.Get("old")
+			assert.True(t, ok)
+			assert.True(t, isDeleted)
+
+			key := "old"
+			reloaded.Store(&key, "https://new.ru", "2")
+			assert.NotEqual(t, "old", key, "quarantined key is not reissued")
+			_, ok, isDeleted = reloaded.Get("old")
+			assert.True(t, ok)
+			assert.True(t, isDeleted)
+		})
+	}
+}
+
+func Test_fileStore_PersistsWorkspacesAccountsQuotas(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		rewrite bool
+	}{
+		{
+			name: "Workspaces, accounts and quota overrides are restored from file",
+			path: "tmp",
+		},
+		{
+			name:    "Workspaces, accounts and quota overrides survive rewriting of file",
+			path:    "tmp",
+			rewrite: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer os.Remove(tt.path)
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+			maxLinks := 10
+
+			fs := NewFile(tt.path, zap.NewNop())
+			workspaces := NewWorkspaceStore(fs, zap.NewNop())
+			require.NoError(t, workspaces.CreateWorkspace(ctx,
+				auth.Workspace{ID: "w", Name: "team", CreatedAt: now},
+				auth.WorkspaceMember{WorkspaceID: "w", UID: "owner", Role: auth.WorkspaceOwner, AddedAt: now},
+			))
+			require.NoError(t, workspaces.SaveMember(ctx, auth.WorkspaceMember{WorkspaceID: "w", UID: "1", Role: auth.WorkspaceViewer, AddedAt: now}))
+			require.NoError(t, workspaces.SaveMember(ctx, auth.WorkspaceMember{WorkspaceID: "w", UID: "1", Role: auth.WorkspaceEditor}))
+			require.NoError(t, workspaces.SaveMember(ctx, auth.WorkspaceMember{WorkspaceID: "w", UID: "2", Role: auth.WorkspaceViewer, AddedAt: now}))
+			require.NoError(t, workspaces.DeleteMember(ctx, "w", "2"))
+
+			accounts := NewAccountStore(fs, zap.NewNop())
+			require.NoError(t, accounts.CreateAccount(ctx, auth.Account{ID: "1", Login: "user", PasswordHash: "hash", CreatedAt: now}))
+			require.NoError(t, accounts.SaveSession(ctx, auth.Session{Hash: "live", UID: "1", ExpiresAt: now.Add(time.Hour)}))
+			require.NoError(t, accounts.SaveSession(ctx, auth.Session{Hash: "logout", UID: "1", ExpiresAt: now.Add(time.Hour)}))
+			require.NoError(t, accounts.SaveSession(ctx, auth.Session{Hash: "expired", UID: "1", ExpiresAt: now.Add(-time.Hour)}))
+			require.NoError(t, accounts.DeleteSession(ctx, "logout"))
+			n, err := accounts.DeleteExpiredSessions(ctx, now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+
+			quotas := NewQuotaStore(fs, zap.NewNop())
+			require.NoError(t, quotas.SaveQuotaOverride(ctx, "1", QuotaOverride{MaxLinks: &maxLinks}))
+			require.NoError(t, quotas.SaveQuotaOverride(ctx, "2", QuotaOverride{MaxLinks: &maxLinks}))
+			require.NoError(t, quotas.DeleteQuotaOverride(ctx, "2"))
+
+			if tt.rewrite {
+				fs.mu.Lock()
+				require.NoError(t, fs.rewriteFile())
+				fs.mu.Unlock()
+				assert.Len(t, fs.extras, 5, "history of changes is compacted")
+			}
+
+			reloaded := NewFile(tt.path, zap.NewNop())
+
+			members, err := NewWorkspaceStore(reloaded, zap.NewNop()).Members(ctx, "w")
+			require.NoError(t, err)
+			assert.Equal(t, []auth.WorkspaceMember{
+				{WorkspaceID: "w", UID: "1", Role: auth.WorkspaceEditor, AddedAt: now},
+				{WorkspaceID: "w", UID: "owner", Role: auth.WorkspaceOwner, AddedAt: now},
+			}, members)
+
+			reloadedAccounts := NewAccountStore(reloaded, zap.NewNop())
+			a, err := reloadedAccounts.AccountByLogin(ctx, "user")
+			require.NoError(t, err)
+			assert.Equal(t, "hash", a.PasswordHash)
+			_, err = reloadedAccounts.SessionByHash(ctx, "live")
+			assert.NoError(t, err)
+			for _, hash := range []string{"logout", "expired"} {
+				_, err = reloadedAccounts.SessionByHash(ctx, hash)
+				assert.Error(t, err, hash)
+			}
+
+			reloadedQuotas := NewQuotaStore(reloaded, zap.NewNop())
+			o, err := reloadedQuotas.QuotaOverride(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, QuotaOverride{MaxLinks: &maxLinks}, o)
+			o, err = reloadedQuotas.QuotaOverride(ctx, "2")
+			require.NoError(t, err)
+			assert.Equal(t, QuotaOverride{}, o)
+		})
+	}
+}
+
+func Test_fileStore_MergeUserLinks(t *testing.T) {
+	path := "tmp_merge"
+	defer os.Remove(path)
+
+	fs := NewFile(path, zap.NewNop())
+	first, own := "first", "own"
+	fs.Store(&first, "https://first.ru", "anon")
+	fs.Store(&own, "https://own.ru", "account")
+
+	merged, err := fs.MergeUserLinks(context.Background(), "anon", "account")
+	require.NoError(t, err)
+	assert.Equal(t, 1, merged)
+
+	reloaded := NewFile(path, zap.NewNop())
+	assert.Len(t, reloaded.userURLs["account"], 2)
+	assert.NotContains(t, reloaded.userURLs, "anon")
+}
+
+func Test_fileStore_UserUsage(t *testing.T) {
+	path := "tmp_usage"
+	defer os.Remove(path)
+
+	fs := NewFile(path, zap.NewNop())
+	own, shared := "own", "shared"
+	fs.Store(&own, "https://own.ru", "member")
+	require.NoError(t, fs.StoreContext(WithCreator(context.Background(), "member"), &shared, "https://shared.ru", "workspace"))
+
+	for _, s := range []*fileStore{fs, NewFile(path, zap.NewNop())} {
+		active, _, err := s.UserUsage(context.Background(), "member", time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, active)
+
+		active, _, err = s.UserUsage(context.Background(), "workspace", time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, active)
+	}
+}
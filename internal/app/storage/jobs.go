@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/jobs"
+)
+
+// KindDeleteURLs - kind of job soft deleting links of user. Its payload is BatchDelete.
+const KindDeleteURLs = "delete_urls"
+
+var _ jobs.Store = (*dbJobStore)(nil)
+var _ Deleter = (*jobDeleter)(nil)
+
+// dbJobStore - jobs.Store backed by jobs table.
+type dbJobStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	insertJob = `insert into jobs (kind, payload, max_attempts) values ($1, $2, $3)`
+	claimJob  = `update jobs set status = 'running', attempts = attempts + 1, locked_until = NOW() + make_interval(secs => $1)
+		where id = (
+			select id from jobs
+			where (status = 'pending' and run_at <= NOW()) or (status = 'running' and locked_until < NOW())
+			order by run_at
+			for update skip locked
+			limit 1
+		)
+		returning id, kind, payload, attempts, max_attempts, run_at, locked_until`
+	completeJob   = `delete from jobs where id = $1`
+	retryJob      = `update jobs set status = 'pending', run_at = $2, locked_until = null, last_error = $3 where id = $1`
+	deadLetterJob = `update jobs set status = 'dead', locked_until = null, last_error = $2 where id = $1`
+	purgeDeadJobs = `delete from jobs where status = 'dead' and run_at < $1`
+)
+
+// NewJobStore - creates jobs.Store for provided storage: jobs table for database and in-memory store otherwise.
+func NewJobStore(s Storage, l *zap.Logger) jobs.Store {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbJobStore{conn: d.conn, logger: l}
+	}
+
+	return jobs.NewMemory()
+}
+
+// Enqueue - inserts pending job with payload marshalled to json.
+func (s *dbJobStore) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = s.conn.Exec(ctx, insertJob, kind, b, config.JobMaxAttempts())
+
+	return err
+}
+
+// Claim - locks the oldest ready job with SELECT ... FOR UPDATE SKIP LOCKED, so concurrent workers
+// never claim the same job, and marks it running for visibility time.
+func (s *dbJobStore) Claim(ctx context.Context, visibility time.Duration) (*jobs.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn.Query(ctx, claimJob, visibility.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	j := jobs.Job{Status: jobs.StatusRunning}
+	if err = rows.Scan(&j.ID, &j.Kind, &j.Payload, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedUntil); err != nil {
+		return nil, err
+	}
+
+	return &j, nil
+}
+
+// Complete - removes job from jobs table.
+func (s *dbJobStore) Complete(ctx context.Context, id int64) error {
+	_, err := s.conn.Exec(ctx, completeJob, id)
+	return err
+}
+
+// Retry - returns job to pending state.
+func (s *dbJobStore) Retry(ctx context.Context, id int64, runAt time.Time, lastErr string) error {
+	_, err := s.conn.Exec(ctx, retryJob, id, runAt, lastErr)
+	return err
+}
+
+// DeadLetter - marks job as dead. Dead jobs are kept in jobs table for inspection.
+func (s *dbJobStore) DeadLetter(ctx context.Context, id int64, lastErr string) error {
+	_, err := s.conn.Exec(ctx, deadLetterJob, id, lastErr)
+	return err
+}
+
+// PurgeDead - removes dead jobs which were run for the last time before provided time.
+func (s *dbJobStore) PurgeDead(ctx context.Context, before time.Time) (int, error) {
+	r, err := s.conn.Exec(ctx, purgeDeadJobs, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(r.RowsAffected()), nil
+}
+
+// jobDeleter - Deleter which soft deletes links through durable KindDeleteURLs jobs.
+type jobDeleter struct {
+	producer jobs.Producer
+}
+
+// NewJobDeleter - creates Deleter which enqueues KindDeleteURLs jobs instead of deleting links right away.
+func NewJobDeleter(p jobs.Producer) Deleter {
+	return &jobDeleter{producer: p}
+}
+
+// SoftDeleteUserURLs - enqueues KindDeleteURLs job.
+func (d *jobDeleter) SoftDeleteUserURLs(uuid string, ids []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return d.producer.Enqueue(ctx, KindDeleteURLs, BatchDelete{UID: uuid, Arr: ids})
+}
+
+// DeleteJobHandler - returns jobs.Handler soft deleting links from KindDeleteURLs job payload.
+func DeleteJobHandler(d Deleter) jobs.Handler {
+	return func(ctx context.Context, payload []byte) error {
+		var b BatchDelete
+		if err := json.Unmarshal(payload, &b); err != nil {
+			return err
+		}
+
+		return d.SoftDeleteUserURLs(b.UID, b.Arr)
+	}
+}
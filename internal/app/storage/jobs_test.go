@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/jobs"
+)
+
+func TestNewJobStore(t *testing.T) {
+	tests := []struct {
+		storage Storage
+		want    jobs.Store
+		name    string
+	}{
+		{
+			name:    "Memory job store is created for memory storage",
+			storage: NewMemory(zap.NewNop()),
+			want:    jobs.NewMemory(),
+		},
+		{
+			name:    "Memory job store is created for database without connection",
+			storage: &db{},
+			want:    jobs.NewMemory(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewJobStore(tt.storage, zap.NewNop()))
+		})
+	}
+}
+
+func TestDeleteJobHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch BatchDelete
+	}{
+		{
+			name:  "Links are soft deleted by job enqueued by job deleter",
+			batch: BatchDelete{UID: "1", Arr: []string{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := jobs.NewMemory()
+			require.NoError(t, NewJobDeleter(store).SoftDeleteUserURLs(tt.batch.UID, tt.batch.Arr))
+
+			j, err := store.Claim(context.Background(), time.Minute)
+			require.NoError(t, err)
+			require.NotNil(t, j)
+			assert.Equal(t, KindDeleteURLs, j.Kind)
+
+			d := &deleterMock{}
+			require.NoError(t, DeleteJobHandler(d)(context.Background(), j.Payload))
+			assert.Equal(t, []BatchDelete{tt.batch}, d.calls)
+		})
+	}
+}
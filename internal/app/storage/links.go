@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+// Sort orders of user links.
+const (
+	SortCreatedAsc  = "created_at"
+	SortCreatedDesc = "-created_at"
+)
+
+// LinksQuery - parameters of user links listing.
+type LinksQuery struct {
+	CreatedAfter time.Time // only links created after that time, zero time disables filter
+	Deleted      *bool     // only deleted or only not deleted links, nil disables filter
+	UID          string    // owner of links
+	Cursor       string    // cursor returned with previous page, empty for first page
+	Domain       string    // only links which original URL has that host, case-insensitive
+	Search       string    // only links which original URL contains that text, case-insensitive
+	Sort         string    // SortCreatedAsc or SortCreatedDesc
+	Limit        int       // max amount of links on page
+}
+
+// LinksPage - a page of user links. NextCursor is empty on the last page.
+type LinksPage struct {
+	NextCursor string
+	Links      []UserURLs
+}
+
+// linksCursor - position of the last link on page. ID is a key of link for memory and file storage
+// and id of row for database.
+type linksCursor struct {
+	At time.Time `json:"t"`
+	ID string    `json:"id"`
+}
+
+// encodeCursor - encodes position of the last link on page into opaque string.
+func encodeCursor(c linksCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor - decodes cursor of LinksQuery. Returns utils.ErrInvalidCursor if cursor is malformed.
+func decodeCursor(s string) (linksCursor, error) {
+	var c linksCursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, utils.ErrInvalidCursor
+	}
+
+	if err = json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return c, utils.ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// linkHost - returns lowercased host of URL without port.
+func linkHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
+
+// findLinks - returns page of links for memory and file storage. Links are ordered by creation time and key,
+// so cursor stays valid when links are added or removed between requests.
+func findLinks(
+	links []UserURLs,
+	createdAt map[string]time.Time,
+	deletedAt map[string]time.Time,
+	q LinksQuery,
+) (LinksPage, error) {
+	var (
+		cursor    linksCursor
+		hasCursor = q.Cursor != ""
+		desc      = q.Sort == SortCreatedDesc
+		domain    = strings.ToLower(q.Domain)
+		search    = strings.ToLower(q.Search)
+	)
+
+	if hasCursor {
+		var err error
+		if cursor, err = decodeCursor(q.Cursor); err != nil {
+			return LinksPage{}, err
+		}
+	}
+
+	// after - reports whether first position goes after second one in requested order.
+	after := func(at time.Time, key string, other time.Time, otherKey string) bool {
+		if desc {
+			return at.Before(other) || (at.Equal(other) && key < otherKey)
+		}
+		return at.After(other) || (at.Equal(other) && key > otherKey)
+	}
+
+	matched := make([]UserURLs, 0, len(links))
+	for _, l := range links {
+		at := createdAt[l.ShortURL]
+
+		if !q.CreatedAfter.IsZero() && !at.After(q.CreatedAfter) {
+			continue
+		}
+		if _, isDeleted := deletedAt[l.ShortURL]; q.Deleted != nil && isDeleted != *q.Deleted {
+			continue
+		}
+		if domain != "" && linkHost(l.OriginalURL) != domain {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(l.OriginalURL), search) {
+			continue
+		}
+		if hasCursor && !after(at, l.ShortURL, cursor.At, cursor.ID) {
+			continue
+		}
+
+		matched = append(matched, l)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return after(createdAt[matched[j].ShortURL], matched[j].ShortURL, createdAt[matched[i].ShortURL], matched[i].ShortURL)
+	})
+
+	page := LinksPage{Links: matched}
+	if q.Limit > 0 && len(matched) > q.Limit {
+		page.Links = matched[:q.Limit]
+
+		last := page.Links[q.Limit-1]
+		page.NextCursor = encodeCursor(linksCursor{At: createdAt[last.ShortURL], ID: last.ShortURL})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+func TestMemory_FindLinks(t *testing.T) {
+	m := NewMemory(zap.NewNop())
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, url := range []string{
+		"https://github.com/a",
+		"https://GitHub.com:443/b",
+		"https://yandex.ru/search?text=go",
+		"https://github.com/c",
+	} {
+		key := string(rune('a' + i))
+		m.Store(&key, url, "1")
+		m.createdAt[key] = base.Add(time.Duration(i) * time.Hour)
+	}
+	require.NoError(t, m.SoftDeleteUserURLs("1", []string{"d"}))
+
+	notDeleted := false
+
+	tests := []struct {
+		name  string
+		query LinksQuery
+		want  []string
+	}{
+		{
+			name:  "Links are ordered by creation time",
+			query: LinksQuery{UID: "1"},
+			want:  []string{"a", "b", "c", "d"},
+		},
+		{
+			name:  "Links can be ordered by creation time descending",
+			query: LinksQuery{UID: "1", Sort: SortCreatedDesc},
+			want:  []string{"d", "c", "b", "a"},
+		},
+		{
+			name:  "Links can be filtered by creation time",
+			query: LinksQuery{UID: "1", CreatedAfter: base.Add(time.Hour)},
+			want:  []string{"c", "d"},
+		},
+		{
+			name:  "Links can be filtered by domain",
+			query: LinksQuery{UID: "1", Domain: "github.com"},
+			want:  []string{"a", "b", "d"},
+		},
+		{
+			name:  "Deleted links can be excluded",
+			query: LinksQuery{UID: "1", Deleted: &notDeleted},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "Links can be searched by original URL",
+			query: LinksQuery{UID: "1", Search: "TEXT=GO"},
+			want:  []string{"c"},
+		},
+		{
+			name:  "Links of other users are not returned",
+			query: LinksQuery{UID: "2"},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := m.FindLinks(context.Background(), tt.query)
+			require.NoError(t, err)
+
+			keys := make([]string, 0, len(page.Links))
+			for _, l := range page.Links {
+				keys = append(keys, l.ShortURL)
+			}
+
+			assert.Equal(t, tt.want, keys)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestMemory_FindLinksPagination(t *testing.T) {
+	m := NewMemory(zap.NewNop())
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		key := key
+		m.Store(&key, "https://github.com/"+key, "1")
+	}
+
+	for _, sort := range []string{SortCreatedAsc, SortCreatedDesc} {
+		var (
+			keys   []string
+			cursor string
+			pages  int
+		)
+
+		for {
+			page, err := m.FindLinks(context.Background(), LinksQuery{UID: "1", Limit: 2, Cursor: cursor, Sort: sort})
+			require.NoError(t, err)
+
+			pages++
+			for _, l := range page.Links {
+				keys = append(keys, l.ShortURL)
+			}
+
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+
+		assert.Equal(t, 3, pages, sort)
+		assert.ElementsMatch(t, []string{"a", "b", "c", "d", "e"}, keys, sort)
+	}
+
+	_, err := m.FindLinks(context.Background(), LinksQuery{UID: "1", Cursor: "%%%"})
+	assert.ErrorIs(t, err, utils.ErrInvalidCursor)
+}
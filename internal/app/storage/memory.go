@@ -1,19 +1,33 @@
 package storage
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/pkg/sequence"
 )
 
 // if Memory struct will no longer complains with Storage interface, code will be broken on building stage
 var _ Storage = (*Memory)(nil)
+var _ Retention = (*Memory)(nil)
+var _ Merger = (*Memory)(nil)
+var _ Moderation = (*Memory)(nil)
+var _ Usage = (*Memory)(nil)
 
 type Memory struct {
-	logger   *zap.Logger
-	urls     map[string]string
-	userURLs map[string][]UserURLs
-	mu       sync.Mutex
+	logger      *zap.Logger
+	urls        map[string]string
+	userURLs    map[string][]UserURLs
+	createdAt   map[string]time.Time
+	deletedAt   map[string]time.Time
+	quarantined map[string]time.Time
+	disabled    map[string]struct{}
+	banned      map[string]Ban
+	creators    map[string]string // creators of links created for other owners, like workspaces
+	mu          sync.Mutex
 }
 
 // UserURLs - container for ShortURL and OriginalURL
@@ -25,29 +39,72 @@ type UserURLs struct {
 // NewMemory - creates Memory struct
 func NewMemory(l *zap.Logger) *Memory {
 	return &Memory{
-		urls:     map[string]string{},
-		userURLs: map[string][]UserURLs{},
-		logger:   l,
+		urls:        map[string]string{},
+		userURLs:    map[string][]UserURLs{},
+		createdAt:   map[string]time.Time{},
+		deletedAt:   map[string]time.Time{},
+		quarantined: map[string]time.Time{},
+		disabled:    map[string]struct{}{},
+		banned:      map[string]Ban{},
+		creators:    map[string]string{},
+		logger:      l,
 	}
 }
 
-// Store - storing provided URL in Memory using key.
+// Store - storing provided URL in Memory using key. If key is quarantined, it's replaced by a new one.
 func (m *Memory) Store(key *string, url string, uuid string) {
 	defer m.mu.Unlock()
 	m.mu.Lock()
 
-	m.urls[*key] = url
+	if err := replaceQuarantinedKey(key, m.urls, m.quarantined, false); err != nil {
+		m.logger.Error(err.Error(), zap.Error(err))
+		return
+	}
+	m.store(*key, url, uuid, uuid)
+}
+
+// StoreContext - storing provided URL in Memory using key. If key is used or quarantined, it's replaced by a new one.
+// Link is charged to Creator of ctx.
+func (m *Memory) StoreContext(ctx context.Context, key *string, url string, uid string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if err := replaceQuarantinedKey(key, m.urls, m.quarantined, true); err != nil {
+		return err
+	}
+	m.store(*key, url, uid, Creator(ctx, uid))
+
+	return nil
+}
+
+// store - puts link in maps of Memory.
+func (m *Memory) store(key string, url string, uid string, creator string) {
+	m.urls[key] = url
+	m.createdAt[key] = time.Now()
+	delete(m.disabled, key)
+	setCreator(m.creators, key, uid, creator)
 
-	m.userURLs[uuid] = append(m.userURLs[uuid], UserURLs{ShortURL: *key, OriginalURL: url})
+	m.userURLs[uid] = append(m.userURLs[uid], UserURLs{ShortURL: key, OriginalURL: url})
 }
 
 // Get - trying to get from Memory URL by its key.
+// Links that were purged but still are in quarantine are reported as found and deleted.
+// Disabled links and links of banned users are reported as deleted.
 func (m *Memory) Get(key string) (string, bool, bool) {
 	defer m.mu.Unlock()
 	m.mu.Lock()
 
 	originalURL, ok := m.urls[key]
-	return originalURL, ok, false
+	if !ok {
+		if until, isQuarantined := m.quarantined[key]; isQuarantined && time.Now().Before(until) {
+			return "", true, true
+		}
+		return "", false, false
+	}
+
+	_, isDeleted := m.deletedAt[key]
+	_, isDisabled := m.disabled[key]
+	return originalURL, ok, isDeleted || isDisabled || isBannedLink(m.userURLs, m.banned, key)
 }
 
 // LinksByUUID - trying to get an array of UserURLs.
@@ -61,6 +118,332 @@ func (m *Memory) LinksByUUID(uuid string) ([]UserURLs, bool) {
 	return userLinks, ok
 }
 
+// FindLinks - returns page of links of user matching provided query.
+func (m *Memory) FindLinks(ctx context.Context, q LinksQuery) (LinksPage, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return findLinks(m.userURLs[q.UID], m.createdAt, m.deletedAt, q)
+}
+
+// SoftDeleteUserURLs - marks links of provided user as deleted.
+func (m *Memory) SoftDeleteUserURLs(uuid string, ids []string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	markDeleted(m.userURLs[uuid], m.deletedAt, ids, time.Now())
+
+	return nil
+}
+
+// Stats - returns count of urls and users stored in memory. Counts only non-soft deleted links.
 func (m *Memory) Stats() (int, int, error) {
-	return len(m.urls), len(m.userURLs), nil
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	urls, users := countActive(m.urls, m.userURLs, m.deletedAt)
+	return urls, users, nil
+}
+
+// MergeUserLinks - moves all links of user from to user to. Links created by user from for workspaces are charged
+// to user to.
+func (m *Memory) MergeUserLinks(ctx context.Context, from string, to string) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	mergeCreators(m.creators, from, to)
+
+	return mergeUserLinks(m.userURLs, from, to), nil
+}
+
+// PurgeDeleted - physically removes up to limit links that were soft deleted before provided time.
+// If quarantineUntil is not zero, keys of removed links can't be reused until that time.
+func (m *Memory) PurgeDeleted(ctx context.Context, before time.Time, quarantineUntil time.Time, limit int) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	purged := purgeDeleted(m.urls, m.userURLs, m.createdAt, m.deletedAt, before, limit)
+	quarantine(m.quarantined, purged, quarantineUntil)
+	forgetCreators(m.creators, purged)
+	for _, key := range purged {
+		delete(m.disabled, key)
+	}
+
+	return len(purged), nil
+}
+
+// ReleaseQuarantine - removes keys with expired quarantine, so they can be reused.
+func (m *Memory) ReleaseQuarantine(ctx context.Context) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return releaseQuarantine(m.quarantined, time.Now()), nil
+}
+
+// SearchLinks - returns page of links of all users matching provided query.
+func (m *Memory) SearchLinks(ctx context.Context, q SearchQuery) (SearchPage, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return searchLinks(m.userURLs, m.createdAt, m.deletedAt, m.disabled, q)
+}
+
+// SetLinksDisabled - disables or enables links with provided keys.
+func (m *Memory) SetLinksDisabled(ctx context.Context, keys []string, disabled bool) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return len(setDisabled(m.urls, m.disabled, keys, disabled)), nil
+}
+
+// ForceDeleteLinks - physically removes links with provided keys regardless of their owner.
+// If quarantineUntil is not zero, keys of removed links can't be reused until that time.
+func (m *Memory) ForceDeleteLinks(ctx context.Context, keys []string, quarantineUntil time.Time) (int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	removed := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		removed[key] = struct{}{}
+		delete(m.disabled, key)
+	}
+
+	deleted := removeLinks(m.urls, m.userURLs, m.createdAt, m.deletedAt, removed)
+	quarantine(m.quarantined, deleted, quarantineUntil)
+	forgetCreators(m.creators, deleted)
+
+	return len(deleted), nil
+}
+
+// BanUser - bans user or updates reason of existing ban.
+func (m *Memory) BanUser(ctx context.Context, b Ban) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if existing, ok := m.banned[b.UID]; ok {
+		b.BannedAt = existing.BannedAt
+	}
+	m.banned[b.UID] = b
+
+	return nil
+}
+
+// UnbanUser - lifts ban of user.
+func (m *Memory) UnbanUser(ctx context.Context, uid string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.banned, uid)
+
+	return nil
+}
+
+// IsBanned - reports whether user is banned.
+func (m *Memory) IsBanned(ctx context.Context, uid string) (bool, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	_, ok := m.banned[uid]
+	return ok, nil
+}
+
+// Bans - returns all bans ordered by time.
+func (m *Memory) Bans(ctx context.Context) ([]Ban, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	return sortedBans(m.banned), nil
+}
+
+// mergeUserLinks - moves links of user from to user to and returns their count.
+func mergeUserLinks(userURLs map[string][]UserURLs, from string, to string) int {
+	links, ok := userURLs[from]
+	if !ok || from == to {
+		return 0
+	}
+
+	userURLs[to] = append(userURLs[to], links...)
+	delete(userURLs, from)
+
+	return len(links)
+}
+
+// setCreator - remembers creator of link if it's not owner of link.
+func setCreator(creators map[string]string, key string, owner string, creator string) {
+	if creator == owner {
+		delete(creators, key)
+		return
+	}
+
+	creators[key] = creator
+}
+
+// mergeCreators - charges links created by user from to user to and returns their count.
+func mergeCreators(creators map[string]string, from string, to string) int {
+	if from == to {
+		return 0
+	}
+
+	n := 0
+	for key, creator := range creators {
+		if creator == from {
+			creators[key] = to
+			n++
+		}
+	}
+
+	return n
+}
+
+// forgetCreators - removes creators of removed links.
+func forgetCreators(creators map[string]string, keys []string) {
+	for _, key := range keys {
+		delete(creators, key)
+	}
+}
+
+// markDeleted - sets deletion time for provided ids which belong to links.
+func markDeleted(links []UserURLs, deletedAt map[string]time.Time, ids []string, at time.Time) []string {
+	owned := make(map[string]struct{}, len(links))
+	for _, l := range links {
+		owned[l.ShortURL] = struct{}{}
+	}
+
+	marked := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := owned[id]; !ok {
+			continue
+		}
+		if _, ok := deletedAt[id]; ok {
+			continue
+		}
+
+		deletedAt[id] = at
+		marked = append(marked, id)
+	}
+
+	return marked
+}
+
+// countActive - counts links that are not soft deleted and users which have at least one of them.
+func countActive(urls map[string]string, userURLs map[string][]UserURLs, deletedAt map[string]time.Time) (int, int) {
+	users := 0
+	for _, links := range userURLs {
+		for _, l := range links {
+			if _, ok := deletedAt[l.ShortURL]; !ok {
+				users++
+				break
+			}
+		}
+	}
+
+	return len(urls) - len(deletedAt), users
+}
+
+// purgeDeleted - removes up to limit links soft deleted before provided time and returns their keys.
+func purgeDeleted(
+	urls map[string]string,
+	userURLs map[string][]UserURLs,
+	createdAt map[string]time.Time,
+	deletedAt map[string]time.Time,
+	before time.Time,
+	limit int,
+) []string {
+	purged := make(map[string]struct{})
+	for key, at := range deletedAt {
+		if len(purged) >= limit {
+			break
+		}
+		if at.Before(before) {
+			purged[key] = struct{}{}
+		}
+	}
+
+	if len(purged) == 0 {
+		return nil
+	}
+
+	return removeLinks(urls, userURLs, createdAt, deletedAt, purged)
+}
+
+// removeLinks - removes links with provided keys and returns keys of removed links.
+func removeLinks(
+	urls map[string]string,
+	userURLs map[string][]UserURLs,
+	createdAt map[string]time.Time,
+	deletedAt map[string]time.Time,
+	removed map[string]struct{},
+) []string {
+	for uid, links := range userURLs {
+		kept := make([]UserURLs, 0, len(links))
+		for _, l := range links {
+			if _, ok := removed[l.ShortURL]; !ok {
+				kept = append(kept, l)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(userURLs, uid)
+		} else {
+			userURLs[uid] = kept
+		}
+	}
+
+	keys := make([]string, 0, len(removed))
+	for key := range removed {
+		if _, ok := urls[key]; !ok {
+			continue
+		}
+
+		delete(urls, key)
+		delete(createdAt, key)
+		delete(deletedAt, key)
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// quarantine - forbids reusing of provided keys until provided time. Zero time disables quarantine.
+func quarantine(quarantined map[string]time.Time, keys []string, until time.Time) {
+	if until.IsZero() {
+		return
+	}
+
+	for _, key := range keys {
+		quarantined[key] = until
+	}
+}
+
+// replaceQuarantinedKey - replaces key with not expired quarantine by a new key of the same length, which is neither
+// quarantined nor used by stored links, so keys of purged links are not reissued to other URLs. If isUsedReplaced is
+// set, provided key is replaced when it's used too.
+func replaceQuarantinedKey(key *string, urls map[string]string, quarantined map[string]time.Time, isUsedReplaced bool) error {
+	seq := sequence.NewSequence()
+	for isReplaced := isUsedReplaced; ; isReplaced = true {
+		until, isQuarantined := quarantined[*key]
+		isQuarantined = isQuarantined && time.Now().Before(until)
+		if _, isUsed := urls[*key]; !isQuarantined && !(isReplaced && isUsed) {
+			return nil
+		}
+
+		newKey, err := seq.Generate(len(*key))
+		if err != nil {
+			return err
+		}
+		*key = newKey
+	}
+}
+
+// releaseQuarantine - removes keys with quarantine expired at provided time.
+func releaseQuarantine(quarantined map[string]time.Time, now time.Time) int {
+	released := 0
+	for key, until := range quarantined {
+		if !until.After(now) {
+			delete(quarantined, key)
+			released++
+		}
+	}
+
+	return released
 }
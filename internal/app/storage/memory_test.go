@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -128,9 +130,15 @@ func TestNewMemory(t *testing.T) {
 		{
 			name: "Memory object can be created",
 			want: &Memory{
-				urls:     map[string]string{},
-				userURLs: map[string][]UserURLs{},
-				logger:   &zap.Logger{},
+				urls:        map[string]string{},
+				userURLs:    map[string][]UserURLs{},
+				createdAt:   map[string]time.Time{},
+				deletedAt:   map[string]time.Time{},
+				quarantined: map[string]time.Time{},
+				disabled:    map[string]struct{}{},
+				banned:      map[string]Ban{},
+				creators:    map[string]string{},
+				logger:      &zap.Logger{},
 			},
 		},
 	}
@@ -210,3 +218,183 @@ func TestMemory_Stats(t *testing.T) {
 		})
 	}
 }
+
+func TestMemory_PurgeDeleted(t *testing.T) {
+	tests := []struct {
+		name            string
+		quarantineUntil time.Time
+		wantPurged      int
+		wantFound       bool
+	}{
+		{
+			name:            "Purged link key is quarantined",
+			quarantineUntil: time.Now().Add(time.Hour),
+			wantPurged:      1,
+			wantFound:       true,
+		},
+		{
+			name:       "Purged link key is released without quarantine",
+			wantPurged: 1,
+			wantFound:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(zap.NewNop())
+			old, fresh := "old", "fresh"
+			m.Store(&old, "https://old.ru", "1")
+			m.Store(&fresh, "https://fresh.ru", "1")
+
+			require.NoError(t, m.SoftDeleteUserURLs("1", []string{old, fresh}))
+			m.deletedAt[old] = time.Now().AddDate(0, 0, -31)
+
+			purged, err := m.PurgeDeleted(context.Background(), time.Now().AddDate(0, 0, -30), tt.quarantineUntil, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPurged, purged)
+
+			_, ok, isDeleted := m.Get(old)
+			assert.Equal(t, tt.wantFound, ok)
+			assert.Equal(t, tt.wantFound, isDeleted)
+
+			_, ok, isDeleted = m.Get(fresh)
+			assert.True(t, ok)
+			assert.True(t, isDeleted)
+
+			links, _ := m.LinksByUUID("1")
+			assert.Len(t, links, 1)
+		})
+	}
+}
+
+func TestMemory_StoreQuarantinedKey(t *testing.T) {
+	tests := []struct {
+		name            string
+		quarantineUntil time.Time
+		wantReplaced    bool
+	}{
+		{
+			name:            "Quarantined key is replaced by a new one",
+			quarantineUntil: time.Now().Add(time.Hour),
+			wantReplaced:    true,
+		},
+		{
+			name:            "Key with expired quarantine is reused",
+			quarantineUntil: time.Now().Add(-time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(zap.NewNop())
+			key := "purged"
+			m.Store(&key, "https://old.ru", "1")
+			require.NoError(t, m.SoftDeleteUserURLs("1", []string{key}))
+			_, err := m.PurgeDeleted(context.Background(), time.Now().Add(time.Second), tt.quarantineUntil, 10)
+			require.NoError(t, err)
+
+			m.Store(&key, "https://new.ru", "2")
+
+			assert.Equal(t, tt.wantReplaced, key != "purged")
+			assert.Len(t, key, len("purged"))
+			url, ok, isDeleted := m.Get(key)
+			assert.Equal(t, "https://new.ru", url)
+			assert.True(t, ok)
+			assert.False(t, isDeleted)
+			if tt.wantReplaced {
+				_, ok, isDeleted = m.Get("purged")
+				assert.True(t, ok, "key stays quarantined")
+				assert.True(t, isDeleted)
+			}
+		})
+	}
+}
+
+func TestMemory_MergeUserLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       string
+		wantMerged int
+		wantLinks  int
+	}{
+		{
+			name:       "Links of anonymous user are merged into account",
+			from:       "anon",
+			wantMerged: 2,
+			wantLinks:  3,
+		},
+		{
+			name:      "Nothing is merged from user without links",
+			from:      "unknown",
+			wantLinks: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(zap.NewNop())
+			first, second, own := "first", "second", "own"
+			m.Store(&first, "https://first.ru", "anon")
+			m.Store(&second, "https://second.ru", "anon")
+			m.Store(&own, "https://own.ru", "account")
+
+			merged, err := m.MergeUserLinks(context.Background(), tt.from, "account")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMerged, merged)
+
+			links, _ := m.LinksByUUID("account")
+			assert.Len(t, links, tt.wantLinks)
+		})
+	}
+}
+
+func TestMemory_UserUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		uid         string
+		mergeInto   string
+		wantActive  int
+		wantCreated int
+	}{
+		{
+			name:        "Links created for workspace are charged to member",
+			uid:         "member",
+			wantActive:  3,
+			wantCreated: 3,
+		},
+		{
+			name: "Links created by members are not charged to workspace",
+			uid:  "workspace",
+		},
+		{
+			name:        "Deleted links are counted only in created links",
+			uid:         "other",
+			wantCreated: 1,
+		},
+		{
+			name:        "Links created for workspace are charged to user merged into",
+			uid:         "account",
+			mergeInto:   "account",
+			wantActive:  3,
+			wantCreated: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(zap.NewNop())
+			own, shared, batch, deleted := "own", "shared", "batch", "deleted"
+			m.Store(&own, "https://own.ru", "member")
+			require.NoError(t, m.StoreContext(WithCreator(context.Background(), "member"), &shared, "https://shared.ru", "workspace"))
+			require.NoError(t, m.StoreContext(WithCreator(context.Background(), "member"), &batch, "https://batch.ru", "workspace"))
+			require.NoError(t, m.StoreContext(WithCreator(context.Background(), "other"), &deleted, "https://deleted.ru", "workspace"))
+			require.NoError(t, m.SoftDeleteUserURLs("workspace", []string{deleted}))
+
+			if tt.mergeInto != "" {
+				_, err := m.MergeUserLinks(context.Background(), "member", tt.mergeInto)
+				require.NoError(t, err)
+			}
+
+			active, created, err := m.UserUsage(context.Background(), tt.uid, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantActive, active)
+			assert.Equal(t, tt.wantCreated, created)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Moderation - storage that allows admins to manage links of all users. Disabled links and links of banned users
+// are reported by Get as deleted, so they are not redirected.
+type Moderation interface {
+	// SearchLinks - returns page of links of all users matching provided query. Returns utils.ErrInvalidCursor
+	// if cursor of query is malformed.
+	SearchLinks(ctx context.Context, q SearchQuery) (SearchPage, error)
+	// SetLinksDisabled - disables or enables links with provided keys and returns count of changed links.
+	SetLinksDisabled(ctx context.Context, keys []string, disabled bool) (int, error)
+	// ForceDeleteLinks - physically removes links with provided keys regardless of their owner and returns count
+	// of removed links. If quarantineUntil is not zero, keys of removed links can't be reused until that time.
+	ForceDeleteLinks(ctx context.Context, keys []string, quarantineUntil time.Time) (int, error)
+	// BanUser - bans user or updates reason of existing ban.
+	BanUser(ctx context.Context, b Ban) error
+	// UnbanUser - lifts ban of user. Lifting of absent ban is not an error.
+	UnbanUser(ctx context.Context, uid string) error
+	// IsBanned - reports whether user is banned.
+	IsBanned(ctx context.Context, uid string) (bool, error)
+	// Bans - returns all bans ordered by time.
+	Bans(ctx context.Context) ([]Ban, error)
+}
+
+// SearchQuery - parameters of links search across all users. Empty values disable corresponding filters.
+type SearchQuery struct {
+	Key    string // short key of link
+	UID    string // owner of links
+	URL    string // text in original URL, case-insensitive
+	Cursor string // cursor returned with previous page, empty for first page
+	Limit  int    // max amount of links on page
+}
+
+// SearchPage - a page of found links. NextCursor is empty on the last page.
+type SearchPage struct {
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Links      []LinkInfo `json:"links"`
+}
+
+// LinkInfo - a link with its owner and moderation state.
+type LinkInfo struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Key         string    `json:"key"`
+	OriginalURL string    `json:"original_url"`
+	UID         string    `json:"uid"`
+	IsDeleted   bool      `json:"is_deleted"`
+	IsDisabled  bool      `json:"is_disabled"`
+}
+
+// Ban - a ban of user. Banned user can't shorten links and links of banned user are not redirected.
+type Ban struct {
+	BannedAt time.Time `json:"banned_at"`
+	UID      string    `json:"uid"`
+	Reason   string    `json:"reason"`
+}
+
+// searchLinks - returns page of links of all users for memory and file storage ordered by creation time and key.
+func searchLinks(
+	userURLs map[string][]UserURLs,
+	createdAt map[string]time.Time,
+	deletedAt map[string]time.Time,
+	disabled map[string]struct{},
+	q SearchQuery,
+) (SearchPage, error) {
+	var (
+		cursor    linksCursor
+		hasCursor = q.Cursor != ""
+		search    = strings.ToLower(q.URL)
+	)
+
+	if hasCursor {
+		var err error
+		if cursor, err = decodeCursor(q.Cursor); err != nil {
+			return SearchPage{}, err
+		}
+	}
+
+	matched := make([]LinkInfo, 0)
+	for uid, links := range userURLs {
+		if q.UID != "" && uid != q.UID {
+			continue
+		}
+
+		for _, l := range links {
+			at := createdAt[l.ShortURL]
+
+			if q.Key != "" && l.ShortURL != q.Key {
+				continue
+			}
+			if search != "" && !strings.Contains(strings.ToLower(l.OriginalURL), search) {
+				continue
+			}
+			if hasCursor && !(at.After(cursor.At) || (at.Equal(cursor.At) && l.ShortURL > cursor.ID)) {
+				continue
+			}
+
+			_, isDeleted := deletedAt[l.ShortURL]
+			_, isDisabled := disabled[l.ShortURL]
+			matched = append(matched, LinkInfo{
+				CreatedAt:   at,
+				Key:         l.ShortURL,
+				OriginalURL: l.OriginalURL,
+				UID:         uid,
+				IsDeleted:   isDeleted,
+				IsDisabled:  isDisabled,
+			})
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].Key < matched[j].Key
+		}
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+
+	page := SearchPage{Links: matched}
+	if q.Limit > 0 && len(matched) > q.Limit {
+		page.Links = matched[:q.Limit]
+
+		last := page.Links[q.Limit-1]
+		page.NextCursor = encodeCursor(linksCursor{At: last.CreatedAt, ID: last.Key})
+	}
+
+	return page, nil
+}
+
+// setDisabled - disables or enables stored links with provided keys and returns keys of changed links.
+func setDisabled(urls map[string]string, disabled map[string]struct{}, keys []string, isDisabled bool) []string {
+	changed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := urls[key]; !ok {
+			continue
+		}
+
+		_, was := disabled[key]
+		switch {
+		case isDisabled && !was:
+			disabled[key] = struct{}{}
+		case !isDisabled && was:
+			delete(disabled, key)
+		default:
+			continue
+		}
+
+		changed = append(changed, key)
+	}
+
+	return changed
+}
+
+// isBannedLink - reports whether link with provided key belongs to banned user.
+func isBannedLink(userURLs map[string][]UserURLs, banned map[string]Ban, key string) bool {
+	for uid := range banned {
+		for _, l := range userURLs[uid] {
+			if l.ShortURL == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sortedBans - returns bans ordered by time.
+func sortedBans(banned map[string]Ban) []Ban {
+	bans := make([]Ban, 0, len(banned))
+	for _, b := range banned {
+		bans = append(bans, b)
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].BannedAt.Before(bans[j].BannedAt)
+	})
+
+	return bans
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestModeration(t *testing.T) {
+	path := "tmp_moderation"
+	defer os.Remove(path)
+
+	tests := []struct {
+		storage interface {
+			Storage
+			Moderation
+		}
+		name string
+	}{
+		{
+			name:    "Memory",
+			storage: NewMemory(zap.NewNop()),
+		},
+		{
+			name:    "File",
+			storage: NewFile(path, zap.NewNop()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := tt.storage
+			for _, l := range []struct{ key, url, uid string }{
+				{"spam1", "https://spam.example.com/1", "spammer"},
+				{"spam2", "https://spam.example.com/2", "spammer"},
+				{"good", "https://good.example.com", "user"},
+			} {
+				key := l.key
+				s.Store(&key, l.url, l.uid)
+			}
+
+			page, err := s.SearchLinks(ctx, SearchQuery{URL: "SPAM.example"})
+			require.NoError(t, err)
+			require.Len(t, page.Links, 2)
+			assert.Equal(t, "spammer", page.Links[0].UID)
+
+			page, err = s.SearchLinks(ctx, SearchQuery{Key: "good"})
+			require.NoError(t, err)
+			require.Len(t, page.Links, 1)
+			assert.Equal(t, "user", page.Links[0].UID)
+
+			page, err = s.SearchLinks(ctx, SearchQuery{Limit: 2})
+			require.NoError(t, err)
+			require.Len(t, page.Links, 2)
+			require.NotEmpty(t, page.NextCursor)
+			next, err := s.SearchLinks(ctx, SearchQuery{Limit: 2, Cursor: page.NextCursor})
+			require.NoError(t, err)
+			require.Len(t, next.Links, 1)
+			assert.Empty(t, next.NextCursor)
+
+			n, err := s.SetLinksDisabled(ctx, []string{"good", "unknown"}, true)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			_, ok, isDeleted := s.Get("good")
+			assert.True(t, ok)
+			assert.True(t, isDeleted, "disabled link must not be redirected")
+			page, err = s.SearchLinks(ctx, SearchQuery{UID: "user"})
+			require.NoError(t, err)
+			assert.True(t, page.Links[0].IsDisabled)
+
+			n, err = s.SetLinksDisabled(ctx, []string{"good"}, false)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			_, _, isDeleted = s.Get("good")
+			assert.False(t, isDeleted)
+
+			require.NoError(t, s.BanUser(ctx, Ban{UID: "spammer", Reason: "spam"}))
+			banned, err := s.IsBanned(ctx, "spammer")
+			require.NoError(t, err)
+			assert.True(t, banned)
+			_, _, isDeleted = s.Get("spam1")
+			assert.True(t, isDeleted, "links of banned user must not be redirected")
+			bans, err := s.Bans(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []Ban{{UID: "spammer", Reason: "spam"}}, bans)
+
+			require.NoError(t, s.UnbanUser(ctx, "spammer"))
+			_, _, isDeleted = s.Get("spam1")
+			assert.False(t, isDeleted)
+
+			n, err = s.ForceDeleteLinks(ctx, []string{"spam1", "unknown"}, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			_, ok, isDeleted = s.Get("spam1")
+			assert.True(t, ok && isDeleted, "key of deleted link must be quarantined")
+			_, ok, _ = s.Get("unknown")
+			assert.False(t, ok, "key of absent link must not be quarantined")
+			links, _ := s.LinksByUUID("spammer")
+			assert.Len(t, links, 1)
+		})
+	}
+}
+
+func Test_fileStore_ModerationIsPersisted(t *testing.T) {
+	path := "tmp_moderation_reload"
+	defer os.Remove(path)
+
+	ctx := context.Background()
+	fs := NewFile(path, zap.NewNop())
+	first, second := "first", "second"
+	fs.Store(&first, "https://first.ru", "spammer")
+	fs.Store(&second, "https://second.ru", "user")
+
+	_, err := fs.SetLinksDisabled(ctx, []string{"second"}, true)
+	require.NoError(t, err)
+	require.NoError(t, fs.BanUser(ctx, Ban{UID: "spammer", Reason: "spam"}))
+
+	reloaded := NewFile(path, zap.NewNop())
+	assert.Contains(t, reloaded.disabled, "second")
+	assert.Equal(t, "spam", reloaded.banned["spammer"].Reason)
+
+	_, err = reloaded.ForceDeleteLinks(ctx, []string{"first"}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, reloaded.UnbanUser(ctx, "spammer"))
+
+	reloaded = NewFile(path, zap.NewNop())
+	assert.Contains(t, reloaded.disabled, "second", "disabled links must survive rewrite of file")
+	assert.NotContains(t, reloaded.urls, "first")
+	assert.Empty(t, reloaded.banned)
+}
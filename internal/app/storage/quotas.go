@@ -0,0 +1,404 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Usage - storage that can count links of user for quotas.
+type Usage interface {
+	// UserUsage - returns count of not deleted links created by user and count of links created by user since
+	// provided time, including deleted ones, so deleting links doesn't restore daily quota. Links created by user
+	// for workspace are counted too.
+	UserUsage(ctx context.Context, uid string, since time.Time) (int, int, error)
+}
+
+// creatorKey - key of user creating links in context.
+type creatorKey struct{}
+
+// WithCreator - returns copy of ctx with user creating links on behalf of their owner, like member of workspace
+// creates links owned by workspace. Links stored with ctx are charged to quota of creator.
+func WithCreator(ctx context.Context, uid string) context.Context {
+	return context.WithValue(ctx, creatorKey{}, uid)
+}
+
+// Creator - returns user creating links stored with ctx, owner of links if ctx doesn't carry one.
+func Creator(ctx context.Context, owner string) string {
+	if uid, ok := ctx.Value(creatorKey{}).(string); ok && uid != "" {
+		return uid
+	}
+
+	return owner
+}
+
+// Quota - limits of user. Zero limit means no limit.
+type Quota struct {
+	MaxLinks int `json:"max_links"` // max amount of active links
+	MaxBatch int `json:"max_batch"` // max amount of links in one batch
+	MaxDaily int `json:"max_daily"` // max amount of links created per day
+}
+
+// QuotaOverride - limits of user set by admin. Nil limits are taken from global quota.
+type QuotaOverride struct {
+	MaxLinks *int `json:"max_links,omitempty"`
+	MaxBatch *int `json:"max_batch,omitempty"`
+	MaxDaily *int `json:"max_daily,omitempty"`
+}
+
+// Apply - returns copy of q with limits set by override.
+func (o QuotaOverride) Apply(q Quota) Quota {
+	if o.MaxLinks != nil {
+		q.MaxLinks = *o.MaxLinks
+	}
+	if o.MaxBatch != nil {
+		q.MaxBatch = *o.MaxBatch
+	}
+	if o.MaxDaily != nil {
+		q.MaxDaily = *o.MaxDaily
+	}
+
+	return q
+}
+
+// QuotaStore - storage of quota overrides of users.
+type QuotaStore interface {
+	// QuotaOverride - returns override of user, empty override if there is none.
+	QuotaOverride(ctx context.Context, uid string) (QuotaOverride, error)
+	// SaveQuotaOverride - stores override of user replacing previous one.
+	SaveQuotaOverride(ctx context.Context, uid string, o QuotaOverride) error
+	// DeleteQuotaOverride - removes override of user. Removing of absent override is not an error.
+	DeleteQuotaOverride(ctx context.Context, uid string) error
+}
+
+// QuotaLocker - lock of quota of user held from check of quota until links are inserted, so concurrent requests
+// of user can't exceed quota together.
+type QuotaLocker interface {
+	// WithUserLock - waits until quota of user is unlocked or ctx is done, locks it and runs fn. Usage counted and
+	// links stored with ctx passed to fn are checked and inserted under the same lock, quota is unlocked when fn
+	// returns.
+	WithUserLock(ctx context.Context, uid string, fn func(ctx context.Context) error) error
+}
+
+var _ QuotaLocker = (*MemoryQuotaLocker)(nil)
+var _ QuotaLocker = (*dbQuotaLocker)(nil)
+
+var _ QuotaStore = (*MemoryQuotas)(nil)
+var _ QuotaStore = (*dbQuotaStore)(nil)
+var _ QuotaStore = (*fileQuotaStore)(nil)
+
+// MemoryQuotas - in-memory QuotaStore used when service runs in memory or file mode.
+type MemoryQuotas struct {
+	overrides map[string]QuotaOverride
+	mu        sync.RWMutex
+}
+
+// NewMemoryQuotas - creates MemoryQuotas.
+func NewMemoryQuotas() *MemoryQuotas {
+	return &MemoryQuotas{overrides: map[string]QuotaOverride{}}
+}
+
+// QuotaOverride - returns override of user.
+func (m *MemoryQuotas) QuotaOverride(ctx context.Context, uid string) (QuotaOverride, error) {
+	defer m.mu.RUnlock()
+	m.mu.RLock()
+
+	return m.overrides[uid], nil
+}
+
+// SaveQuotaOverride - stores override of user.
+func (m *MemoryQuotas) SaveQuotaOverride(ctx context.Context, uid string, o QuotaOverride) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.overrides[uid] = o
+
+	return nil
+}
+
+// DeleteQuotaOverride - removes override of user.
+func (m *MemoryQuotas) DeleteQuotaOverride(ctx context.Context, uid string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.overrides, uid)
+
+	return nil
+}
+
+// MemoryQuotaLocker - QuotaLocker used when service runs in memory or file mode, so it is the only process
+// inserting links.
+type MemoryQuotaLocker struct {
+	users map[string]*userLock
+	mu    sync.Mutex
+}
+
+// userLock - lock of one user, removed when nobody holds or waits for it.
+type userLock struct {
+	ch      chan struct{}
+	waiters int
+}
+
+// NewMemoryQuotaLocker - creates MemoryQuotaLocker.
+func NewMemoryQuotaLocker() *MemoryQuotaLocker {
+	return &MemoryQuotaLocker{users: map[string]*userLock{}}
+}
+
+// WithUserLock - locks quota of user in memory while fn runs.
+func (m *MemoryQuotaLocker) WithUserLock(ctx context.Context, uid string, fn func(ctx context.Context) error) error {
+	m.mu.Lock()
+	l, ok := m.users[uid]
+	if !ok {
+		l = &userLock{ch: make(chan struct{}, 1)}
+		m.users[uid] = l
+	}
+	l.waiters++
+	m.mu.Unlock()
+	defer m.release(uid, l)
+
+	select {
+	case l.ch <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-l.ch }()
+
+	return fn(ctx)
+}
+
+// release - forgets lock of user if nobody else holds or waits for it.
+func (m *MemoryQuotaLocker) release(uid string, l *userLock) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	l.waiters--
+	if l.waiters == 0 {
+		delete(m.users, uid)
+	}
+}
+
+// dbQuotaLocker - QuotaLocker backed by transaction level advisory lock of Postgres, so quota is locked for all
+// instances of service sharing database.
+type dbQuotaLocker struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+// NewQuotaLocker - creates QuotaLocker for provided storage: advisory lock for database and in-memory lock
+// otherwise.
+func NewQuotaLocker(s Storage, l *zap.Logger) QuotaLocker {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbQuotaLocker{conn: d.conn, logger: l}
+	}
+
+	return NewMemoryQuotaLocker()
+}
+
+// WithUserLock - takes advisory lock of user in transaction passed to fn with ctx, so usage is counted and links
+// are inserted by the same connection in the same transaction. Transaction is committed if fn succeeds, lock is
+// released with it.
+func (d *dbQuotaLocker) WithUserLock(ctx context.Context, uid string, fn func(ctx context.Context) error) error {
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err = tx.Exec(ctx, lockUserQuota, uid); err != nil {
+		return err
+	}
+	if err = fn(withTx(ctx, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// dbQuotaStore - QuotaStore backed by user_quotas table.
+type dbQuotaStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	selectUserUsage = `select count(*) filter (where is_deleted = false), count(*) filter (where created_at >= $2)
+		from links where created_by = $1`
+	selectQuotaOverride = `select max_links, max_batch, max_daily from user_quotas where uid = $1`
+	upsertQuotaOverride = `insert into user_quotas (uid, max_links, max_batch, max_daily, updated_at) values ($1, $2, $3, $4, NOW())
+		on conflict (uid) do update set max_links = excluded.max_links, max_batch = excluded.max_batch,
+		max_daily = excluded.max_daily, updated_at = excluded.updated_at`
+	deleteQuotaOverride = `delete from user_quotas where uid = $1`
+	lockUserQuota       = `select pg_advisory_xact_lock(hashtext('quota'), hashtext($1))`
+)
+
+// NewQuotaStore - creates QuotaStore for provided storage: user_quotas table for database, records in file for
+// file storage and in-memory store otherwise.
+func NewQuotaStore(s Storage, l *zap.Logger) QuotaStore {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbQuotaStore{conn: d.conn, logger: l}
+	}
+	if f, ok := s.(*fileStore); ok {
+		return newFileQuotaStore(f, l)
+	}
+
+	return NewMemoryQuotas()
+}
+
+// QuotaOverride - selects override of user from user_quotas table.
+func (s *dbQuotaStore) QuotaOverride(ctx context.Context, uid string) (QuotaOverride, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var o QuotaOverride
+	err := s.conn.QueryRow(ctx, selectQuotaOverride, uid).Scan(&o.MaxLinks, &o.MaxBatch, &o.MaxDaily)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return QuotaOverride{}, nil
+	}
+
+	return o, err
+}
+
+// SaveQuotaOverride - upserts override of user into user_quotas table.
+func (s *dbQuotaStore) SaveQuotaOverride(ctx context.Context, uid string, o QuotaOverride) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, upsertQuotaOverride, uid, o.MaxLinks, o.MaxBatch, o.MaxDaily)
+
+	return err
+}
+
+// DeleteQuotaOverride - deletes override of user from user_quotas table.
+func (s *dbQuotaStore) DeleteQuotaOverride(ctx context.Context, uid string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, deleteQuotaOverride, uid)
+
+	return err
+}
+
+// UserUsage - counts links of user in database, in transaction of QuotaLocker if ctx carries one.
+func (d *db) UserUsage(ctx context.Context, uid string, since time.Time) (int, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var active, created int
+	if err := d.querier(ctx).QueryRow(ctx, selectUserUsage, uid, since).Scan(&active, &created); err != nil {
+		return 0, 0, err
+	}
+
+	return active, created, nil
+}
+
+// UserUsage - counts links of user stored in memory.
+func (m *Memory) UserUsage(ctx context.Context, uid string, since time.Time) (int, int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	active, created := countUserLinks(uid, m.userURLs[uid], m.creators, m.createdAt, m.deletedAt, since)
+
+	return active, created, nil
+}
+
+// UserUsage - counts links of user stored in file.
+func (m *fileStore) UserUsage(ctx context.Context, uid string, since time.Time) (int, int, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	active, created := countUserLinks(uid, m.userURLs[uid], m.creators, m.createdAt, m.deletedAt, since)
+
+	return active, created, nil
+}
+
+// countUserLinks - counts not deleted links and links created since provided time among links created by user:
+// links of user, except ones created by other users, and links created by user for other owners.
+func countUserLinks(
+	uid string,
+	links []UserURLs,
+	creators map[string]string,
+	createdAt map[string]time.Time,
+	deletedAt map[string]time.Time,
+	since time.Time,
+) (int, int) {
+	keys := make(map[string]struct{}, len(links))
+	for _, l := range links {
+		if creator, ok := creators[l.ShortURL]; !ok || creator == uid {
+			keys[l.ShortURL] = struct{}{}
+		}
+	}
+	for key, creator := range creators {
+		if creator == uid {
+			keys[key] = struct{}{}
+		}
+	}
+
+	active, created := 0, 0
+	for key := range keys {
+		if _, ok := deletedAt[key]; !ok {
+			active++
+		}
+		if !createdAt[key].Before(since) {
+			created++
+		}
+	}
+
+	return active, created
+}
+
+// fileQuotaStore - QuotaStore keeping overrides in memory and saving their changes to file of fileStore.
+type fileQuotaStore struct {
+	*MemoryQuotas
+	file   *fileStore
+	logger *zap.Logger
+}
+
+// newFileQuotaStore - creates fileQuotaStore with overrides previously saved to file.
+func newFileQuotaStore(f *fileStore, l *zap.Logger) *fileQuotaStore {
+	s := &fileQuotaStore{MemoryQuotas: NewMemoryQuotas(), file: f, logger: l}
+
+	for _, r := range f.extraRecords(recordQuotaOverride, recordQuotaOverridden) {
+		if r.Kind == recordQuotaOverridden {
+			delete(s.overrides, r.UID)
+			continue
+		}
+
+		var o QuotaOverride
+		if err := json.Unmarshal(r.Data, &o); err != nil {
+			l.Error(err.Error(), zap.Error(err))
+			continue
+		}
+		s.overrides[r.UID] = o
+	}
+
+	return s
+}
+
+// SaveQuotaOverride - stores override of user and saves it to file.
+func (s *fileQuotaStore) SaveQuotaOverride(ctx context.Context, uid string, o QuotaOverride) error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	if err = s.MemoryQuotas.SaveQuotaOverride(ctx, uid, o); err != nil {
+		return err
+	}
+
+	return s.file.saveExtra(urlRecord{UID: uid, Kind: recordQuotaOverride, Data: data})
+}
+
+// DeleteQuotaOverride - removes override of user and saves removal to file.
+func (s *fileQuotaStore) DeleteQuotaOverride(ctx context.Context, uid string) error {
+	if err := s.MemoryQuotas.DeleteQuotaOverride(ctx, uid); err != nil {
+		return err
+	}
+
+	return s.file.saveExtra(urlRecord{UID: uid, Kind: recordQuotaOverridden})
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+)
+
+var _ auth.RateStore = (*dbRateStore)(nil)
+
+// dbRateStore - auth.RateStore backed by rate_buckets table, so replicas share limits.
+type dbRateStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	// takeRateToken - refills and takes token in one statement, so concurrent requests to replicas can't take
+	// the same token. Time of database is used, so clocks of replicas don't matter.
+	takeRateToken = `insert into rate_buckets as b (key, tokens, allowed, updated_at) values ($1, $2::float8 - 1, true, NOW())
+		on conflict (key) do update set
+			tokens = case
+				when least($2::float8, b.tokens + greatest(extract(epoch from NOW() - b.updated_at), 0) * $3::float8) >= 1
+				then least($2::float8, b.tokens + greatest(extract(epoch from NOW() - b.updated_at), 0) * $3::float8) - 1
+				else least($2::float8, b.tokens + greatest(extract(epoch from NOW() - b.updated_at), 0) * $3::float8)
+			end,
+			allowed = least($2::float8, b.tokens + greatest(extract(epoch from NOW() - b.updated_at), 0) * $3::float8) >= 1,
+			updated_at = NOW()
+		returning tokens, allowed`
+	deleteIdleRateBuckets = `delete from rate_buckets where updated_at < $1`
+)
+
+// NewRateStore - creates auth.RateStore for provided storage: rate_buckets table for database
+// and in-memory store otherwise.
+func NewRateStore(s Storage, l *zap.Logger) auth.RateStore {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbRateStore{conn: d.conn, logger: l}
+	}
+
+	return auth.NewMemoryRates()
+}
+
+// TakeToken - takes token from row of bucket in rate_buckets table.
+func (s *dbRateStore) TakeToken(ctx context.Context, key string, capacity int, period time.Duration) (bool, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	perSecond := float64(capacity) / period.Seconds()
+
+	var tokens float64
+	var allowed bool
+	if err := s.conn.QueryRow(ctx, takeRateToken, key, float64(capacity), perSecond).Scan(&tokens, &allowed); err != nil {
+		return false, 0, err
+	}
+	if allowed {
+		return true, 0, nil
+	}
+
+	return false, time.Duration((1 - tokens) / perSecond * float64(time.Second)), nil
+}
+
+// DeleteIdleBuckets - removes buckets not used since provided time.
+func (s *dbRateStore) DeleteIdleBuckets(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.conn.Exec(ctx, deleteIdleRateBuckets, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
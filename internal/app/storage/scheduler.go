@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/scheduler"
+)
+
+// schedulerLockName - name of advisory lock held by leader of scheduler.
+const schedulerLockName = "shortener_scheduler"
+
+var _ scheduler.Backend = (*dbSchedulerBackend)(nil)
+
+// dbSchedulerBackend - scheduler.Backend electing leader through Postgres advisory lock.
+// Advisory lock belongs to session, so leader keeps dedicated connection from pool until it resigns.
+// If connection is lost, lock is released by Postgres and another replica becomes leader.
+type dbSchedulerBackend struct {
+	pool   *pgxpool.Pool
+	leader *pgxpool.Conn
+	logger *zap.Logger
+	mu     sync.Mutex
+}
+
+const (
+	tryLockScheduler = `select pg_try_advisory_lock(hashtext($1))`
+	unlockScheduler  = `select pg_advisory_unlock(hashtext($1))`
+	selectLastRun    = `select last_run_at, last_status, coalesce(last_error, ''), last_duration_ms from scheduled_tasks where name = $1`
+	upsertLastRun    = `insert into scheduled_tasks (name, last_run_at, last_status, last_error, last_duration_ms)
+		values ($1, $2, $3, nullif($4, ''), $5)
+		on conflict (name) do update set last_run_at = excluded.last_run_at, last_status = excluded.last_status,
+			last_error = excluded.last_error, last_duration_ms = excluded.last_duration_ms`
+)
+
+// NewSchedulerBackend - creates scheduler.Backend for provided storage: advisory lock and scheduled_tasks table
+// for database and single-process lock otherwise.
+func NewSchedulerBackend(s Storage, l *zap.Logger) scheduler.Backend {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbSchedulerBackend{pool: d.conn, logger: l}
+	}
+
+	return scheduler.NewLocal()
+}
+
+// TryLead - keeps leadership if connection holding lock is alive, otherwise tries to take advisory lock.
+func (b *dbSchedulerBackend) TryLead(ctx context.Context) (bool, error) {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if b.leader != nil {
+		if err := b.leader.Ping(ctx); err == nil {
+			return true, nil
+		}
+
+		// connection may still hold lock if only ping has failed, so it's closed instead of being returned to pool
+		_ = b.leader.Conn().Close(ctx)
+		b.leader.Release()
+		b.leader = nil
+	}
+
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var isLocked bool
+	if err = conn.QueryRow(ctx, tryLockScheduler, schedulerLockName).Scan(&isLocked); err != nil || !isLocked {
+		conn.Release()
+		return false, err
+	}
+
+	b.leader = conn
+
+	return true, nil
+}
+
+// Resign - releases advisory lock and returns connection to pool.
+func (b *dbSchedulerBackend) Resign(ctx context.Context) error {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+
+	if b.leader == nil {
+		return nil
+	}
+
+	_, err := b.leader.Exec(ctx, unlockScheduler, schedulerLockName)
+	if err != nil {
+		// connection still holding lock must not be reused
+		_ = b.leader.Conn().Close(ctx)
+	}
+	b.leader.Release()
+	b.leader = nil
+
+	return err
+}
+
+// LastRun - returns last run of task from scheduled_tasks table.
+func (b *dbSchedulerBackend) LastRun(ctx context.Context, name string) (scheduler.Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	r := scheduler.Run{Name: name}
+
+	var durationMs int64
+	err := b.pool.QueryRow(ctx, selectLastRun, name).Scan(&r.At, &r.Status, &r.Error, &durationMs)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return scheduler.Run{Name: name}, nil
+	}
+	if err != nil {
+		return scheduler.Run{}, err
+	}
+
+	r.Duration = time.Duration(durationMs) * time.Millisecond
+
+	return r, nil
+}
+
+// SaveRun - stores last run of task in scheduled_tasks table.
+func (b *dbSchedulerBackend) SaveRun(ctx context.Context, r scheduler.Run) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := b.pool.Exec(ctx, upsertLastRun, r.Name, r.At, r.Status, r.Error, r.Duration.Milliseconds())
+
+	return err
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/sergalkin/go-url-shortener.git/internal/app/config"
@@ -9,16 +12,40 @@ import (
 type Storage interface {
 	// Store - store given URL into storage with key as id.
 	Store(key *string, url string, uid string)
+	// StoreContext - stores given URL like Store, but key which is already used is replaced by a new one as well
+	// and failure of storing is returned. Database reports utils.ErrLinksConflict if URL is already stored,
+	// replacing key by key of stored link.
+	StoreContext(ctx context.Context, key *string, url string, uid string) error
 	// Get - trying to retrieve a URL from storage by provided key. As first bool value - returns was retrieval a
 	// success or not and as second bool value return is link still present in storage.
 	Get(key string) (string, bool, bool)
 	// LinksByUUID - trying to retrieve slice of UserURLs. On successful retrieval returns true as bool value and false of
 	// failure.
 	LinksByUUID(uuid string) ([]UserURLs, bool)
+	// FindLinks - returns page of links of user matching provided query. Returns utils.ErrInvalidCursor
+	// if cursor of query is malformed.
+	FindLinks(ctx context.Context, q LinksQuery) (LinksPage, error)
+	// SoftDeleteUserURLs - marks provided links of user as deleted. Links of other users are left untouched.
+	SoftDeleteUserURLs(uuid string, ids []string) error
 	// Stats - returns count of urls and users stored. Can be accessed only via trusted subnet.
 	Stats() (int, int, error)
 }
 
+// Retention - storage that can physically remove soft deleted links.
+type Retention interface {
+	// PurgeDeleted - removes up to limit links soft deleted before provided time and returns count of removed links.
+	// If quarantineUntil is not zero, keys of removed links must not be reused until that time.
+	PurgeDeleted(ctx context.Context, before time.Time, quarantineUntil time.Time, limit int) (int, error)
+	// ReleaseQuarantine - makes keys with expired quarantine available for reuse and returns their count.
+	ReleaseQuarantine(ctx context.Context) (int, error)
+}
+
+// Merger - storage that can move links between users.
+type Merger interface {
+	// MergeUserLinks - moves all links of user from to user to and returns count of moved links.
+	MergeUserLinks(ctx context.Context, from string, to string) (int, error)
+}
+
 // NewStorage - creates Storage implementation based on config options.
 func NewStorage(l *zap.Logger) (Storage, error) {
 	switch {
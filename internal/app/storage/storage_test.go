@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -20,19 +21,31 @@ func TestNewStorage(t *testing.T) {
 		{
 			name: "Memory storage will be created if no filepath is provided",
 			want: &Memory{
-				urls:     map[string]string{},
-				userURLs: map[string][]UserURLs{},
-				logger:   &zap.Logger{},
+				urls:        map[string]string{},
+				userURLs:    map[string][]UserURLs{},
+				createdAt:   map[string]time.Time{},
+				deletedAt:   map[string]time.Time{},
+				quarantined: map[string]time.Time{},
+				disabled:    map[string]struct{}{},
+				banned:      map[string]Ban{},
+				creators:    map[string]string{},
+				logger:      &zap.Logger{},
 			},
 			do: func() {},
 		},
 		{
 			name: "FileStorage will be created if filepath is provided",
 			want: &fileStore{
-				urls:     map[string]string{},
-				filePath: "tmp",
-				userURLs: map[string][]UserURLs{},
-				logger:   &zap.Logger{},
+				urls:        map[string]string{},
+				filePath:    "tmp",
+				userURLs:    map[string][]UserURLs{},
+				createdAt:   map[string]time.Time{},
+				deletedAt:   map[string]time.Time{},
+				quarantined: map[string]time.Time{},
+				disabled:    map[string]struct{}{},
+				banned:      map[string]Ban{},
+				creators:    map[string]string{},
+				logger:      &zap.Logger{},
 			},
 			do: func() {
 				config.NewConfig(config.WithFileStoragePath("tmp"))
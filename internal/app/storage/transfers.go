@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var _ auth.TransferStore = (*dbTransferStore)(nil)
+
+// dbTransferStore - auth.TransferStore backed by transfer_codes table.
+type dbTransferStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	insertTransfer = `insert into transfer_codes (hash, uid, created_at, expires_at) values ($1, $2, $3, $4)
+		on conflict (hash) do update set uid = excluded.uid, created_at = excluded.created_at, expires_at = excluded.expires_at`
+	takeTransfer           = `delete from transfer_codes where hash = $1 returning hash, uid, created_at, expires_at`
+	deleteExpiredTransfers = `delete from transfer_codes where expires_at < $1`
+)
+
+// NewTransferStore - creates auth.TransferStore for provided storage: transfer_codes table for database
+// and in-memory store otherwise.
+func NewTransferStore(s Storage, l *zap.Logger) auth.TransferStore {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbTransferStore{conn: d.conn, logger: l}
+	}
+
+	return auth.NewMemoryTransfers()
+}
+
+// SaveTransfer - inserts code into transfer_codes table.
+func (s *dbTransferStore) SaveTransfer(ctx context.Context, c auth.TransferCode) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, insertTransfer, c.Hash, c.UID, c.CreatedAt, c.ExpiresAt)
+
+	return err
+}
+
+// TakeTransfer - deletes code with provided hash and returns deleted row, so concurrent redemptions
+// of the same code can't both succeed.
+func (s *dbTransferStore) TakeTransfer(ctx context.Context, hash string) (auth.TransferCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var c auth.TransferCode
+	err := s.conn.QueryRow(ctx, takeTransfer, hash).Scan(&c.Hash, &c.UID, &c.CreatedAt, &c.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.TransferCode{}, utils.ErrInvalidTransfer
+	}
+
+	return c, err
+}
+
+// DeleteExpiredTransfers - removes codes expired before provided time.
+func (s *dbTransferStore) DeleteExpiredTransfers(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.conn.Exec(ctx, deleteExpiredTransfers, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
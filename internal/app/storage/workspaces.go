@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/sergalkin/go-url-shortener.git/internal/app/auth"
+	"github.com/sergalkin/go-url-shortener.git/internal/app/utils"
+)
+
+var _ auth.WorkspaceStore = (*dbWorkspaceStore)(nil)
+var _ auth.WorkspaceStore = (*fileWorkspaceStore)(nil)
+
+// dbWorkspaceStore - auth.WorkspaceStore backed by workspaces and workspace_members tables.
+type dbWorkspaceStore struct {
+	conn   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+const (
+	insertWorkspace      = `insert into workspaces (id, name, created_at) values ($1, $2, $3)`
+	selectUserWorkspaces = `select w.id, w.name, w.created_at, m.role from workspace_members m
+		join workspaces w on w.id = m.workspace_id where m.uid = $1 order by w.created_at, w.id`
+	selectWorkspaceMember = `select workspace_id, uid, role, added_at from workspace_members
+		where workspace_id = $1 and uid = $2`
+	selectWorkspaceMembers = `select workspace_id, uid, role, added_at from workspace_members
+		where workspace_id = $1 order by added_at, uid`
+	upsertWorkspaceMember = `insert into workspace_members (workspace_id, uid, role, added_at) values ($1, $2, $3, $4)
+		on conflict (workspace_id, uid) do update set role = excluded.role`
+	deleteWorkspaceMember = `delete from workspace_members where workspace_id = $1 and uid = $2`
+)
+
+// NewWorkspaceStore - creates auth.WorkspaceStore for provided storage: workspaces and workspace_members tables
+// for database, records in file for file storage and in-memory store otherwise.
+func NewWorkspaceStore(s Storage, l *zap.Logger) auth.WorkspaceStore {
+	if d, ok := s.(*db); ok && d.HasNotNilConn() {
+		return &dbWorkspaceStore{conn: d.conn, logger: l}
+	}
+	if f, ok := s.(*fileStore); ok {
+		return newFileWorkspaceStore(f, l)
+	}
+
+	return auth.NewMemoryWorkspaces()
+}
+
+// CreateWorkspace - inserts workspace and its owner in one transaction.
+func (s *dbWorkspaceStore) CreateWorkspace(ctx context.Context, w auth.Workspace, owner auth.WorkspaceMember) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, insertWorkspace, w.ID, w.Name, w.CreatedAt); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, upsertWorkspaceMember, owner.WorkspaceID, owner.UID, owner.Role, owner.AddedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UserWorkspaces - selects workspaces where user is member.
+func (s *dbWorkspaceStore) UserWorkspaces(ctx context.Context, uid string) ([]auth.UserWorkspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn.Query(ctx, selectUserWorkspaces, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := make([]auth.UserWorkspace, 0)
+	for rows.Next() {
+		var w auth.UserWorkspace
+		if err = rows.Scan(&w.ID, &w.Name, &w.CreatedAt, &w.Role); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, w)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// Member - selects member of workspace.
+func (s *dbWorkspaceStore) Member(ctx context.Context, workspaceID string, uid string) (auth.WorkspaceMember, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var m auth.WorkspaceMember
+	err := s.conn.QueryRow(ctx, selectWorkspaceMember, workspaceID, uid).Scan(&m.WorkspaceID, &m.UID, &m.Role, &m.AddedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.WorkspaceMember{}, utils.ErrNotMember
+	}
+
+	return m, err
+}
+
+// Members - selects members of workspace.
+func (s *dbWorkspaceStore) Members(ctx context.Context, workspaceID string) ([]auth.WorkspaceMember, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.conn.Query(ctx, selectWorkspaceMembers, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]auth.WorkspaceMember, 0)
+	for rows.Next() {
+		var m auth.WorkspaceMember
+		if err = rows.Scan(&m.WorkspaceID, &m.UID, &m.Role, &m.AddedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// SaveMember - inserts member or updates role of existing one.
+func (s *dbWorkspaceStore) SaveMember(ctx context.Context, m auth.WorkspaceMember) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, upsertWorkspaceMember, m.WorkspaceID, m.UID, m.Role, m.AddedAt)
+
+	return err
+}
+
+// DeleteMember - removes member from workspace.
+func (s *dbWorkspaceStore) DeleteMember(ctx context.Context, workspaceID string, uid string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.conn.Exec(ctx, deleteWorkspaceMember, workspaceID, uid)
+
+	return err
+}
+
+// fileWorkspaceStore - auth.WorkspaceStore keeping workspaces in memory and saving their changes to file of fileStore.
+type fileWorkspaceStore struct {
+	*auth.MemoryWorkspaces
+	file   *fileStore
+	logger *zap.Logger
+}
+
+// fileWorkspace - data of workspace record.
+type fileWorkspace struct {
+	Workspace auth.Workspace       `json:"workspace"`
+	Owner     auth.WorkspaceMember `json:"owner"`
+}
+
+// newFileWorkspaceStore - creates fileWorkspaceStore with workspaces and members previously saved to file.
+func newFileWorkspaceStore(f *fileStore, l *zap.Logger) *fileWorkspaceStore {
+	s := &fileWorkspaceStore{MemoryWorkspaces: auth.NewMemoryWorkspaces(), file: f, logger: l}
+
+	ctx := context.Background()
+	for _, r := range f.extraRecords(recordWorkspace, recordMember, recordMemberRemoved) {
+		var err error
+		switch r.Kind {
+		case recordWorkspace:
+			var w fileWorkspace
+			if err = json.Unmarshal(r.Data, &w); err == nil {
+				err = s.MemoryWorkspaces.CreateWorkspace(ctx, w.Workspace, w.Owner)
+			}
+		case recordMember:
+			var m auth.WorkspaceMember
+			if err = json.Unmarshal(r.Data, &m); err == nil {
+				err = s.MemoryWorkspaces.SaveMember(ctx, m)
+			}
+		case recordMemberRemoved:
+			err = s.MemoryWorkspaces.DeleteMember(ctx, r.Key, r.UID)
+		}
+		if err != nil {
+			l.Error(err.Error(), zap.Error(err))
+		}
+	}
+
+	return s
+}
+
+// CreateWorkspace - stores workspace with its owner and saves it to file.
+func (s *fileWorkspaceStore) CreateWorkspace(ctx context.Context, w auth.Workspace, owner auth.WorkspaceMember) error {
+	data, err := json.Marshal(fileWorkspace{Workspace: w, Owner: owner})
+	if err != nil {
+		return err
+	}
+
+	if err = s.MemoryWorkspaces.CreateWorkspace(ctx, w, owner); err != nil {
+		return err
+	}
+
+	return s.file.saveExtra(urlRecord{Key: w.ID, Kind: recordWorkspace, Data: data})
+}
+
+// SaveMember - stores member of existing workspace and saves it to file.
+func (s *fileWorkspaceStore) SaveMember(ctx context.Context, member auth.WorkspaceMember) error {
+	if err := s.MemoryWorkspaces.SaveMember(ctx, member); err != nil {
+		return err
+	}
+
+	saved, err := s.MemoryWorkspaces.Member(ctx, member.WorkspaceID, member.UID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+
+	return s.file.saveExtra(urlRecord{Key: member.WorkspaceID, UID: member.UID, Kind: recordMember, Data: data})
+}
+
+// DeleteMember - removes member from workspace and saves removal to file.
+func (s *fileWorkspaceStore) DeleteMember(ctx context.Context, workspaceID string, uid string) error {
+	if err := s.MemoryWorkspaces.DeleteMember(ctx, workspaceID, uid); err != nil {
+		return err
+	}
+
+	return s.file.saveExtra(urlRecord{Key: workspaceID, UID: uid, Kind: recordMemberRemoved})
+}
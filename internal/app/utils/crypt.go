@@ -4,72 +4,141 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"encoding/hex"
+	"encoding/base64"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
 )
 
-var (
-	aesGCM   cipher.AEAD
-	aesBlock cipher.Block
-	nonce    []byte
-)
+// tokenSeparator - separates key ID from encrypted value in token.
+const tokenSeparator = "."
 
-func init() {
-	var err error
+// keyIDPattern - allowed key IDs. Key ID is a part of cookie value, so it's limited to URL safe symbols.
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
 
-	key, errKey := generateRandom(2 * aes.BlockSize)
-	if errKey != nil {
-		fmt.Println(errKey.Error())
-	}
+// keyring - Keyring used by Encode and Decode.
+var keyring atomic.Value
 
-	aesBlock, err = aes.NewCipher(key)
+func init() {
+	secret, err := generateRandom(2 * aes.BlockSize)
 	if err != nil {
 		fmt.Println(err.Error())
 	}
 
-	aesGCM, err = cipher.NewGCM(aesBlock)
+	k, err := NewKeyring("ephemeral", []Key{{ID: "ephemeral", Secret: secret}})
 	if err != nil {
 		fmt.Println(err.Error())
 	}
 
-	nonce, err = generateRandom(aesGCM.NonceSize())
-	if err != nil {
-		fmt.Println(err.Error())
+	SetKeyring(k)
+}
+
+// Key - secret of AES cipher and its ID.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring - set of keys. Values are encrypted by active key and can be decrypted by any key of Keyring,
+// so keys can be rotated without invalidating already issued tokens.
+type Keyring struct {
+	aeads  map[string]cipher.AEAD
+	active string
+}
+
+// NewKeyring - creates Keyring from provided keys. Secrets must be 16, 24 or 32 bytes long.
+func NewKeyring(active string, keys []Key) (*Keyring, error) {
+	k := &Keyring{aeads: make(map[string]cipher.AEAD, len(keys)), active: active}
+
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("%w: id %q must match %s", ErrWrongCipherKey, key.ID, keyIDPattern)
+		}
+
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrWrongCipherKey, key.ID, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrWrongCipherKey, key.ID, err)
+		}
+
+		k.aeads[key.ID] = aead
+	}
+
+	if _, ok := k.aeads[active]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoActiveKey, active)
 	}
+
+	return k, nil
 }
 
-// Encode - encodes provided string to hexadecimal value encrypted by aesGCM.Seal.
-func Encode(userID string) (string, error) {
-	src := []byte(userID)
+// Encode - encrypts value by active key with random nonce. Returns token in format "<key ID>.<base64 nonce+cipher>".
+func (k *Keyring) Encode(value string) (string, error) {
+	aead := k.aeads[k.active]
 
-	dst := aesGCM.Seal(nil, nonce, src, nil)
+	nonce, err := generateRandom(aead.NonceSize())
+	if err != nil {
+		return "", err
+	}
 
-	sha := hex.EncodeToString(dst)
+	// key ID is authenticated as additional data, so it can't be swapped in token
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(k.active))
 
-	return sha, nil
+	return k.active + tokenSeparator + base64.RawURLEncoding.EncodeToString(sealed), nil
 }
 
-// Decode - decodes provided hexadecimal value and decrypts by aesGCM.Open, after decrypting sets userID with value
-func Decode(sha string, userID *string) error {
-	dst, err := hex.DecodeString(sha)
+// Decode - decrypts token created by Encode of this or any Keyring sharing key with it.
+func (k *Keyring) Decode(token string) (string, error) {
+	id, encoded, ok := strings.Cut(token, tokenSeparator)
+	if !ok {
+		return "", ErrMalformedToken
+	}
 
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", ErrUnknownTokenKey
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedToken
+	}
+
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
 	if err != nil {
-		fmt.Printf("error: %v\n", err)
-		return err
+		return "", err
 	}
 
-	src, errGCM := aesGCM.Open(nil, nonce, dst, nil)
+	return string(value), nil
+}
+
+// SetKeyring - sets Keyring used by Encode and Decode.
+func SetKeyring(k *Keyring) {
+	keyring.Store(k)
+}
 
-	if errGCM != nil {
-		return errGCM
+// Encode - encrypts provided user ID by active key of keyring set by SetKeyring.
+func Encode(userID string) (string, error) {
+	return keyring.Load().(*Keyring).Encode(userID)
+}
+
+// Decode - decrypts token created by Encode by any key of keyring set by SetKeyring, after decrypting sets userID with value.
+func Decode(sha string, userID *string) error {
+	value, err := keyring.Load().(*Keyring).Decode(sha)
+	if err != nil {
+		return err
 	}
 
-	*userID = string(src)
+	*userID = value
 
 	return nil
 }
 
-// generateRandom - generates random slice of bytes used by aes.Newcipher.
+// generateRandom - generates random slice of bytes used for keys and nonces.
 func generateRandom(size int) ([]byte, error) {
 	b := make([]byte, size)
 	_, err := rand.Read(b)
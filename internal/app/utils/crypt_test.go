@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncode(t *testing.T) {
@@ -55,3 +58,122 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestEncode_RandomNonce(t *testing.T) {
+	first, err := Encode("1")
+	assert.NoError(t, err)
+
+	second, err := Encode("1")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first, second, "each token must be encrypted with its own nonce")
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey := Key{ID: "old", Secret: bytes.Repeat([]byte{1}, 32)}
+	newKey := Key{ID: "new", Secret: bytes.Repeat([]byte{2}, 32)}
+
+	before, err := NewKeyring("old", []Key{oldKey})
+	require.NoError(t, err)
+	after, err := NewKeyring("new", []Key{newKey, oldKey})
+	require.NoError(t, err)
+	other, err := NewKeyring("new", []Key{newKey})
+	require.NoError(t, err)
+
+	oldToken, err := before.Encode("user")
+	require.NoError(t, err)
+	newToken, err := after.Encode("user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		keyring *Keyring
+		wantErr error
+		name    string
+		token   string
+		want    string
+	}{
+		{
+			name:    "Token of previous key can be decoded after rotation",
+			keyring: after,
+			token:   oldToken,
+			want:    "user",
+		},
+		{
+			name:    "Token is encoded by active key",
+			keyring: other,
+			token:   newToken,
+			want:    "user",
+		},
+		{
+			name:    "Token of removed key is rejected",
+			keyring: other,
+			token:   oldToken,
+			wantErr: ErrUnknownTokenKey,
+		},
+		{
+			name:    "Token without key ID is rejected",
+			keyring: after,
+			token:   "deadbeef",
+			wantErr: ErrMalformedToken,
+		},
+		{
+			name:    "Token with swapped key ID is rejected",
+			keyring: after,
+			token:   "new" + strings.TrimPrefix(oldToken, "old"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.Decode(tt.token)
+			if tt.want == "" {
+				assert.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		wantErr error
+		name    string
+		active  string
+		keys    []Key
+	}{
+		{
+			name:   "Keyring can be created",
+			active: "a",
+			keys:   []Key{{ID: "a", Secret: make([]byte, 16)}},
+		},
+		{
+			name:    "Keyring without active key can't be created",
+			active:  "b",
+			keys:    []Key{{ID: "a", Secret: make([]byte, 16)}},
+			wantErr: ErrNoActiveKey,
+		},
+		{
+			name:    "Keyring with short secret can't be created",
+			active:  "a",
+			keys:    []Key{{ID: "a", Secret: make([]byte, 5)}},
+			wantErr: ErrWrongCipherKey,
+		},
+		{
+			name:    "Keyring with unsafe key ID can't be created",
+			active:  "a.b",
+			keys:    []Key{{ID: "a.b", Secret: make([]byte, 16)}},
+			wantErr: ErrWrongCipherKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.active, tt.keys)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -9,6 +9,51 @@ import (
 var (
 	ErrLinksConflict   = errors.New("url has been already stored") // an error that represents duplicate of URL in storage.
 	ErrLinkIsDeleted   = errors.New("url has been deleted")        // an error that represents access to soft deleted URL.
+	ErrLinkNotFound    = errors.New("url has not been found")      // an error that represents access to URL which is not stored.
+	ErrDeleteQueueFull = errors.New("delete queue is full")        // an error that represents overflow of delete queue.
+	ErrDeleteQueueDone = errors.New("delete queue is shut down")   // an error that represents delete request after shutdown.
+	ErrInvalidCursor   = errors.New("invalid cursor")              // an error that represents malformed pagination cursor.
+	ErrWrongLinksQuery = errors.New("wrong links query")           // an error that represents malformed filter of links.
+	ErrUnknownUser     = errors.New("user is not identified")      // an error that represents request without user ID.
+	ErrMalformedToken  = errors.New("malformed token")             // an error that represents token which can't be parsed.
+	ErrUnknownTokenKey = errors.New("unknown token key")           // an error that represents token encrypted by unknown key.
+	ErrWrongCipherKey  = errors.New("wrong cipher key")            // an error that represents malformed key of keyring.
+	ErrNoActiveKey     = errors.New("active key not found")        // an error that represents keyring without active key.
+	ErrInvalidToken    = errors.New("invalid token")               // an error that represents token with wrong format or signature.
+	ErrTokenExpired    = errors.New("token is expired")            // an error that represents expired token.
+	ErrUnknownScope    = errors.New("unknown scope")               // an error that represents request of unknown scope.
+	ErrWrongTokenTTL   = errors.New("wrong token ttl")             // an error that represents request of too long token.
+	ErrWrongSigningKey = errors.New("wrong signing key")           // an error that represents malformed key of tokens.
+	ErrForbidden       = errors.New("forbidden")                   // an error that represents lack of permissions.
+	ErrInvalidAPIKey   = errors.New("invalid api key")             // an error that represents unknown or revoked API key.
+	ErrAPIKeyNotFound  = errors.New("api key not found")           // an error that represents access to API key which does not exist.
+	ErrWrongRateLimit  = errors.New("wrong rate limit")            // an error that represents negative rate limit of API key.
+	ErrRateLimited     = errors.New("rate limit exceeded")         // an error that represents request over rate limit.
+	ErrWrongLogin      = errors.New("wrong login")                 // an error that represents login of wrong length or reserved one.
+	ErrWeakPassword    = errors.New("password must be 8-72 bytes") // an error that represents too short or too long password.
+	ErrLoginTaken      = errors.New("login is already taken")      // an error that represents registration of existing login.
+	ErrBadCredentials  = errors.New("wrong login or password")     // an error that represents failed login.
+	ErrInvalidSession  = errors.New("invalid session")             // an error that represents unknown or expired session.
+	ErrInvalidIDToken  = errors.New("invalid id token")            // an error that represents ID token failed verification.
+	ErrMissingClaim    = errors.New("missing claim")               // an error that represents ID token without user claim.
+	ErrProviderFailed  = errors.New("identity provider failed")    // an error that represents failed request to identity provider.
+	ErrWrongRoleMap    = errors.New("wrong role mapping")          // an error that represents malformed mapping of groups to roles.
+	ErrOIDCState       = errors.New("invalid oidc state")          // an error that represents callback not matching started login.
+	ErrUserBanned      = errors.New("user is banned")              // an error that represents request of user banned by admin.
+	ErrWrongUID        = errors.New("wrong user id")               // an error that represents user ID which is not UUID.
+	ErrNotMember       = errors.New("not a workspace member")      // an error that represents access to workspace by outsider.
+	ErrWrongRole       = errors.New("wrong workspace role")        // an error that represents unknown role of workspace member.
+	ErrLastOwner       = errors.New("workspace needs an owner")    // an error that represents removal of the last owner.
+	ErrWorkspaceName   = errors.New("wrong workspace name")        // an error that represents empty or too long workspace name.
+	ErrInvalidTransfer = errors.New("invalid transfer code")       // an error that represents unknown, used or expired transfer code.
+	ErrTransferMode    = errors.New("wrong transfer mode")         // an error that represents unknown mode of transfer redemption.
+	ErrTamperedCookie  = errors.New("invalid uid cookie")          // an error that represents uid cookie which can't be decrypted.
+	ErrCSRFOrigin      = errors.New("untrusted request origin")    // an error that represents state-changing request from another site.
+	ErrCSRFToken       = errors.New("invalid csrf token")          // an error that represents missing or forged CSRF token.
+	ErrLinksQuota      = errors.New("links quota exceeded")        // an error that represents too many active links of user.
+	ErrDailyQuota      = errors.New("daily links quota exceeded")  // an error that represents too many links created by user today.
+	ErrBatchQuota      = errors.New("batch size quota exceeded")   // an error that represents too large batch of links.
+	ErrWrongQuota      = errors.New("wrong quota")                 // an error that represents negative limit of quota.
 	ErrGRPCWrongUserID = errors.New("wrong ID")
 	ErrGRPCInternal    = errors.New("internal error occurred")
 )
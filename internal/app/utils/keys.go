@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeyFile - a representation of file with cipher keys. Keys are ordered from newest to oldest.
+type KeyFile struct {
+	Active string       `json:"active"`
+	Keys   []KeyFileKey `json:"keys"`
+}
+
+// KeyFileKey - a representation of key in KeyFile. Secret is hex encoded.
+type KeyFileKey struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"`
+}
+
+// GenerateKey - generates random 256-bit key with provided ID.
+func GenerateKey(id string) (KeyFileKey, error) {
+	if !keyIDPattern.MatchString(id) {
+		return KeyFileKey{}, fmt.Errorf("%w: id %q must match %s", ErrWrongCipherKey, id, keyIDPattern)
+	}
+
+	secret, err := generateRandom(2 * aes.BlockSize)
+	if err != nil {
+		return KeyFileKey{}, err
+	}
+
+	return KeyFileKey{ID: id, Secret: hex.EncodeToString(secret), CreatedAt: time.Now().UTC()}, nil
+}
+
+// ReadKeyFile - reads KeyFile from provided path. Missing file is returned as empty KeyFile.
+func ReadKeyFile(path string) (KeyFile, error) {
+	var f KeyFile
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+
+	if err = json.Unmarshal(b, &f); err != nil {
+		return f, fmt.Errorf("%w: %s: %v", ErrWrongCipherKey, path, err)
+	}
+
+	return f, nil
+}
+
+// WriteKeyFile - writes KeyFile to provided path readable only by owner.
+func WriteKeyFile(path string, f KeyFile) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, b, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// ParseKeys - parses keys in format "id:hex,id:hex".
+func ParseKeys(s string) ([]KeyFileKey, error) {
+	var keys []KeyFileKey
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		id, secret, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: key must be in format id:hex", ErrWrongCipherKey)
+		}
+
+		keys = append(keys, KeyFileKey{ID: id, Secret: secret})
+	}
+
+	return keys, nil
+}
+
+// LoadKeyring - creates Keyring from keys in format "id:hex,id:hex" and keys from KeyFile at provided path.
+// Active key is provided one, otherwise active key of KeyFile or the first of inline keys.
+// Returns nil Keyring if there are no keys at all.
+func LoadKeyring(inline string, path string, active string) (*Keyring, error) {
+	keys, err := ParseKeys(inline)
+	if err != nil {
+		return nil, err
+	}
+
+	if active == "" && len(keys) > 0 {
+		active = keys[0].ID
+	}
+
+	if path != "" {
+		f, errFile := ReadKeyFile(path)
+		if errFile != nil {
+			return nil, errFile
+		}
+
+		if active == "" {
+			active = f.Active
+		}
+
+		keys = append(keys, f.Keys...)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	ringKeys := make([]Key, 0, len(keys))
+	for _, key := range keys {
+		secret, errHex := hex.DecodeString(key.Secret)
+		if errHex != nil {
+			return nil, fmt.Errorf("%w: %s: secret must be hex encoded", ErrWrongCipherKey, key.ID)
+		}
+
+		ringKeys = append(ringKeys, Key{ID: key.ID, Secret: secret})
+	}
+
+	return NewKeyring(active, ringKeys)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeyring(t *testing.T) {
+	first, err := GenerateKey("first")
+	require.NoError(t, err)
+	second, err := GenerateKey("second")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, WriteKeyFile(path, KeyFile{Active: "second", Keys: []KeyFileKey{second, first}}))
+
+	inline := first.ID + ":" + first.Secret
+
+	tests := []struct {
+		wantErr    error
+		name       string
+		inline     string
+		path       string
+		active     string
+		wantActive string
+		wantNil    bool
+	}{
+		{
+			name:    "No keyring is created without keys",
+			wantNil: true,
+		},
+		{
+			name:    "No keyring is created for missing file",
+			path:    filepath.Join(t.TempDir(), "missing.json"),
+			wantNil: true,
+		},
+		{
+			name:       "First inline key is active",
+			inline:     inline,
+			wantActive: "first",
+		},
+		{
+			name:       "Active key of file is used",
+			path:       path,
+			wantActive: "second",
+		},
+		{
+			name:       "Provided active key overrides file",
+			path:       path,
+			active:     "first",
+			wantActive: "first",
+		},
+		{
+			name:    "Error is returned on malformed inline keys",
+			inline:  "first",
+			wantErr: ErrWrongCipherKey,
+		},
+		{
+			name:    "Error is returned on non hex secret",
+			inline:  "first:zz",
+			wantErr: ErrWrongCipherKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := LoadKeyring(tt.inline, tt.path, tt.active)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, k)
+				return
+			}
+
+			require.NotNil(t, k)
+			assert.Equal(t, tt.wantActive, k.active)
+		})
+	}
+}